@@ -0,0 +1,241 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveExtension is the extension of archives that can be organized in place of a
+// source folder, without extracting them first
+const ArchiveExtension = ".zip"
+
+// maxBufferedEntry is the largest archive entry read into memory when a handler needs
+// random access to it; larger entries are written to a temporary file instead
+const maxBufferedEntry = 32 << 20
+
+// sourceArchive is a zip file being organized. Its entries are known by paths below the
+// archive's own path, e.g. /imports/trip.zip/DCIM/IMG_0001.jpg, so everything that
+// reports, groups or records files by path treats them like files in a folder.
+type sourceArchive struct {
+	path    string
+	reader  *zip.ReadCloser
+	entries map[string]*zip.File // By slash-separated name within the archive
+}
+
+// isArchive reports whether path is a file that can be organized as an archive
+func isArchive(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ArchiveExtension) {
+		return false
+	}
+	stat, err := os.Stat(path)
+	return err == nil && stat.Mode().IsRegular()
+}
+
+// openSourceArchive opens the archive at path and indexes its entries
+func openSourceArchive(path string) (*sourceArchive, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	archive := &sourceArchive{path: filepath.Clean(path), reader: reader, entries: make(map[string]*zip.File)}
+	for _, entry := range reader.File {
+		archive.entries[strings.TrimPrefix(entry.Name, "/")] = entry
+	}
+	return archive, nil
+}
+
+// findArchiveMediaFiles opens the archive at path as the source of the run and returns
+// the media files in it. The archive stays open until closeArchive.
+func (org *Organizer) findArchiveMediaFiles(path string) ([]string, error) {
+	archive, err := openSourceArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	org.archive = archive
+	org.safeLog(fmt.Sprintf("Reading %s as an archive of %d entries\n", filepath.Base(path), len(archive.reader.File)))
+
+	// Links inside an archive point at paths on whatever disk it was made from
+	return org.scanMediaFiles(&archive.reader.Reader, path, false)
+}
+
+// closeArchive closes the archive opened by findArchiveMediaFiles, if any
+func (org *Organizer) closeArchive() {
+	if org.archive != nil {
+		org.archive.reader.Close()
+		org.archive = nil
+	}
+}
+
+// archiveEntry returns the archive entry at path, when path is inside the open archive
+func (org *Organizer) archiveEntry(path string) (*zip.File, bool) {
+	if org.archive == nil {
+		return nil, false
+	}
+	rel, err := filepath.Rel(org.archive.path, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil, false
+	}
+	entry, ok := org.archive.entries[filepath.ToSlash(rel)]
+	return entry, ok
+}
+
+// openSource opens a source file for reading, from the archive when it's in one
+func (org *Organizer) openSource(path string) (io.ReadCloser, error) {
+	if entry, ok := org.archiveEntry(path); ok {
+		return entry.Open()
+	}
+	return os.Open(path)
+}
+
+// statSource returns the size and modification time of a source file, from the archive
+// when it's in one
+func (org *Organizer) statSource(path string) (fs.FileInfo, error) {
+	if entry, ok := org.archiveEntry(path); ok {
+		return entry.FileInfo(), nil
+	}
+	return os.Stat(path)
+}
+
+// readSource returns the contents of a small source file, such as a sidecar
+func (org *Organizer) readSource(path string) ([]byte, error) {
+	if org.archive != nil && isWithinFolder(path, org.archive.path) {
+		entry, ok := org.archiveEntry(path)
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	}
+	return os.ReadFile(path)
+}
+
+// seekableSource is a source file opened for random access
+type seekableSource interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+}
+
+// bufferedEntry is an archive entry read into memory
+type bufferedEntry struct{ *bytes.Reader }
+
+// Close does nothing: the memory goes with the reader
+func (bufferedEntry) Close() error { return nil }
+
+// spooledEntry is an archive entry written to a temporary file, removed on Close
+type spooledEntry struct{ *os.File }
+
+// Close closes and removes the temporary file
+func (entry spooledEntry) Close() error {
+	err := entry.File.Close()
+	os.RemoveAll(filepath.Dir(entry.Name()))
+	return err
+}
+
+// openSourceSeekable opens a source file for random access. Compressed archive entries
+// can only be read in order, so small ones are read into memory and larger ones are
+// written to a temporary file.
+func (org *Organizer) openSourceSeekable(path string) (seekableSource, error) {
+	entry, ok := org.archiveEntry(path)
+	if !ok {
+		return os.Open(path)
+	}
+	if entry.UncompressedSize64 <= maxBufferedEntry {
+		reader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		return bufferedEntry{bytes.NewReader(data)}, nil
+	}
+
+	spooled, err := org.spoolEntry(entry, path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(spooled)
+	if err != nil {
+		os.RemoveAll(filepath.Dir(spooled))
+		return nil, err
+	}
+	return spooledEntry{file}, nil
+}
+
+// spoolEntry writes the archive entry at path to a file of the same name in a new
+// temporary folder, for tools like exiftool that only read files on disk. The caller
+// removes the folder.
+func (org *Organizer) spoolEntry(entry *zip.File, path string) (string, error) {
+	dir, err := os.MkdirTemp("", "media-organizer-entry-*")
+	if err != nil {
+		return "", err
+	}
+	spooled := filepath.Join(dir, filepath.Base(path))
+
+	reader, err := entry.Open()
+	if err == nil {
+		var file *os.File
+		if file, err = os.Create(spooled); err == nil {
+			_, err = io.Copy(file, reader)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		reader.Close()
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	// exiftool falls back to the file's modification time for entries without a date
+	if err := os.Chtimes(spooled, entry.Modified, entry.Modified); err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not keep the modification time of %s: %v\n", filepath.Base(path), err))
+	}
+	return spooled, nil
+}
+
+// errNeedsFile is returned by a ReaderMediaHandler that can only finish reading a file
+// from disk, such as with exiftool; the file is then read again from a temporary copy
+var errNeedsFile = errors.New("the file must be read from disk")
+
+// readArchivedInfo reads the metadata of the archive entry at path with handler. Handlers
+// that accept a reader read it in memory or from a temporary file when it's large; the
+// others, which pass the file to exiftool, read a temporary copy of it.
+func (org *Organizer) readArchivedInfo(handler MediaHandler, entry *zip.File, path string) (*ImageInfo, error) {
+	if readerHandler, ok := handler.(ReaderMediaHandler); ok {
+		source, err := org.openSourceSeekable(path)
+		if err != nil {
+			return nil, err
+		}
+		info, err := readerHandler.ExtractInfoFrom(source, int64(entry.UncompressedSize64), path)
+		source.Close()
+		if !errors.Is(err, errNeedsFile) {
+			return info, err
+		}
+	}
+
+	spooled, err := org.spoolEntry(entry, path)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(filepath.Dir(spooled))
+	info, err := handler.ExtractInfo(spooled)
+
+	// Report exiftool's problems with the copy against the entry
+	org.runStats.MoveProblem(spooled, path)
+	return info, err
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// What happens to the frames of a burst other than the best one
+const (
+	BurstKeepAll    = "Keep all frames"
+	BurstKeepBest   = "Keep only the best frame"
+	BurstRejectRest = "Move other frames to _Rejects"
+)
+
+// How the best frame of a burst is chosen
+const (
+	BestSharpest   = "Sharpest"
+	BestResolution = "Highest resolution"
+	BestLargest    = "Largest file"
+)
+
+const (
+	// RejectsFolder holds the frames of bursts that weren't chosen, when they are kept
+	RejectsFolder = "_Rejects"
+	// burstFrameGap is the longest pause between two shots of the same burst
+	burstFrameGap = time.Second
+	// sharpnessSize is the longest edge frames are scaled to before measuring sharpness,
+	// so frames of different resolutions are judged alike
+	sharpnessSize = 512
+)
+
+// burstFrame is a photo of a burst with its score under the chosen heuristic
+type burstFrame struct {
+	info  *ImageInfo
+	score float64
+	size  int64
+}
+
+// findBursts returns the bursts in infos, which must be sorted by capture time: runs of
+// two or more still photos from the same camera, each taken within burstFrameGap of the
+// one before. Only capture times from metadata count, since filename and file dates
+// are too coarse, and photos with an edit or Live Photo movie are left out so that a
+// companion never loses its photo.
+func (org *Organizer) findBursts(infos []*ImageInfo, companions map[string]bool) [][]*ImageInfo {
+	var bursts [][]*ImageInfo
+	current := make(map[string][]*ImageInfo) // Burst in progress per camera
+
+	flush := func(device string) {
+		if frames := current[device]; len(frames) > 1 {
+			bursts = append(bursts, frames)
+		}
+		delete(current, device)
+	}
+
+	for _, info := range infos {
+		if kind := org.mediaKind(info.OriginalPath); kind != MediaImage && kind != MediaHEIF {
+			continue
+		}
+		if info.DateSource != DateSourceMetadata {
+			continue
+		}
+		if key, _ := companionKey(info.OriginalPath); companions[key] {
+			continue
+		}
+
+		device := info.CameraMake + " " + info.CameraModel
+		if frames := current[device]; len(frames) > 0 && info.Date.Sub(frames[len(frames)-1].Date) > burstFrameGap {
+			flush(device)
+		}
+		current[device] = append(current[device], info)
+	}
+	for device := range current {
+		flush(device)
+	}
+
+	// Flushing the map leaves bursts from different cameras in random order
+	sort.Slice(bursts, func(i, j int) bool {
+		return bursts[i][0].Date.Before(bursts[j][0].Date)
+	})
+	return bursts
+}
+
+// selectBurstFrames picks the best frame of each burst in every cluster with the chosen
+// heuristic and, depending on the burst policy, drops the other frames or sends them
+// to RejectsFolder. Every decision is logged. It returns how many frames were passed over.
+func (org *Organizer) selectBurstFrames(clusterInfos [][]*ImageInfo) int {
+	if org.burstPolicy == BurstKeepAll || org.burstPolicy == "" {
+		return 0
+	}
+
+	// Photos with a companion, by companion key
+	companions := make(map[string]bool)
+	for _, infos := range clusterInfos {
+		for _, info := range infos {
+			if key, primary := companionKey(info.OriginalPath); key != "" && !primary {
+				companions[key] = true
+			}
+		}
+	}
+
+	passedOver := 0
+	for i, infos := range clusterInfos {
+		rejected := make(map[*ImageInfo]bool)
+		for _, burst := range org.findBursts(infos, companions) {
+			best := org.bestFrame(burst)
+
+			var others []string
+			for _, info := range burst {
+				if info != best {
+					rejected[info] = true
+					others = append(others, filepath.Base(info.OriginalPath))
+				}
+			}
+			action := "skipping"
+			if org.burstPolicy == BurstRejectRest {
+				action = "moving to " + RejectsFolder
+			}
+			org.safeLog(fmt.Sprintf("Burst of %d frames at %s: keeping %s (%s), %s %s\n",
+				len(burst), best.Date.Format("2006-01-02 15:04:05"), filepath.Base(best.OriginalPath),
+				strings.ToLower(org.burstHeuristic), action, strings.Join(others, ", ")))
+		}
+		if len(rejected) == 0 {
+			continue
+		}
+
+		passedOver += len(rejected)
+		if org.burstPolicy == BurstRejectRest {
+			for info := range rejected {
+				info.BurstReject = true
+			}
+			continue
+		}
+		kept := infos[:0]
+		for _, info := range infos {
+			if !rejected[info] {
+				kept = append(kept, info)
+			}
+		}
+		clusterInfos[i] = kept
+	}
+	return passedOver
+}
+
+// bestFrame returns the frame of burst that scores highest under the burst heuristic.
+// Ties, and frames that couldn't be scored, are settled by file size and then by which
+// came first.
+func (org *Organizer) bestFrame(burst []*ImageInfo) *ImageInfo {
+	var best burstFrame
+	for i, info := range burst {
+		frame := burstFrame{info: info, score: -1}
+		if stat, err := org.statSource(info.OriginalPath); err == nil {
+			frame.size = stat.Size()
+		}
+		if score, err := org.frameScore(info.OriginalPath, frame.size); err == nil {
+			frame.score = score
+		} else {
+			org.safeLog(fmt.Sprintf("Warning: Could not judge burst frame %s: %v\n", filepath.Base(info.OriginalPath), err))
+		}
+
+		if i == 0 || frame.score > best.score || (frame.score == best.score && frame.size > best.size) {
+			best = frame
+		}
+	}
+	return best.info
+}
+
+// frameScore rates a burst frame under the burst heuristic; higher is better. Neither score
+// depends on the EXIF orientation: resolution is a pixel count, and the Laplacian is
+// the same turned either way, so portrait and landscape frames compare fairly.
+func (org *Organizer) frameScore(path string, size int64) (float64, error) {
+	if org.burstHeuristic == BestLargest {
+		return float64(size), nil
+	}
+
+	file, err := org.openSourceSeekable(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	if org.burstHeuristic == BestResolution {
+		config, _, err := image.DecodeConfig(file)
+		if err != nil {
+			return 0, err
+		}
+		return float64(config.Width) * float64(config.Height), nil
+	}
+
+	img, _, err := decodeImage(file, path)
+	if err != nil {
+		return 0, err
+	}
+	return sharpness(scaleToFit(img, sharpnessSize)), nil
+}
+
+// sharpness returns the variance of the Laplacian of img's luminance. Blurred or
+// shaken frames have few crisp edges, so their Laplacian is flat and its variance low.
+func sharpness(img image.Image) float64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 3 || height < 3 {
+		return 0
+	}
+
+	luma := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			r, g, b, _ := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			luma[y*width+x] = 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+		}
+	}
+
+	var sum, sumSquares float64
+	for y := 1; y < height-1; y++ {
+		for x := 1; x < width-1; x++ {
+			i := y*width + x
+			laplacian := luma[i-width] + luma[i+width] + luma[i-1] + luma[i+1] - 4*luma[i]
+			sum += laplacian
+			sumSquares += laplacian * laplacian
+		}
+	}
+	n := float64((width - 2) * (height - 2))
+	mean := sum / n
+	return sumSquares/n - mean*mean
+}
@@ -0,0 +1,11 @@
+//go:build darwin
+
+package main
+
+import "golang.org/x/sys/unix"
+
+// cloneFile makes dst a copy-on-write clone of src with clonefile(2), which APFS
+// supports within a single volume
+func cloneFile(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
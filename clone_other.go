@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package main
+
+import "errors"
+
+// cloneFile reports that copy-on-write clones aren't supported on this platform
+func cloneFile(src, dst string) error {
+	return errors.New("file cloning is not supported on this platform")
+}
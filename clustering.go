@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// AutoNameDecimals names clusters with just enough decimals to tell grid cells apart
+	AutoNameDecimals = 0
+	// fileLocationDecimals is the precision of a single file's location (about 11m)
+	fileLocationDecimals = 4
+	// maxNameDecimals is the most decimals used to tell clashing cluster names apart
+	maxNameDecimals = 6
+)
+
+// ClusterStrategy holds the clustering settings other than sensitivity: how elevation
+// splits clusters and where files without GPS go
+type ClusterStrategy struct {
+	ElevationBand float64       // Split clusters into bands this many meters tall (0 to ignore elevation)
+	NoGPSPolicy   string        // One of the NoGPS* policies
+	NoGPSWindow   time.Duration // How far in time the borrowing and interpolating policies look
+	NameDecimals  int           // Decimal places in cluster names (AutoNameDecimals to follow sensitivity)
+	Places        []NamedPlace  // Labeled places; clusters centered in one take its name
+	SplitCells    bool          // Split cells whose images form clearly separate groups
+	MinSize       int           // Merge clusters with fewer images into nearby ones (below 2 to keep them all)
+}
+
+// clusterStrategy returns the organizer's current clustering settings
+func (org *Organizer) clusterStrategy() ClusterStrategy {
+	return ClusterStrategy{
+		ElevationBand: org.elevationBand,
+		NoGPSPolicy:   org.noGPSPolicy,
+		NoGPSWindow:   org.noGPSWindow,
+		NameDecimals:  org.nameDecimals,
+		Places:        org.namedPlaces,
+		SplitCells:    org.splitCells,
+		MinSize:       org.minClusterSize,
+	}
+}
+
+// newClusterGrid returns an empty spatial grid set up for strategy
+func newClusterGrid(sensitivity float64, strategy ClusterStrategy) *SpatialGrid {
+	grid := NewSpatialGrid(sensitivity)
+	grid.recordTimeline = strategy.NoGPSPolicy == NoGPSNearestInTime || strategy.NoGPSPolicy == NoGPSInterpolate
+	grid.elevationBand = strategy.ElevationBand
+	grid.nameDecimals = strategy.NameDecimals
+	grid.places = strategy.Places
+	grid.splitCells = strategy.SplitCells
+	return grid
+}
+
+// LocateWithoutGPS gives images without GPS a location from the images around them in
+// time, when strategy's no-GPS policy asks for one. It returns how many images borrowed
+// a location and the estimates made by interpolation.
+func (sg *SpatialGrid) LocateWithoutGPS(strategy ClusterStrategy) (int, []locationEstimate) {
+	switch strategy.NoGPSPolicy {
+	case NoGPSNearestInTime:
+		return sg.BorrowNearestLocations(strategy.NoGPSWindow), nil
+	case NoGPSInterpolate:
+		estimates := sg.InterpolateLocations(strategy.NoGPSWindow)
+		return len(estimates), estimates
+	}
+	return 0, nil
+}
+
+// ClusterImages groups infos into location clusters on a grid of sensitivity degrees,
+// exactly as a run would, without an organizer, logging or UI. infos are not modified.
+// A run builds the same grid batch by batch so it never holds every file's metadata;
+// this is the one-shot form for callers that already have it all in memory.
+func ClusterImages(infos []*ImageInfo, sensitivity float64, strategy ClusterStrategy) []LocationCluster {
+	grid := newClusterGrid(sensitivity, strategy)
+	for _, info := range infos {
+		grid.AddImage(info)
+	}
+	grid.LocateWithoutGPS(strategy)
+	grid.SplitSeparatedCells()
+	clusters, _ := mergeStrayClusters(grid.GetClusters(), strategy.MinSize)
+	return clusters
+}
+
+// locationDecimals returns the decimal places a name needs for its last digit to be no
+// coarser than a grid of sensitivity degrees: 3 for the default 0.001, 2 for 0.01
+func locationDecimals(sensitivity float64) int {
+	if sensitivity <= 0 {
+		return fileLocationDecimals
+	}
+	// The epsilon keeps exact powers of ten like 0.001 from rounding up a place
+	return min(max(int(math.Ceil(-math.Log10(sensitivity)-1e-9)), 0), maxNameDecimals)
+}
+
+// clusterNames names each located cell by its center, using the grid's name precision.
+// Cells whose names clash at that precision get more decimals until they differ, and
+// any still clashing at maxNameDecimals get a numeric suffix, so every located cluster
+// of a run has its own folder. Cells centered in a labeled place are named after it
+// instead and returned in placed, as they share the place's folder. The caller must
+// hold the mutex.
+func (sg *SpatialGrid) clusterNames() (names map[string]string, placed map[string]NamedPlace) {
+	decimals := sg.nameDecimals
+	if decimals == AutoNameDecimals {
+		decimals = locationDecimals(sg.sensitivity)
+	}
+
+	banded := func(cell *GridCell, name string) string {
+		if cell.HasBand {
+			// Name the band by its lower bound so clusters at different heights get separate folders
+			name = fmt.Sprintf("%s_%.0fm", name, float64(cell.Band)*sg.elevationBand)
+		}
+		return sanitizePathSegment(name)
+	}
+	name := func(cell *GridCell, decimals int) string {
+		lat, lng := cell.Center()
+		return banded(cell, formatLocation(lat, lng, decimals))
+	}
+
+	names = make(map[string]string, len(sg.cells))
+	placed = make(map[string]NamedPlace)
+	for key, cell := range sg.cells {
+		if key == noLocationKey {
+			continue
+		}
+		lat, lng := cell.Center()
+		if place, ok := placeAt(sg.places, lat, lng); ok {
+			placed[key] = place
+			continue
+		}
+		names[key] = name(cell, decimals)
+	}
+	for extra := decimals + 1; extra <= maxNameDecimals; extra++ {
+		clashing := clashingKeys(names)
+		if len(clashing) == 0 {
+			break
+		}
+		for _, key := range clashing {
+			names[key] = name(sg.cells[key], extra)
+		}
+	}
+
+	// Sorted, so the suffixes are the same on every run
+	taken := make(map[string]bool, len(names))
+	for _, name := range names {
+		taken[name] = true
+	}
+	clashing := clashingKeys(names)
+	sort.Strings(clashing)
+	seen := make(map[string]bool)
+	for _, key := range clashing {
+		base := names[key]
+		if !seen[base] {
+			seen[base] = true // The first keeps the plain name
+			continue
+		}
+		for n := 2; ; n++ {
+			if candidate := fmt.Sprintf("%s_%d", base, n); !taken[candidate] {
+				names[key], taken[candidate] = candidate, true
+				break
+			}
+		}
+	}
+
+	for key, place := range placed {
+		names[key] = banded(sg.cells[key], place.Name)
+	}
+	return names, placed
+}
+
+// clashingKeys returns the keys of names whose names are shared with another key,
+// ignoring case since folder names are case-insensitive on many filesystems
+func clashingKeys(names map[string]string) []string {
+	counts := make(map[string]int, len(names))
+	for _, name := range names {
+		counts[strings.ToLower(name)]++
+	}
+
+	var clashing []string
+	for key, name := range names {
+		if counts[strings.ToLower(name)] > 1 {
+			clashing = append(clashing, key)
+		}
+	}
+	return clashing
+}
+
+// unitVector converts a position in degrees to a point on the unit sphere
+func unitVector(lat, lng float64) (x, y, z float64) {
+	latRad, lngRad := lat*math.Pi/180, lng*math.Pi/180
+	return math.Cos(latRad) * math.Cos(lngRad), math.Cos(latRad) * math.Sin(lngRad), math.Sin(latRad)
+}
+
+// fromUnitVector converts a direction (of any length, e.g. a sum of unit vectors) back to
+// a position in degrees. The zero vector, from points that cancel out, maps to 0,0.
+func fromUnitVector(x, y, z float64) (lat, lng float64) {
+	if x == 0 && y == 0 && z == 0 {
+		return 0, 0
+	}
+	return math.Atan2(z, math.Hypot(x, y)) * 180 / math.Pi, math.Atan2(y, x) * 180 / math.Pi
+}
+
+// longitudeDelta returns the shortest eastward change in longitude from one longitude to
+// another, negative when west is shorter, so a move across the antimeridian is small
+func longitudeDelta(from, to float64) float64 {
+	return wrapLongitude(to - from)
+}
+
+// wrapLongitude brings a longitude into [-180, 180)
+func wrapLongitude(lng float64) float64 {
+	return math.Mod(math.Mod(lng+180, 360)+360, 360) - 180
+}
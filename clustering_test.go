@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// syntheticClusterInputs returns count synthetic photo records ready for clustering,
+// with the file locations a run would give them
+func syntheticClusterInputs(count int) []*ImageInfo {
+	infos := syntheticImages(count, 1)
+	for _, info := range infos {
+		if info.HasGPS {
+			info.Location = formatLocation(info.Latitude, info.Longitude, fileLocationDecimals)
+		}
+	}
+	return infos
+}
+
+func TestClusterImagesMatchesSimulate(t *testing.T) {
+	org := NewOrganizer(nil)
+	org.minClusterSize = 3
+	report := org.Simulate(5000, 1)
+	clusters := ClusterImages(syntheticClusterInputs(5000), org.locationSensitivity, org.clusterStrategy())
+
+	largest := 0
+	for _, cluster := range clusters {
+		largest = max(largest, len(cluster.Images))
+	}
+	if len(clusters) != report.Clusters || largest != report.LargestCount {
+		t.Errorf("ClusterImages made %d clusters (largest %d), a simulated run %d (largest %d)",
+			len(clusters), largest, report.Clusters, report.LargestCount)
+	}
+}
+
+func TestGetClustersIsDeterministic(t *testing.T) {
+	infos := syntheticClusterInputs(2000)
+	// Photos taken at the same moment in two places, and photos without GPS taken then
+	// too, which borrow one of those places
+	moment := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	for i, position := range [][2]float64{{48.8566, 2.3522}, {45.764, 4.8357}, {48.8566, 2.3522}} {
+		infos = append(infos, &ImageInfo{OriginalPath: fmt.Sprintf("/tied/gps%d.jpg", i), Date: moment, HasGPS: true,
+			Latitude: position[0], Longitude: position[1], Location: formatLocation(position[0], position[1], fileLocationDecimals)})
+	}
+	for i := 0; i < 3; i++ {
+		infos = append(infos, &ImageInfo{OriginalPath: fmt.Sprintf("/tied/nogps%d.jpg", i), Date: moment, Location: NoLocationClusterName})
+	}
+
+	org := NewOrganizer(nil)
+	org.noGPSPolicy = NoGPSNearestInTime
+	build := func(infos []*ImageInfo) *SpatialGrid {
+		grid := newClusterGrid(org.locationSensitivity, org.clusterStrategy())
+		for _, info := range infos {
+			grid.AddImage(info)
+		}
+		grid.LocateWithoutGPS(org.clusterStrategy())
+		return grid
+	}
+
+	// The same grid is read the same way every time, as is a grid built the same way
+	grid := build(infos)
+	want := grid.GetClusters()
+	for run := 0; run < 5; run++ {
+		if got := grid.GetClusters(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("GetClusters returned something else on call %d", run+2)
+		}
+	}
+	if got := build(infos).GetClusters(); !reflect.DeepEqual(got, want) {
+		t.Fatal("a grid built from the same files returned other clusters")
+	}
+
+	// Files added in another order only change the last bits of the centers
+	names := func(clusters []LocationCluster) map[string][]string {
+		images := make(map[string][]string)
+		for _, cluster := range clusters {
+			images[cluster.Name] = cluster.Images
+		}
+		return images
+	}
+	shuffled := slices.Clone(infos)
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	if !reflect.DeepEqual(names(build(shuffled).GetClusters()), names(want)) {
+		t.Error("files added in another order were clustered differently")
+	}
+}
+
+func BenchmarkClusterImages(b *testing.B) {
+	org := NewOrganizer(nil)
+	strategy := org.clusterStrategy()
+	for _, count := range []int{10000, 100000, 1000000} {
+		b.Run(fmt.Sprintf("%d", count), func(b *testing.B) {
+			infos := syntheticClusterInputs(count)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ClusterImages(infos, org.locationSensitivity, strategy)
+			}
+		})
+	}
+}
+
+func TestCellCenterIsExactMean(t *testing.T) {
+	// Many points scattered across one cell of the default grid; over so small an area
+	// the mean direction and the mean of the degrees agree far below name precision
+	rng := rand.New(rand.NewSource(1))
+	points := make([]cellPoint, 50000)
+	latSum, lngSum := new(big.Float), new(big.Float)
+	for i := range points {
+		points[i] = cellPoint{Path: fmt.Sprint(i), Lat: 48.8565 + (rng.Float64()-0.5)*0.0008, Lng: 2.3525 + (rng.Float64()-0.5)*0.0008}
+		latSum.Add(latSum, big.NewFloat(points[i].Lat))
+		lngSum.Add(lngSum, big.NewFloat(points[i].Lng))
+	}
+	count := big.NewFloat(float64(len(points)))
+	wantLat, _ := latSum.Quo(latSum, count).Float64()
+	wantLng, _ := lngSum.Quo(lngSum, count).Float64()
+
+	var centers [][2]float64
+	for run := 0; run < 3; run++ {
+		cell := &GridCell{}
+		for _, point := range points {
+			cell.addPoint(point, false)
+		}
+		lat, lng := cell.Center()
+		if math.Abs(lat-wantLat) > 1e-9 || math.Abs(lng-wantLng) > 1e-9 {
+			t.Errorf("center %.10f, %.10f, want the mean %.10f, %.10f", lat, lng, wantLat, wantLng)
+		}
+		centers = append(centers, [2]float64{lat, lng})
+		rng.Shuffle(len(points), func(i, j int) { points[i], points[j] = points[j], points[i] })
+	}
+
+	// Insertion order only changes the last bits, never a name
+	for _, center := range centers[1:] {
+		if got, want := formatLocation(center[0], center[1], maxNameDecimals), formatLocation(centers[0][0], centers[0][1], maxNameDecimals); got != want {
+			t.Errorf("shuffled points centered at %s, want %s", got, want)
+		}
+	}
+}
+
+func TestCellCenterOnTheGlobe(t *testing.T) {
+	tests := []struct {
+		name     string
+		points   [][2]float64
+		lat, lng float64
+	}{
+		{"Fiji, across the antimeridian", [][2]float64{{-17, 179}, {-17, -179}}, -17.0024, 180},
+		{"lopsided across the antimeridian", [][2]float64{{-17, 179.9}, {-17, 179.9}, {-17, -179.6}}, -17, -179.9333},
+		{"beside the pole", [][2]float64{{89.9, 10}, {89.9, 20}}, 89.9004, 15},
+		{"around the pole", [][2]float64{{89.9, 0}, {89.9, 90}, {89.9, 180}, {89.9, -90}}, 90, 0},
+		{"far from both", [][2]float64{{48, 2}, {48, 4}}, 48.0044, 3},
+	}
+	for _, tt := range tests {
+		cell := &GridCell{}
+		for _, point := range tt.points {
+			cell.addPoint(cellPoint{Lat: point[0], Lng: point[1]}, false)
+		}
+		lat, lng := cell.Center()
+		lngError := math.Abs(wrapLongitude(lng - tt.lng))
+		if tt.lat == 90 {
+			lngError = 0 // Any longitude is the pole
+		}
+		if math.Abs(lat-tt.lat) > 1e-3 || lngError > 1e-3 {
+			t.Errorf("%s: centered at %.4f, %.4f, want %.4f, %.4f", tt.name, lat, lng, tt.lat, tt.lng)
+		}
+	}
+}
+
+func TestLocationDecimals(t *testing.T) {
+	tests := []struct {
+		sensitivity float64
+		want        int
+	}{
+		{0.00045, 4}, // Street
+		{0.001, 3},   // The default
+		{0.005, 3},
+		{0.01, 2},
+		{0.1, 1},
+		{1, 0},
+		{0.0000001, maxNameDecimals},
+		{0, fileLocationDecimals},
+	}
+	for _, tt := range tests {
+		if got := locationDecimals(tt.sensitivity); got != tt.want {
+			t.Errorf("sensitivity %g: %d decimals, want %d", tt.sensitivity, got, tt.want)
+		}
+	}
+}
+
+func TestClashingNamesGetMoreDecimals(t *testing.T) {
+	// Photos either side of a cell edge, whose cells' centers round to one name
+	var infos []*ImageInfo
+	for i, lat := range []float64{48.8559, 48.8559, 48.8561, 48.8561} {
+		infos = append(infos, &ImageInfo{OriginalPath: fmt.Sprintf("%d.jpg", i), HasGPS: true, Latitude: lat, Longitude: 2.3525})
+	}
+	clusters := ClusterImages(infos, 0.001, ClusterStrategy{})
+	if len(clusters) != 2 {
+		t.Fatalf("%d clusters, want one per cell", len(clusters))
+	}
+	if clusters[0].Name == clusters[1].Name || clusters[0].Name != "48.8559N_2.3525E" || clusters[1].Name != "48.8561N_2.3525E" {
+		t.Errorf("clusters named %s and %s, want 48.8559N_2.3525E and 48.8561N_2.3525E", clusters[0].Name, clusters[1].Name)
+	}
+}
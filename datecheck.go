@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Capture dates past now plus FutureDateTolerance, or before EarliestPlausibleDate, come
+// from a camera whose clock was never set or has gone wrong. The tolerance covers a file
+// dated in local time somewhere ahead of the computer's timezone.
+const (
+	CheckDateFolder     = "CheckDate"
+	FutureDateTolerance = 24 * time.Hour
+)
+
+// EarliestPlausibleDate is the earliest capture date taken at face value
+var EarliestPlausibleDate = time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// What happens to files with an implausible capture date
+const (
+	SuspectDateFlag   = "Organize as dated and list them"
+	SuspectDateClamp  = "Clamp to the nearest plausible date"
+	SuspectDateFolder = "Send to " + CheckDateFolder
+)
+
+// implausibleDate returns why date can't be a real capture date at now, if it can't
+func implausibleDate(date, now time.Time) (string, bool) {
+	switch {
+	case date.After(now.Add(FutureDateTolerance)):
+		return "in the future", true
+	case date.Before(EarliestPlausibleDate):
+		return fmt.Sprintf("before %d", EarliestPlausibleDate.Year()), true
+	}
+	return "", false
+}
+
+// checkCaptureDates finds the files whose date is implausible at now, logs and records
+// each with its date, and clamps the date or flags the file for CheckDateFolder as the
+// suspect date policy says. It returns how many files it found.
+func (org *Organizer) checkCaptureDates(clusterInfos [][]*ImageInfo, now time.Time) int {
+	suspect := 0
+	for _, infos := range clusterInfos {
+		for _, info := range infos {
+			if info.DateSource == "" {
+				continue // Dated by the run itself
+			}
+			why, ok := implausibleDate(info.Date, now)
+			if !ok {
+				continue
+			}
+			suspect++
+
+			reason := fmt.Sprintf("the date %s (from %s) is %s", info.Date.Format("2006-01-02 15:04"), strings.ToLower(info.DateSource), why)
+			org.runStats.RecordSuspectDate(info.OriginalPath, reason)
+			switch org.suspectDatePolicy {
+			case SuspectDateClamp:
+				if info.Date.After(now) {
+					info.Date = now
+				} else {
+					info.Date = EarliestPlausibleDate
+				}
+				reason += fmt.Sprintf("; dated %s instead", info.Date.Format("2006-01-02"))
+			case SuspectDateFolder:
+				info.CheckDate = true
+				reason += "; sent to " + CheckDateFolder
+			}
+			org.safeLog(fmt.Sprintf("Warning: Implausible date for %s: %s\n", filepath.Base(info.OriginalPath), reason))
+		}
+	}
+	return suspect
+}
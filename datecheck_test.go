@@ -0,0 +1,68 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestImplausibleDate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		date time.Time
+		why  string
+	}{
+		{now, ""},
+		{now.Add(FutureDateTolerance), ""}, // A timezone ahead of the computer's
+		{now.Add(FutureDateTolerance + time.Second), "in the future"},
+		{EarliestPlausibleDate, ""},
+		{EarliestPlausibleDate.Add(-time.Second), "before 1990"},
+		{time.Time{}, "before 1990"}, // A camera clock never set
+	}
+	for _, tt := range tests {
+		why, ok := implausibleDate(tt.date, now)
+		if why != tt.why || ok != (tt.why != "") {
+			t.Errorf("%v: implausible %v (%q), want %q", tt.date, ok, why, tt.why)
+		}
+	}
+}
+
+func TestCheckCaptureDates(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	future := now.AddDate(1, 0, 0)
+	past := time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	plausible := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		policy    string
+		want      []time.Time // Dates after the check: future, past, plausible, undated
+		checkDate bool
+	}{
+		{SuspectDateFlag, []time.Time{future, past, plausible, past}, false},
+		{SuspectDateClamp, []time.Time{now, EarliestPlausibleDate, plausible, past}, false},
+		{SuspectDateFolder, []time.Time{future, past, plausible, past}, true},
+	}
+	for _, tt := range tests {
+		org := NewOrganizer(nil)
+		org.suspectDatePolicy = tt.policy
+		infos := []*ImageInfo{
+			{OriginalPath: "/photos/future.jpg", Date: future, DateSource: DateSourceMetadata},
+			{OriginalPath: "/photos/past.jpg", Date: past, DateSource: DateSourceModTime},
+			{OriginalPath: "/photos/plausible.jpg", Date: plausible, DateSource: DateSourceMetadata},
+			{OriginalPath: "/photos/undated.jpg", Date: past}, // Dated by the run, never checked
+		}
+
+		if suspect := org.checkCaptureDates([][]*ImageInfo{infos[:2], infos[2:]}, now); suspect != 2 {
+			t.Errorf("%s: %d suspect dates, want 2", tt.policy, suspect)
+		}
+		for i, info := range infos {
+			suspect := i < 2
+			if !info.Date.Equal(tt.want[i]) || info.CheckDate != (suspect && tt.checkDate) {
+				t.Errorf("%s: %s dated %v, for %s %v; want %v, %v", tt.policy, info.OriginalPath, info.Date,
+					CheckDateFolder, info.CheckDate, tt.want[i], suspect && tt.checkDate)
+			}
+			if _, recorded := org.runStats.SuspectDates[info.OriginalPath]; recorded != suspect {
+				t.Errorf("%s: %s recorded %v, want %v", tt.policy, info.OriginalPath, recorded, suspect)
+			}
+		}
+	}
+}
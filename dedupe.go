@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// duplicateCandidate is a file about to be copied, with the cluster it would go into
+type duplicateCandidate struct {
+	info    *ImageInfo
+	cluster int
+}
+
+// locationRank rates how well a file is placed by its cluster: its own GPS position
+// beats one borrowed from neighboring photos, which beats no location at all
+func locationRank(cluster LocationCluster, info *ImageInfo) int {
+	switch {
+	case cluster.HasLocation && info.HasGPS:
+		return 2
+	case cluster.HasLocation:
+		return 1
+	}
+	return 0
+}
+
+// dedupeAcrossClusters makes sure each distinct file content is copied only once, even
+// when copies of it landed in different clusters (say one with GPS and one stripped of
+// it). Of each set of identical files the one in the best located cluster is kept, the
+// first in cluster order when they tie, and the others are dropped from their clusters
+// and remembered on the kept file's Duplicates. Only files whose size matches another's
+// are hashed. It returns how many files were dropped.
+func (org *Organizer) dedupeAcrossClusters(locationClusters []LocationCluster, clusterInfos [][]*ImageInfo) int {
+	bySize := make(map[int64][]duplicateCandidate)
+	for i, infos := range clusterInfos {
+		for _, info := range infos {
+			if stat, err := org.statSource(info.OriginalPath); err == nil {
+				bySize[stat.Size()] = append(bySize[stat.Size()], duplicateCandidate{info, i})
+			}
+		}
+	}
+
+	dropped := make(map[*ImageInfo]bool)
+	for _, candidates := range bySize {
+		if len(candidates) < 2 {
+			continue
+		}
+
+		byHash := make(map[string][]duplicateCandidate)
+		var hashes []string // In first-seen order, so the log reads the same on every run
+		for _, candidate := range candidates {
+			hash, err := org.sourceSHA256(candidate.info.OriginalPath)
+			if err != nil {
+				continue
+			}
+			if _, seen := byHash[hash]; !seen {
+				hashes = append(hashes, hash)
+			}
+			byHash[hash] = append(byHash[hash], candidate)
+		}
+
+		for _, hash := range hashes {
+			identical := byHash[hash]
+			if len(identical) < 2 {
+				continue
+			}
+
+			kept := identical[0]
+			for _, candidate := range identical[1:] {
+				rank, keptRank := locationRank(locationClusters[candidate.cluster], candidate.info), locationRank(locationClusters[kept.cluster], kept.info)
+				if rank > keptRank || (rank == keptRank && candidate.cluster < kept.cluster) {
+					kept = candidate
+				}
+			}
+
+			for _, candidate := range identical {
+				if candidate == kept {
+					continue
+				}
+				dropped[candidate.info] = true
+				kept.info.Duplicates = append(kept.info.Duplicates, candidate.info.OriginalPath)
+				org.safeLog(fmt.Sprintf("Duplicate: %s (%s) has the same contents as %s (%s); copying it only once, to %s\n",
+					filepath.Base(candidate.info.OriginalPath), locationClusters[candidate.cluster].Name,
+					filepath.Base(kept.info.OriginalPath), locationClusters[kept.cluster].Name, locationClusters[kept.cluster].Name))
+			}
+		}
+	}
+
+	if len(dropped) == 0 {
+		return 0
+	}
+	for i, infos := range clusterInfos {
+		kept := infos[:0]
+		for _, info := range infos {
+			if !dropped[info] {
+				kept = append(kept, info)
+			}
+		}
+		clusterInfos[i] = kept
+	}
+	return len(dropped)
+}
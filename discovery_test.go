@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFindMediaFiles(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("media")}
+	fsys := fstest.MapFS{
+		"IMG_0001.jpg":                   file,
+		"IMG_0002.JPG":                   file, // Extensions match whatever their case
+		"trip/clip.MoV":                  file,
+		"trip/clip.3gp":                  file,
+		"trip/00001.MTS":                 file,
+		"trip/00002.m2ts":                file,
+		"trip/photo.heic":                file,
+		"trip/notes.txt":                 file, // Not media
+		"trip/voice.m4a":                 file, // Audio, left out by default
+		"trip/.DS_Store":                 file,
+		"trip/Thumbs.db":                 file,
+		"trip/._IMG_0003.jpg":            file, // An AppleDouble file beside a real one
+		"trip/IMG_0003.jpg":              file,
+		".Trashes/old.jpg":               file, // In a hidden folder
+		"exports/.organizer-ignore":      {Data: []byte("# Skip it all\n")},
+		"exports/edit.jpg":               file,
+		"raw/.organizer-ignore":          {Data: []byte("*.dng\n")},
+		"raw/one.dng":                    file,
+		"raw/one.jpg":                    file,
+		"organized/Paris/kept.jpg":       file, // The output folder, inside the source
+		"organized/" + ManifestFileName:  file,
+		ManifestFileName:                 file, // The organizer's own files, wherever they are
+		"trip/" + FolderMetadataFileName: file,
+	}
+
+	tests := []struct {
+		name  string
+		setup func(org *Organizer)
+		want  []string
+	}{
+		{"defaults", func(org *Organizer) {}, []string{
+			"IMG_0001.jpg", "IMG_0002.JPG", "raw/one.jpg", "trip/IMG_0003.jpg", "trip/clip.MoV", "trip/clip.3gp", "trip/00001.MTS", "trip/00002.m2ts", "trip/photo.heic",
+		}},
+		{"with audio", func(org *Organizer) { org.includeAudio = true }, []string{
+			"IMG_0001.jpg", "IMG_0002.JPG", "raw/one.jpg", "trip/IMG_0003.jpg", "trip/clip.MoV", "trip/clip.3gp", "trip/00001.MTS", "trip/00002.m2ts", "trip/photo.heic", "trip/voice.m4a",
+		}},
+		{"keeping junk", func(org *Organizer) { org.skipJunkFiles = false }, []string{
+			".Trashes/old.jpg", "IMG_0001.jpg", "IMG_0002.JPG", "raw/one.jpg", "trip/._IMG_0003.jpg", "trip/IMG_0003.jpg", "trip/clip.MoV", "trip/clip.3gp", "trip/00001.MTS", "trip/00002.m2ts", "trip/photo.heic",
+		}},
+	}
+	root := filepath.Join(string(filepath.Separator), "source")
+	for _, tt := range tests {
+		org := NewOrganizer(nil)
+		org.outputFolder = filepath.Join(root, "organized")
+		tt.setup(org)
+
+		files, err := org.FindMediaFiles(fsys, root)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var want []string
+		for _, name := range tt.want {
+			want = append(want, filepath.Join(root, filepath.FromSlash(name)))
+		}
+		slices.Sort(files)
+		slices.Sort(want)
+		if !slices.Equal(files, want) {
+			t.Errorf("%s: found %v, want %v", tt.name, files, want)
+		}
+	}
+}
+
+// unreadableFS is a filesystem whose folder unreadable can't be listed
+type unreadableFS struct {
+	fstest.MapFS
+	unreadable string
+}
+
+func (fsys unreadableFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == fsys.unreadable {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+	return fsys.MapFS.ReadDir(name)
+}
+
+func TestUnreadableFolderIsSkipped(t *testing.T) {
+	// A folder locked on disk, where permissions are enforced, and one that can't be
+	// listed on an in-memory filesystem, where they always are
+	dir := t.TempDir()
+	for _, name := range []string{"a.jpg", "locked/b.jpg", "open/c.jpg"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestPNG(t, path)
+	}
+	locked := filepath.Join(dir, "locked")
+	if err := os.Chmod(locked, 0000); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(locked, 0755)
+
+	file := &fstest.MapFile{Data: []byte("media")}
+	filesystems := map[string]fs.FS{
+		"disk":   os.DirFS(dir),
+		"memory": unreadableFS{fstest.MapFS{"a.jpg": file, "locked/b.jpg": file, "open/c.jpg": file}, "locked"},
+	}
+	for name, fsys := range filesystems {
+		if _, err := os.ReadDir(locked); name == "disk" && err == nil {
+			t.Log("skipping the disk: permissions aren't enforced for this user")
+			continue
+		}
+		org := NewOrganizer(nil)
+		scan := &mediaScan{root: dir, ignores: make(map[string]ignoreRules)}
+		if err := org.walkMediaFiles(fsys, dir, scan); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := []string{filepath.Join(dir, "a.jpg"), filepath.Join(dir, "open", "c.jpg")}
+		slices.Sort(scan.files)
+		if !slices.Equal(scan.files, want) || scan.skippedPaths != 1 {
+			t.Errorf("%s: found %v with %d paths skipped, want %v with 1", name, scan.files, scan.skippedPaths, want)
+		}
+	}
+
+	// Only an unreadable source folder stops the scan
+	_, err := NewOrganizer(nil).FindMediaFiles(unreadableFS{fstest.MapFS{"a.jpg": file}, "."}, dir)
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("unreadable source folder: %v, want a permission error", err)
+	}
+}
+
+func TestNestedIgnoreMarkers(t *testing.T) {
+	file := &fstest.MapFile{Data: []byte("media")}
+	marker := func(patterns string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(patterns)} }
+	fsys := fstest.MapFS{
+		IgnoreFileName: marker(""), // Overridden, since the source was chosen explicitly
+		"a.jpg":        file,
+
+		"raw/" + IgnoreFileName: marker("*.dng\nexports/\n/top.jpg\nsub/skip.jpg\n"),
+		"raw/one.dng":           file, // Unanchored patterns match at any depth
+		"raw/sub/two.dng":       file,
+		"raw/exports/e.jpg":     file, // Folder patterns skip the folder
+		"raw/top.jpg":           file, // Anchored patterns match only below the marker's folder
+		"raw/sub/top.jpg":       file,
+		"raw/sub/skip.jpg":      file,
+		"raw/sub/keep.jpg":      file,
+		"raw/p.png":             file,
+
+		// A deeper marker adds to the ones above it, and only applies below itself
+		"raw/sub/" + IgnoreFileName:        marker("*.png\n"),
+		"raw/sub/p.png":                    file,
+		"raw/sub/deeper/" + IgnoreFileName: marker("# Nothing here\n"),
+		"raw/sub/deeper/d.jpg":             file,
+	}
+	root := filepath.Join(string(filepath.Separator), "source")
+	files, err := NewOrganizer(nil).FindMediaFiles(fsys, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want []string
+	for _, name := range []string{"a.jpg", "raw/p.png", "raw/sub/keep.jpg", "raw/sub/top.jpg"} {
+		want = append(want, filepath.Join(root, filepath.FromSlash(name)))
+	}
+	slices.Sort(files)
+	if !slices.Equal(files, want) {
+		t.Errorf("found %v, want %v", files, want)
+	}
+}
+
+// syntheticTree writes a library of empty files to dir: folders of folders, each
+// holding media files, sidecars and the odd bit of junk
+func syntheticTree(b *testing.B, dir string, folders, filesPerFolder int) {
+	b.Helper()
+	names := []string{"IMG_%04d.jpg", "IMG_%04d.HEIC", "VID_%04d.mp4", "IMG_%04d.jpg.json", "notes_%04d.txt"}
+	for i := 0; i < folders; i++ {
+		folder := filepath.Join(dir, fmt.Sprintf("%d", 2000+i/12), fmt.Sprintf("%02d", i%12+1))
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			b.Fatal(err)
+		}
+		for j := 0; j < filesPerFolder; j++ {
+			if err := os.WriteFile(filepath.Join(folder, fmt.Sprintf(names[j%len(names)], j)), nil, 0644); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(folder, ".DS_Store"), nil, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFindMediaFiles(b *testing.B) {
+	dir := b.TempDir()
+	syntheticTree(b, dir, 120, 100)
+	org := NewOrganizer(nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := org.findMediaFiles(dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkExistingFiles(b *testing.B) {
+	dir := b.TempDir()
+	syntheticTree(b, dir, 120, 100)
+	org := NewOrganizer(nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		org.existingFilesByName(dir)
+	}
+}
+
+func TestEveryMediaFormatIsDiscovered(t *testing.T) {
+	org := NewOrganizer(nil)
+	org.includeAudio = true
+	fsys := fstest.MapFS{}
+	root := filepath.Join(string(filepath.Separator), "source")
+	var want []string
+	for ext, format := range mediaFormats {
+		name := "file" + strings.ToUpper(ext)
+		fsys[name] = &fstest.MapFile{Data: []byte("media")}
+		want = append(want, filepath.Join(root, name))
+		if kind := org.mediaKind(name); kind != format.Kind {
+			t.Errorf("%s handled as kind %v, want %v", ext, kind, format.Kind)
+		}
+	}
+
+	files, err := org.FindMediaFiles(fsys, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	slices.Sort(want)
+	if !slices.Equal(files, want) {
+		t.Errorf("found %v, want %v", files, want)
+	}
+}
@@ -0,0 +1,59 @@
+//go:build bundled_exiftool
+
+package main
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// bundledExifToolFS holds an exiftool executable shipped inside the binary. Place the
+// platform's standalone exiftool (exiftool.exe on Windows) in bundled/ and build with
+// -tags bundled_exiftool to include it.
+//
+//go:embed bundled/exiftool*
+var bundledExifToolFS embed.FS
+
+// extractBundledExifTool writes the embedded exiftool to a temp directory on first run
+// and returns its path. The directory is keyed by content hash so upgrades replace it.
+func extractBundledExifTool() (string, error) {
+	name := "exiftool"
+	if runtime.GOOS == "windows" {
+		name = "exiftool.exe"
+	}
+
+	data, err := bundledExifToolFS.ReadFile("bundled/" + name)
+	if err != nil {
+		return "", fmt.Errorf("no bundled exiftool for %s: %w", runtime.GOOS, err)
+	}
+
+	sum := sha256.Sum256(data)
+	dir := filepath.Join(os.TempDir(), "media-organizer-exiftool-"+hex.EncodeToString(sum[:8]))
+	path := filepath.Join(dir, name)
+
+	// Already extracted by a previous run
+	if fileInfo, err := os.Stat(path); err == nil && fileInfo.Size() == int64(len(data)) {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("could not create %s: %w", dir, err)
+	}
+
+	// Write under a temporary name first so a partially written file is never executed
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0755); err != nil {
+		return "", fmt.Errorf("could not extract bundled exiftool: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("could not extract bundled exiftool: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,11 @@
+//go:build !bundled_exiftool
+
+package main
+
+import "errors"
+
+// extractBundledExifTool reports that this build carries no bundled exiftool.
+// Build with -tags bundled_exiftool to embed one.
+func extractBundledExifTool() (string, error) {
+	return "", errors.New("exiftool is not bundled in this build")
+}
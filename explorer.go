@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// linuxFolderManagers are the Linux file managers tried, in order, for opening a folder
+var linuxFolderManagers = []string{"xdg-open", "nautilus", "dolphin", "thunar", "pcmanfm"}
+
+// openFileExplorer opens the native file explorer to the specified folder
+func (app *App) openFileExplorer(folderPath string) {
+	if app.runFileExplorer(fileExplorerCommands(runtime.GOOS, folderPath, false)) {
+		app.safeLog(fmt.Sprintf("📂 Opened %s in file explorer\n", folderPath))
+	}
+}
+
+// revealInFileExplorer opens the folder containing filePath in the native file explorer
+// with the file selected. Where selecting isn't supported the folder is opened instead.
+// A file that is gone, or inside an archive, shows the nearest file or folder above it.
+func (app *App) revealInFileExplorer(filePath string) {
+	for {
+		stat, err := os.Stat(filePath)
+		if err == nil && stat.IsDir() {
+			app.openFileExplorer(filePath)
+			return
+		}
+		if err == nil || filePath == filepath.Dir(filePath) {
+			break
+		}
+		filePath = filepath.Dir(filePath)
+	}
+	if app.runFileExplorer(fileExplorerCommands(runtime.GOOS, filePath, true)) {
+		app.safeLog(fmt.Sprintf("📂 Showing %s in file explorer\n", filepath.Base(filePath)))
+	}
+}
+
+// fileExplorerCommands returns the commands that can show target on the goos platform,
+// best first. With selectFile, target is a file to select in its folder; Linux has no
+// single way to do that, so the file managers that can are tried before opening the folder.
+func fileExplorerCommands(goos, target string, selectFile bool) [][]string {
+	switch goos {
+	case "windows":
+		if selectFile {
+			return [][]string{{"explorer", "/select," + target}}
+		}
+		return [][]string{{"explorer", target}}
+	case "darwin":
+		if selectFile {
+			return [][]string{{"open", "-R", target}}
+		}
+		return [][]string{{"open", target}}
+	case "linux":
+		folder := target
+		var commands [][]string
+		if selectFile {
+			folder = filepath.Dir(target)
+			// The freedesktop file manager interface, implemented by most desktops
+			uri := (&url.URL{Scheme: "file", Path: target}).String()
+			commands = append(commands,
+				[]string{"dbus-send", "--session", "--print-reply", "--dest=org.freedesktop.FileManager1",
+					"/org/freedesktop/FileManager1", "org.freedesktop.FileManager1.ShowItems",
+					"array:string:" + uri, "string:"},
+				[]string{"nautilus", "--select", target},
+				[]string{"dolphin", "--select", target},
+				[]string{"caja", "--select", target},
+				[]string{"nemo", target},
+			)
+		}
+		for _, manager := range linuxFolderManagers {
+			commands = append(commands, []string{manager, folder})
+		}
+		return commands
+	}
+	return nil
+}
+
+// runFileExplorer starts the first of commands whose program is installed and reports
+// whether one started. dbus-send is waited for, so a desktop without the interface
+// falls through to the next command.
+func (app *App) runFileExplorer(commands [][]string) bool {
+	if len(commands) == 0 {
+		app.safeLog("Unsupported operating system - cannot open file explorer\n")
+		return false
+	}
+
+	for _, args := range commands {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(args[0], args[1:]...)
+		if args[0] == "dbus-send" {
+			if cmd.Run() == nil {
+				return true
+			}
+			continue
+		}
+		if err := cmd.Start(); err != nil {
+			app.safeLog(fmt.Sprintf("Failed to open file explorer: %v\n", err))
+			return false
+		}
+		// Reap the process once the file manager exits or hands off to a running one
+		go cmd.Wait()
+		return true
+	}
+
+	app.safeLog("Could not find a file manager to open\n")
+	return false
+}
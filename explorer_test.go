@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileExplorerCommands(t *testing.T) {
+	linuxFolder := func(folder string) [][]string {
+		var commands [][]string
+		for _, manager := range linuxFolderManagers {
+			commands = append(commands, []string{manager, folder})
+		}
+		return commands
+	}
+	const photo = "/photos/Paris/IMG 0001.jpg"
+
+	tests := []struct {
+		goos, target string
+		selectFile   bool
+		want         [][]string
+	}{
+		{"windows", `C:\photos\Paris\IMG_0001.jpg`, true, [][]string{{"explorer", `/select,C:\photos\Paris\IMG_0001.jpg`}}},
+		{"windows", `C:\photos\Paris`, false, [][]string{{"explorer", `C:\photos\Paris`}}},
+		{"darwin", photo, true, [][]string{{"open", "-R", photo}}},
+		{"darwin", "/photos/Paris", false, [][]string{{"open", "/photos/Paris"}}},
+		// The file managers that can select, then opening the folder holding the file
+		{"linux", photo, true, append([][]string{
+			{"dbus-send", "--session", "--print-reply", "--dest=org.freedesktop.FileManager1",
+				"/org/freedesktop/FileManager1", "org.freedesktop.FileManager1.ShowItems",
+				"array:string:file:///photos/Paris/IMG%200001.jpg", "string:"},
+			{"nautilus", "--select", photo},
+			{"dolphin", "--select", photo},
+			{"caja", "--select", photo},
+			{"nemo", photo},
+		}, linuxFolder("/photos/Paris")...)},
+		{"linux", "/photos/Paris", false, linuxFolder("/photos/Paris")},
+		{"plan9", "/photos", false, nil},
+	}
+	for _, tt := range tests {
+		if tt.goos == "linux" && filepath.Separator != '/' {
+			continue // Linux paths are split with the running platform's separator
+		}
+		if got := fileExplorerCommands(tt.goos, tt.target, tt.selectFile); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s, %s (select %v):\n got %q\nwant %q", tt.goos, tt.target, tt.selectFile, got, tt.want)
+		}
+	}
+}
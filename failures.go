@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// newFailuresCard creates the list of files the last run couldn't read or copy, each with
+// a button showing it in the file explorer. It stays hidden until a run has failures.
+func (app *App) newFailuresCard() *widget.Card {
+	app.failuresList = widget.NewList(
+		func() int { return len(app.failures) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, widget.NewButtonWithIcon("Show", theme.FolderOpenIcon(), nil), nil, label)
+		},
+		func(id widget.ListItemID, item fyne.CanvasObject) {
+			failure := app.failures[id]
+			row := item.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s: %s", app.failureName(failure.Path), failure.Reason))
+			row.Objects[1].(*widget.Button).OnTapped = func() {
+				go app.revealInFileExplorer(failure.Path)
+			}
+		},
+	)
+
+	// A list has no height of its own, so give it room for a few rows
+	app.failuresCard = widget.NewCard("⚠️ Failed Files", "", container.NewGridWrap(fyne.NewSize(560, 150), app.failuresList))
+	app.failuresCard.Hide()
+	return app.failuresCard
+}
+
+// showFailures lists failures in the failures card, hiding it when there are none. It
+// must be called on the main goroutine.
+func (app *App) showFailures(failures []ProblemFile) {
+	app.failures = failures
+	app.failuresList.Refresh()
+	if len(failures) == 0 {
+		app.failuresCard.Hide()
+		return
+	}
+	app.failuresList.ScrollToTop()
+	app.failuresCard.SetSubTitle(fmt.Sprintf("%d files couldn't be read or copied, so they weren't organized", len(failures)))
+	app.failuresCard.Show()
+}
+
+// failureName shortens path to its place in the source folder
+func (app *App) failureName(path string) string {
+	if rel, err := filepath.Rel(app.sourceFolder, path); err == nil && isWithinFolder(path, app.sourceFolder) {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// makeFolder creates folder and any missing parents like os.MkdirAll, remembering the
+// ones that didn't exist yet so they can be pruned if the run leaves them empty
+func (org *Organizer) makeFolder(folder string) error {
+	// Only folders known not to exist are recorded; anything unreadable counts as
+	// existing, so a folder that was there before the run is never removed
+	var missing []string
+	for dir := folder; ; {
+		ioDir, _ := longPath(dir)
+		if _, err := os.Lstat(ioDir); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		missing = append(missing, dir)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if org.createdFolders == nil {
+		org.createdFolders = make(map[string]bool)
+	}
+	for _, dir := range missing {
+		org.createdFolders[dir] = true
+	}
+
+	ioPath, _ := longPath(folder)
+	return os.MkdirAll(ioPath, 0755)
+}
+
+// pruneCreatedFolders removes the folders created during the run that ended up empty,
+// such as date folders whose copies all failed or were never made because the run
+// stopped. A folder holding nothing but the location metadata this run wrote counts as
+// empty too. Deeper folders go first, so removing a date folder can empty its parent.
+func (org *Organizer) pruneCreatedFolders() {
+	folders := make([]string, 0, len(org.createdFolders))
+	for folder := range org.createdFolders {
+		folders = append(folders, folder)
+	}
+	// A folder's path is always longer than its parent's
+	sort.Slice(folders, func(i, j int) bool {
+		return len(folders[i]) > len(folders[j])
+	})
+
+	removed := 0
+	for _, folder := range folders {
+		ioPath, _ := longPath(folder)
+		entries, err := os.ReadDir(ioPath)
+		if err != nil {
+			continue
+		}
+		if len(entries) == 1 && entries[0].Name() == FolderMetadataFileName {
+			if os.Remove(filepath.Join(ioPath, FolderMetadataFileName)) == nil {
+				entries = nil
+			}
+		}
+		if len(entries) > 0 {
+			continue
+		}
+		if err := os.Remove(ioPath); err != nil {
+			org.safeLog(fmt.Sprintf("Warning: Could not remove empty folder %s: %v\n", folder, err))
+			continue
+		}
+		removed++
+	}
+
+	if removed > 0 {
+		org.safeLog(fmt.Sprintf("Removed %d empty folders created during the run\n", removed))
+	}
+	org.createdFolders = nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneCreatedFolders(t *testing.T) {
+	output := t.TempDir()
+	existingEmpty := filepath.Join(output, "Empty")
+	existingFull := filepath.Join(output, "Paris")
+	for _, dir := range []string{existingEmpty, existingFull} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestPNG(t, filepath.Join(existingFull, "old.png"))
+
+	// The folders made for a run's files, then the run cancelled before most copies
+	org := NewOrganizer(nil)
+	org.outputFolder = output
+	created := []string{
+		filepath.Join(output, "Paris", "2024", "03"),
+		filepath.Join(output, "Lyon", "2024", "03"),
+		filepath.Join(output, "Nice", "2024", "04"),
+		filepath.Join(output, "Empty", "2024"),
+		filepath.Join(output, "Rome"),
+	}
+	for _, dir := range created {
+		if err := org.makeFolder(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := org.makeFolder(existingEmpty); err != nil {
+		t.Fatal(err)
+	}
+	writeTestPNG(t, filepath.Join(output, "Nice", "2024", "04", "copied.png"))
+	if err := os.WriteFile(filepath.Join(output, "Rome", FolderMetadataFileName), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	org.pruneCreatedFolders()
+
+	for _, dir := range []string{"Empty", "Paris", "Paris/old.png", "Nice/2024/04/copied.png"} {
+		if _, err := os.Stat(filepath.Join(output, filepath.FromSlash(dir))); err != nil {
+			t.Errorf("%s was removed: %v", dir, err)
+		}
+	}
+	for _, dir := range []string{"Paris/2024", "Lyon", "Empty/2024", "Rome"} {
+		if _, err := os.Stat(filepath.Join(output, filepath.FromSlash(dir))); !os.IsNotExist(err) {
+			t.Errorf("%s was left behind", dir)
+		}
+	}
+	if org.createdFolders != nil {
+		t.Errorf("still tracking %d folders", len(org.createdFolders))
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// How a format's metadata depends on exiftool
+const (
+	ExifToolNotUsed  = "no"       // Read in-process
+	ExifToolFallback = "fallback" // Read in-process when possible, otherwise with exiftool
+	ExifToolRequired = "required" // Without exiftool, dated by filename or file date only and never placed
+)
+
+// String names the kind, as listed by -list-formats
+func (kind MediaKind) String() string {
+	switch kind {
+	case MediaImage:
+		return "image"
+	case MediaHEIF:
+		return "heif"
+	case MediaVideo:
+		return "video"
+	case MediaAudio:
+		return "audio"
+	}
+	return "unknown"
+}
+
+// exifToolUse returns how reading files of the kind depends on exiftool
+func (kind MediaKind) exifToolUse() string {
+	switch kind {
+	case MediaVideo, MediaAudio:
+		return ExifToolRequired
+	case MediaHEIF:
+		return ExifToolFallback
+	}
+	return ExifToolNotUsed
+}
+
+// SupportedFormat is a file extension the organizer recognizes
+type SupportedFormat struct {
+	Extension   string `json:"extension"`
+	Kind        string `json:"kind"`
+	Description string `json:"description,omitempty"`
+	ExifTool    string `json:"exiftool"`
+	Optional    bool   `json:"optional,omitempty"` // Only organized when audio files are included
+}
+
+// Capabilities are the supported formats and the exiftool that reads those needing it
+type Capabilities struct {
+	Formats         []SupportedFormat `json:"formats"`
+	ExifToolPath    string            `json:"exiftoolPath,omitempty"`
+	ExifToolVersion string            `json:"exiftoolVersion,omitempty"`
+	ExifToolError   string            `json:"exiftoolError,omitempty"` // Why the exiftool found isn't usable
+}
+
+// SupportedFormats lists the extensions with a registered handler, grouped by kind and
+// sorted by extension within each
+func (org *Organizer) SupportedFormats() []SupportedFormat {
+	formats := make([]SupportedFormat, 0, len(org.handlers))
+	kinds := make(map[string]MediaKind, len(org.handlers))
+	for ext, registered := range org.handlers {
+		formats = append(formats, SupportedFormat{
+			Extension:   ext,
+			Kind:        registered.kind.String(),
+			Description: mediaFormats[ext].Description, // Empty for added handlers
+			ExifTool:    registered.kind.exifToolUse(),
+			Optional:    registered.kind == MediaAudio,
+		})
+		kinds[ext] = registered.kind
+	}
+	slices.SortFunc(formats, func(a, b SupportedFormat) int {
+		if kinds[a.Extension] != kinds[b.Extension] {
+			return cmp.Compare(kinds[a.Extension], kinds[b.Extension])
+		}
+		return strings.Compare(a.Extension, b.Extension)
+	})
+	return formats
+}
+
+// Capabilities reports the supported formats and checks the exiftool in use, which
+// runs it, so it should be kept off the main goroutine
+func (org *Organizer) Capabilities() Capabilities {
+	capabilities := Capabilities{Formats: org.SupportedFormats(), ExifToolPath: exiftoolPath}
+	if exiftoolPath != "" {
+		if version, err := validateExifTool(exiftoolPath); err != nil {
+			capabilities.ExifToolError = err.Error()
+		} else {
+			capabilities.ExifToolVersion = version
+		}
+	}
+	return capabilities
+}
+
+// ExifToolAvailable reports whether a working exiftool was found
+func (c Capabilities) ExifToolAvailable() bool {
+	return c.ExifToolPath != "" && c.ExifToolError == ""
+}
+
+// String renders the capabilities as a table of formats followed by the exiftool status
+func (c Capabilities) String() string {
+	var sb strings.Builder
+	table := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Extension\tKind\tExifTool\tDescription")
+	optional := false
+	for _, format := range c.Formats {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", format.Extension, format.Kind, format.ExifTool, format.Description)
+		optional = optional || format.Optional
+	}
+	table.Flush()
+
+	sb.WriteString("\n")
+	if optional {
+		sb.WriteString("Audio formats are only organized when Include audio files is checked\n")
+	}
+	switch {
+	case c.ExifToolAvailable():
+		fmt.Fprintf(&sb, "ExifTool v%s is available (%s)\n", c.ExifToolVersion, c.ExifToolPath)
+	case c.ExifToolPath != "":
+		fmt.Fprintf(&sb, "ExifTool at %s is not working: %s\n", c.ExifToolPath, c.ExifToolError)
+	default:
+		sb.WriteString("ExifTool was not found\n")
+	}
+	if !c.ExifToolAvailable() {
+		fmt.Fprintf(&sb, "Formats marked %q are dated by filename or file date, without GPS; %q formats read most files anyway\n",
+			ExifToolRequired, ExifToolFallback)
+	}
+	return sb.String()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/rwcarlsen/goexif/tiff"
+)
+
+const (
+	// GPSHPositioningError is the EXIF 2.31 horizontal GPS error in meters, a tag goexif
+	// doesn't know and so would otherwise drop
+	GPSHPositioningError exif.FieldName = "GPSHPositioningError"
+	// metersPerDOP estimates the horizontal error of a fix from its dilution of precision,
+	// for files that only record GPSDOP
+	metersPerDOP = 5
+	// nullIslandRadius is how close to 0°, 0° a position must be to be taken for a
+	// receiver reporting itself there without a fix (about 1 km)
+	nullIslandRadius = 0.01
+)
+
+// gpsAccuracyChoices are the selectable thresholds for ignoring inaccurate GPS positions,
+// in meters of recorded horizontal error
+var gpsAccuracyChoices = map[string]float64{
+	"Off":    0,
+	"25 m":   25,
+	"50 m":   50,
+	"100 m":  100,
+	"500 m":  500,
+	"1000 m": 1000,
+}
+
+func init() {
+	exif.RegisterParsers(gpsErrorParser{})
+}
+
+// gpsErrorParser loads GPSHPositioningError from the GPS IFD after goexif's own parser
+type gpsErrorParser struct{}
+
+// Parse adds the positioning error to x when the GPS IFD records one
+func (gpsErrorParser) Parse(x *exif.Exif) error {
+	pointer, err := x.Get(exif.GPSInfoIFDPointer)
+	if err != nil {
+		return nil
+	}
+	offset, err := pointer.Int64(0)
+	if err != nil {
+		return nil
+	}
+
+	// Tag values are found by offsets from the start of the block. The built-in parser
+	// already reported any problem reading the directory.
+	r := bytes.NewReader(x.Raw)
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return nil
+	}
+	dir, _, err := tiff.DecodeDir(r, x.Tiff.Order)
+	if err != nil {
+		return nil
+	}
+	x.LoadTags(dir, map[uint16]exif.FieldName{0x1f: GPSHPositioningError}, false)
+	return nil
+}
+
+// exifGPSError returns the horizontal error of the GPS position in meters, from the
+// positioning error if recorded or else estimated from the dilution of precision
+func exifGPSError(exifData *exif.Exif) (float64, bool) {
+	if value, ok := exifRational(exifData, GPSHPositioningError); ok {
+		return value, true
+	}
+	if value, ok := exifRational(exifData, exif.GPSDOP); ok {
+		return value * metersPerDOP, true
+	}
+	return 0, false
+}
+
+// exifRational returns the value of a single-rational EXIF tag
+func exifRational(exifData *exif.Exif, name exif.FieldName) (float64, bool) {
+	tag, err := exifData.Get(name)
+	if err != nil {
+		return 0, false
+	}
+	numerator, denominator, err := tag.Rat2(0)
+	if err != nil || denominator == 0 {
+		return 0, false
+	}
+	return float64(numerator) / float64(denominator), true
+}
+
+// plausiblePosition reports whether a GPS position could be real: within the valid
+// ranges, and not at 0°, 0° where receivers without a fix place themselves
+func plausiblePosition(lat, lng float64) bool {
+	if math.IsNaN(lat) || math.IsNaN(lng) || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return false
+	}
+	return math.Abs(lat) >= nullIslandRadius || math.Abs(lng) >= nullIslandRadius
+}
+
+// rejectUnreliableGPS drops info's GPS position when it is implausible or its recorded
+// error is over the configured threshold, so the file is placed like any other without
+// GPS. Each rejection is logged.
+func (org *Organizer) rejectUnreliableGPS(info *ImageInfo) {
+	if !info.HasGPS {
+		return
+	}
+
+	var reason string
+	switch {
+	case !plausiblePosition(info.Latitude, info.Longitude):
+		reason = "the position is implausible"
+	case org.maxGPSError > 0 && info.HasGPSError && info.GPSError > org.maxGPSError:
+		reason = fmt.Sprintf("it is only accurate to %.0f m", info.GPSError)
+	default:
+		return
+	}
+
+	org.safeLog(fmt.Sprintf("Ignoring GPS position %.6f, %.6f of %s: %s\n", info.Latitude, info.Longitude, filepath.Base(info.OriginalPath), reason))
+	info.HasGPS = false
+	info.Latitude, info.Longitude = 0, 0
+	info.Elevation, info.HasElevation = 0, false
+	info.Location = "Unknown"
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// MediaHandler reads the metadata of one kind of media file. ExtractInfo returns the
+// capture date, location and camera it finds; readImageInfo then fills in the path and
+// the filename and modification-time dates that every file has, so a handler only
+// reports what its format stores.
+type MediaHandler interface {
+	ExtractInfo(path string) (*ImageInfo, error)
+}
+
+// ReaderMediaHandler is an optional extension of MediaHandler for handlers that can read
+// a file's contents without opening it by path, so files inside an archive are read
+// without being extracted to disk. ExtractInfoFrom reads the size bytes of source, whose
+// path is only for reporting, and returns errNeedsFile when the file has to be read from
+// disk after all.
+type ReaderMediaHandler interface {
+	ExtractInfoFrom(source io.ReaderAt, size int64, path string) (*ImageInfo, error)
+}
+
+// registeredHandler is the handler for one extension and the kind of media it reads
+type registeredHandler struct {
+	kind    MediaKind
+	handler MediaHandler
+}
+
+// RegisterHandler makes handler read files with extension ext (e.g. ".jxl"), replacing
+// any handler already registered for it. Files of a new extension are discovered and
+// organized like the built-in files of the same kind.
+func (org *Organizer) RegisterHandler(ext string, kind MediaKind, handler MediaHandler) {
+	org.handlers[strings.ToLower(ext)] = registeredHandler{kind: kind, handler: handler}
+}
+
+// registerBuiltinHandlers registers the handler for every extension in mediaFormats
+func (org *Organizer) registerBuiltinHandlers() {
+	for ext, format := range mediaFormats {
+		var handler MediaHandler
+		switch format.Kind {
+		case MediaHEIF:
+			handler = heifHandler{org}
+		case MediaVideo:
+			handler = videoHandler{org}
+		case MediaAudio:
+			handler = audioHandler{org}
+		default:
+			handler = rasterHandler{org}
+		}
+		org.RegisterHandler(ext, format.Kind, handler)
+	}
+}
+
+// mediaKind returns the media kind of a file from the handler registered for its extension
+func (org *Organizer) mediaKind(path string) MediaKind {
+	return org.handlers[strings.ToLower(filepath.Ext(path))].kind
+}
+
+// includeGPS reports whether handlers should read GPS: it is irrelevant (and exiftool
+// GPS lookups wasted) when organizing by date only
+func (org *Organizer) includeGPS() bool {
+	return org.organizeMode != ModeDateOnly
+}
+
+// errMalformedExif is returned when an EXIF block is so corrupt or truncated that goexif
+// panics reading it, which it can since it trusts the sizes and counts the block claims
+var errMalformedExif = errors.New("malformed EXIF data")
+
+// guardExif runs read, which decodes or reads EXIF data, and returns errMalformedExif
+// if it panics, so one broken file can't take down the worker reading it
+func guardExif(read func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%w: %v", errMalformedExif, p)
+		}
+	}()
+	return read()
+}
+
+// decodeExif decodes the EXIF block in r like exif.Decode, without panicking
+func decodeExif(r io.Reader) (*exif.Exif, error) {
+	var exifData *exif.Exif
+	err := guardExif(func() error {
+		var err error
+		exifData, err = exif.Decode(r)
+		return err
+	})
+	return exifData, err
+}
+
+// rasterHandler reads JPEG, PNG, TIFF, RAW and other images with goexif
+type rasterHandler struct{ org *Organizer }
+
+// ExtractInfo decodes the file's EXIF block, if it has one
+func (h rasterHandler) ExtractInfo(path string) (*ImageInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return h.extract(file, path)
+}
+
+// ExtractInfoFrom decodes the EXIF block of a file read from source
+func (h rasterHandler) ExtractInfoFrom(source io.ReaderAt, size int64, path string) (*ImageInfo, error) {
+	return h.extract(io.NewSectionReader(source, 0, size), path)
+}
+
+// extract does the work of ExtractInfo, reading the file from r
+func (h rasterHandler) extract(r io.Reader, path string) (*ImageInfo, error) {
+	info := &ImageInfo{}
+	exifData, err := decodeExif(r)
+	if err == nil {
+		err = h.org.applyExifData(info, exifData, h.org.includeGPS())
+	}
+	if errors.Is(err, errMalformedExif) {
+		h.org.safeLog(fmt.Sprintf("Warning: Ignoring malformed EXIF data in %s: %v\n", filepath.Base(path), err))
+		return &ImageInfo{}, nil
+	}
+	// If no EXIF data, the filename or file modification time is the fallback
+	return info, nil
+}
+
+// heifHandler reads HEIC/HEIF images from their embedded EXIF, or with exiftool
+type heifHandler struct{ org *Organizer }
+
+// ExtractInfo reads the file's EXIF item natively when possible
+func (h heifHandler) ExtractInfo(path string) (*ImageInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	info, ok := h.readEmbedded(file, stat.Size(), path)
+	if ok {
+		return info, nil
+	}
+	includeGPS := h.org.includeGPS()
+
+	// Otherwise read the capture date and GPS with exiftool and only fall back
+	// to the filename timestamp or file date
+	metadata, ok := h.org.extractMetadataWithExifTool(path, includeGPS)
+	if ok && !metadata.Date.IsZero() {
+		dateKind := wallClockTime
+		if metadata.DateHasZone {
+			dateKind = zonedTime
+		}
+		info.offerDate(DateSourceMetadata, metadata.Date, dateKind)
+		h.org.safeLog(fmt.Sprintf("Processing HEIC/HEIF file: %s (found capture date %s)\n",
+			filepath.Base(path), metadata.Date.Format("2006-01-02 15:04:05")))
+	} else {
+		h.org.safeLog(fmt.Sprintf("Processing HEIC/HEIF file: %s (no capture date)\n", filepath.Base(path)))
+	}
+
+	if ok {
+		h.org.applyExifToolGPS(info, metadata)
+		info.CameraMake, info.CameraModel = metadata.Make, metadata.Model
+	}
+
+	return info, nil
+}
+
+// ExtractInfoFrom reads the EXIF item of a file read from source. Files without one need
+// exiftool, so they return errNeedsFile.
+func (h heifHandler) ExtractInfoFrom(source io.ReaderAt, size int64, path string) (*ImageInfo, error) {
+	if info, ok := h.readEmbedded(source, size, path); ok {
+		return info, nil
+	}
+	return nil, errNeedsFile
+}
+
+// readEmbedded reads the EXIF block most HEIC files carry inside their meta box, which
+// goexif can decode once it has been located. It reports whether there was a usable
+// one; when there wasn't, the returned info is empty.
+func (h heifHandler) readEmbedded(file io.ReaderAt, size int64, path string) (*ImageInfo, bool) {
+	info := &ImageInfo{}
+	exifData, err := readHEICExif(file, size)
+	if err == nil {
+		err = h.org.applyExifData(info, exifData, h.org.includeGPS())
+	}
+	if err == nil {
+		h.org.safeLog(fmt.Sprintf("Processing HEIC/HEIF file: %s (using embedded EXIF)\n", filepath.Base(path)))
+		return info, true
+	}
+	if errors.Is(err, errMalformedExif) {
+		h.org.safeLog(fmt.Sprintf("Warning: Ignoring malformed EXIF data in %s: %v\n", filepath.Base(path), err))
+	}
+	return &ImageInfo{}, false
+}
+
+// videoHandler reads video GPS and creation dates with exiftool
+type videoHandler struct{ org *Organizer }
+
+// ExtractInfo reads GPS and creation date with a single exiftool call
+func (h videoHandler) ExtractInfo(path string) (*ImageInfo, error) {
+	if err := checkReadable(path); err != nil {
+		return nil, err
+	}
+
+	info := &ImageInfo{}
+	h.org.safeLog(fmt.Sprintf("Processing video file: %s\n", filepath.Base(path)))
+
+	if metadata, ok := h.org.extractMetadataWithExifTool(path, h.org.includeGPS()); ok {
+		h.org.applyExifToolGPS(info, metadata)
+		info.CameraMake, info.CameraModel = metadata.Make, metadata.Model
+		if !metadata.Date.IsZero() {
+			// QuickTime dates are stored in UTC unless exiftool reports an offset
+			dateKind := absoluteTime
+			if metadata.DateHasZone {
+				dateKind = zonedTime
+			}
+			info.offerDate(DateSourceMetadata, metadata.Date, dateKind)
+			h.org.safeLog(fmt.Sprintf("Extracted video date: %s -> %s\n",
+				filepath.Base(path), metadata.Date.Format("2006-01-02 15:04:05")))
+		}
+	}
+
+	return info, nil
+}
+
+// audioHandler reads audio creation dates with exiftool; audio has no GPS
+type audioHandler struct{ org *Organizer }
+
+// ExtractInfo reads the creation date and recording device with exiftool
+func (h audioHandler) ExtractInfo(path string) (*ImageInfo, error) {
+	if err := checkReadable(path); err != nil {
+		return nil, err
+	}
+
+	info := &ImageInfo{}
+	h.org.safeLog(fmt.Sprintf("Processing audio file: %s\n", filepath.Base(path)))
+
+	metadata, ok := h.org.extractMetadataWithExifTool(path, false)
+	if ok {
+		info.CameraMake, info.CameraModel = metadata.Make, metadata.Model
+	}
+	if ok && !metadata.Date.IsZero() {
+		// Like QuickTime video, M4A dates are stored in UTC unless an offset is reported
+		dateKind := absoluteTime
+		if metadata.DateHasZone {
+			dateKind = zonedTime
+		}
+		info.offerDate(DateSourceMetadata, metadata.Date, dateKind)
+		h.org.safeLog(fmt.Sprintf("Extracted audio date: %s -> %s\n",
+			filepath.Base(path), metadata.Date.Format("2006-01-02 15:04:05")))
+	}
+
+	return info, nil
+}
+
+// checkReadable reports an error for files that can't be opened, which exiftool would
+// otherwise only report as unreadable metadata
+func checkReadable(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
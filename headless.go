@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// headlessOptions are the command-line settings for running without the GUI
+type headlessOptions struct {
+	source      string
+	output      string
+	jsonEvents  bool
+	fullRun     bool
+	merge       bool
+	dedupe      bool
+	split       bool
+	minCluster  int // Merge clusters with fewer files into nearby ones
+	sync        bool
+	listFormats bool    // Print the supported formats and exit
+	places      string  // JSON file of labeled places
+	simulate    int     // Synthetic files to cluster instead of organizing a folder
+	seed        int64   // Random seed for -simulate
+	workers     int     // Decode worker threads; 0 keeps the default
+	exifWorkers int     // Exiftool workers; 0 keeps the default
+	sensitivity float64 // Location sensitivity in degrees; 0 keeps the default
+	maxGPSError float64 // Ignore GPS positions less accurate than this many meters
+	lookupCache int     // Grid cells kept in the location lookup cache; 0 keeps the default
+	cpuProfile  string  // Where to write a CPU profile of the run
+	memProfile  string  // Where to write a memory profile of the run
+}
+
+// hiddenFlags are diagnostic flags left out of the -help listing
+var hiddenFlags = map[string]bool{"cpuprofile": true, "memprofile": true}
+
+// parseCommandLine reads the command-line flags; headless mode is requested by
+// passing a source folder, -simulate or -list-formats
+func parseCommandLine() (headlessOptions, bool) {
+	var options headlessOptions
+	flag.StringVar(&options.source, "source", "", "Organize this folder without opening the window")
+	flag.StringVar(&options.output, "output", "", "Output folder for -source")
+	flag.BoolVar(&options.jsonEvents, "json", false, "Print one JSON event per line on stdout (the log goes to stderr)")
+	flag.BoolVar(&options.fullRun, "full", false, "Also process files organized by previous runs into -output")
+	flag.BoolVar(&options.merge, "merge", false, "Add to existing location folders in -output, even renamed ones")
+	flag.BoolVar(&options.dedupe, "dedupe", false, "Copy files with the same contents only once, even when they fall in different clusters")
+	flag.BoolVar(&options.sync, "sync", false, "Flush each copy to disk before giving it its final name")
+	flag.BoolVar(&options.split, "split", false, "Split clusters whose files form clearly separate places into one cluster each")
+	flag.IntVar(&options.minCluster, "min-cluster-size", 0, "Merge clusters with fewer files than this into the nearest larger cluster within 50 km, or into "+MiscLocationsClusterName)
+	flag.StringVar(&options.places, "places", "", "JSON file of labeled places that name clusters, e.g. [{\"name\": \"Home\", \"lat\": 51.5, \"lng\": -0.12, \"radius\": 200}]")
+	flag.BoolVar(&options.listFormats, "list-formats", false, "List the supported file extensions, which need exiftool, and whether exiftool is available (as JSON with -json)")
+	flag.IntVar(&options.simulate, "simulate", 0, "Benchmark clustering on this many synthetic files, without touching any files")
+	flag.Int64Var(&options.seed, "seed", 1, "Random seed for -simulate")
+	flag.IntVar(&options.workers, "workers", 0, "Threads decoding metadata in-process (default: one per CPU core)")
+	flag.IntVar(&options.exifWorkers, "exiftool-workers", 0, "Concurrent exiftool processes for videos, audio and HEIC fallbacks (default: two per CPU core, up to 16)")
+	flag.Float64Var(&options.sensitivity, "sensitivity", 0, "Location sensitivity in degrees (default 0.001, about 100m)")
+	flag.Float64Var(&options.maxGPSError, "max-gps-error", 0, "Treat files whose GPS position is recorded as less accurate than this many meters as having no GPS")
+	flag.IntVar(&options.lookupCache, "lookup-cache", 0, "Grid cells whose timezone and place lookups are kept in memory (default 10000)")
+	flag.StringVar(&options.cpuProfile, "cpuprofile", "", "Write a CPU profile of the run to this file")
+	flag.StringVar(&options.memProfile, "memprofile", "", "Write a memory profile to this file when the run finishes")
+	flag.Usage = printUsage
+	flag.Parse()
+
+	return options, options.source != "" || options.output != "" || options.jsonEvents || options.simulate > 0 || options.listFormats
+}
+
+// printUsage lists the command-line flags, except the hidden ones
+func printUsage() {
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(flag.CommandLine.Output())
+	flag.VisitAll(func(f *flag.Flag) {
+		if !hiddenFlags[f.Name] {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fmt.Fprintf(visible.Output(), "Usage of %s:\n", os.Args[0])
+	visible.PrintDefaults()
+}
+
+// headlessObserver writes the log, and optionally JSON events, to the terminal
+type headlessObserver struct {
+	mutex  sync.Mutex
+	log    io.Writer
+	events *json.Encoder // Nil unless JSON events were requested
+}
+
+// OnLog writes a log message as is
+func (ho *headlessObserver) OnLog(message string) {
+	ho.mutex.Lock()
+	defer ho.mutex.Unlock()
+	fmt.Fprint(ho.log, message)
+}
+
+// OnProgress does nothing: file_processed events already report each file
+func (ho *headlessObserver) OnProgress(processed, total int64) {}
+
+// OnPhaseChange does nothing: phases are reported as events
+func (ho *headlessObserver) OnPhaseChange(phase Phase) {}
+
+// OnEvent writes event as a single line of JSON
+func (ho *headlessObserver) OnEvent(event Event) {
+	if ho.events == nil {
+		return
+	}
+
+	ho.mutex.Lock()
+	defer ho.mutex.Unlock()
+	if err := ho.events.Encode(event); err != nil {
+		fmt.Fprintf(ho.log, "Error writing event: %v\n", err)
+	}
+}
+
+// runHeadless organizes options.source into options.output with the default settings
+// and returns the process exit code
+func runHeadless(options headlessOptions) int {
+	if options.simulate > 0 {
+		return runSimulation(options)
+	}
+	if options.listFormats {
+		return listFormats(options)
+	}
+
+	observer := &headlessObserver{log: os.Stdout}
+	if options.jsonEvents {
+		observer.log = os.Stderr
+		observer.events = json.NewEncoder(os.Stdout)
+	}
+
+	organizer := NewOrganizer(observer)
+	organizer.sourceFolder = options.source
+	organizer.outputFolder = options.output
+	organizer.forceFullRun = options.fullRun
+	organizer.mergeLibrary = options.merge
+	organizer.dedupeClusters = options.dedupe
+	organizer.splitCells = options.split
+	organizer.minClusterSize = options.minCluster
+	organizer.syncCopies = options.sync
+	organizer.maxGPSError = options.maxGPSError
+	options.applyTuning(organizer)
+
+	if options.places != "" {
+		places, err := readNamedPlaces(options.places)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Could not read labeled places: %v\n", err)
+			return 2
+		}
+		organizer.namedPlaces = places
+	}
+
+	if err := organizer.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	setupExifTool("")
+
+	if err := organizer.Run(); err != nil {
+		return 1
+	}
+	return 0
+}
+
+// applyTuning applies the performance and profiling flags that were given to organizer
+func (options headlessOptions) applyTuning(organizer *Organizer) {
+	if options.workers > 0 {
+		organizer.decodeWorkers = options.workers
+	}
+	if options.exifWorkers > 0 {
+		organizer.exiftoolWorkers = options.exifWorkers
+	}
+	if options.sensitivity > 0 {
+		organizer.locationSensitivity = options.sensitivity
+	}
+	if options.lookupCache > 0 {
+		organizer.lookupCacheSize = options.lookupCache
+	}
+	organizer.cpuProfilePath = options.cpuProfile
+	organizer.memProfilePath = options.memProfile
+}
+
+// listFormats prints the supported formats and the exiftool status
+func listFormats(options headlessOptions) int {
+	setupExifTool("")
+	capabilities := NewOrganizer(&headlessObserver{log: io.Discard}).Capabilities()
+
+	if options.jsonEvents {
+		if err := json.NewEncoder(os.Stdout).Encode(capabilities); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing formats: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Print(capabilities)
+	return 0
+}
+
+// runSimulation clusters synthetic files and prints the report
+func runSimulation(options headlessOptions) int {
+	// Per-file log lines would swamp the report and skew the timing
+	organizer := NewOrganizer(&headlessObserver{log: io.Discard})
+	options.applyTuning(organizer)
+	stopProfiling := organizer.startProfiling()
+	report := organizer.Simulate(options.simulate, options.seed)
+	stopProfiling()
+
+	if options.jsonEvents {
+		if err := json.NewEncoder(os.Stdout).Encode(report); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Println(report)
+	return 0
+}
@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// HEIC/HEIF files are ISO Base Media File Format containers. Their EXIF block is stored
+// as an item of type "Exif" inside the top-level meta box: the iinf box names the item
+// and the iloc box says where its bytes live. This is just enough of a parser to find
+// that item and hand it to goexif, so the common case doesn't need exiftool.
+
+// maxHEICExifSize guards against corrupt iloc entries requesting huge allocations
+const maxHEICExifSize = 16 << 20
+
+var errHEICNoExif = errors.New("heic: no Exif item found")
+
+// isoBox describes the payload of a single ISOBMFF box
+type isoBox struct {
+	boxType string
+	offset  int64 // Start of the payload within the file
+	size    int64 // Payload size, excluding the box header
+}
+
+// heicExtent is one contiguous run of an item's data
+type heicExtent struct {
+	offset int64
+	length int64
+}
+
+// readHEICExif locates and decodes the EXIF metadata embedded in a HEIC/HEIF file of
+// size bytes
+func readHEICExif(file io.ReaderAt, size int64) (*exif.Exif, error) {
+	topLevel, err := readISOBoxes(file, 0, size)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, found := findISOBox(topLevel, "meta")
+	if !found {
+		return nil, errors.New("heic: no meta box")
+	}
+
+	// meta is a full box: skip its version and flags
+	metaChildren, err := readISOBoxes(file, meta.offset+4, meta.offset+meta.size)
+	if err != nil {
+		return nil, err
+	}
+
+	iinf, foundInfo := findISOBox(metaChildren, "iinf")
+	iloc, foundLoc := findISOBox(metaChildren, "iloc")
+	if !foundInfo || !foundLoc {
+		return nil, errHEICNoExif
+	}
+
+	exifItemID, err := findHEICExifItemID(file, iinf)
+	if err != nil {
+		return nil, err
+	}
+
+	extents, err := findHEICItemExtents(file, iloc, exifItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload []byte
+	for _, extent := range extents {
+		if extent.length <= 0 || int64(len(payload))+extent.length > maxHEICExifSize {
+			return nil, errors.New("heic: invalid Exif item size")
+		}
+		chunk := make([]byte, extent.length)
+		if _, err := file.ReadAt(chunk, extent.offset); err != nil {
+			return nil, fmt.Errorf("heic: reading Exif item: %w", err)
+		}
+		payload = append(payload, chunk...)
+	}
+
+	// The item starts with a 4-byte offset to the TIFF header (usually skipping "Exif\0\0")
+	if len(payload) < 4 {
+		return nil, errors.New("heic: truncated Exif item")
+	}
+	tiffOffset := int64(binary.BigEndian.Uint32(payload[:4]))
+	if 4+tiffOffset >= int64(len(payload)) {
+		return nil, errors.New("heic: invalid TIFF header offset")
+	}
+
+	return decodeExif(bytes.NewReader(payload[4+tiffOffset:]))
+}
+
+// readISOBoxes reads the sequence of boxes stored between start and end
+func readISOBoxes(r io.ReaderAt, start, end int64) ([]isoBox, error) {
+	var boxes []isoBox
+
+	for pos := start; pos+8 <= end; {
+		var header [16]byte
+		if _, err := r.ReadAt(header[:8], pos); err != nil {
+			return nil, fmt.Errorf("heic: reading box header: %w", err)
+		}
+
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+
+		switch size {
+		case 1:
+			// 64-bit "largesize" follows the type
+			if _, err := r.ReadAt(header[8:16], pos+8); err != nil {
+				return nil, fmt.Errorf("heic: reading box header: %w", err)
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		case 0:
+			// Box extends to the end of its container
+			size = end - pos
+		}
+
+		if size < headerSize || pos+size > end {
+			return nil, fmt.Errorf("heic: invalid %q box size", boxType)
+		}
+
+		boxes = append(boxes, isoBox{boxType: boxType, offset: pos + headerSize, size: size - headerSize})
+		pos += size
+	}
+
+	return boxes, nil
+}
+
+// findISOBox returns the first box of the given type
+func findISOBox(boxes []isoBox, boxType string) (isoBox, bool) {
+	for _, box := range boxes {
+		if box.boxType == boxType {
+			return box, true
+		}
+	}
+	return isoBox{}, false
+}
+
+// readISOBoxPayload reads a (small) box payload into memory
+func readISOBoxPayload(r io.ReaderAt, box isoBox) ([]byte, error) {
+	if box.size > maxHEICExifSize {
+		return nil, fmt.Errorf("heic: %q box too large", box.boxType)
+	}
+	data := make([]byte, box.size)
+	if _, err := r.ReadAt(data, box.offset); err != nil {
+		return nil, fmt.Errorf("heic: reading %q box: %w", box.boxType, err)
+	}
+	return data, nil
+}
+
+// findHEICExifItemID scans the item info box for the item of type "Exif"
+func findHEICExifItemID(r io.ReaderAt, iinf isoBox) (uint64, error) {
+	data, err := readISOBoxPayload(r, iinf)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor := &byteCursor{data: data}
+	version := cursor.uint(1)
+	cursor.skip(3) // flags
+	if version == 0 {
+		cursor.uint(2) // entry_count
+	} else {
+		cursor.uint(4)
+	}
+	if cursor.err != nil {
+		return 0, cursor.err
+	}
+
+	entries, err := readISOBoxes(r, iinf.offset+int64(cursor.pos), iinf.offset+iinf.size)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		if entry.boxType != "infe" {
+			continue
+		}
+
+		entryData, err := readISOBoxPayload(r, entry)
+		if err != nil {
+			return 0, err
+		}
+
+		// Only version 2+ item info entries carry an item type
+		entryCursor := &byteCursor{data: entryData}
+		entryVersion := entryCursor.uint(1)
+		entryCursor.skip(3) // flags
+		if entryVersion < 2 {
+			continue
+		}
+
+		var itemID uint64
+		if entryVersion == 2 {
+			itemID = entryCursor.uint(2)
+		} else {
+			itemID = entryCursor.uint(4)
+		}
+		entryCursor.skip(2) // item_protection_index
+		itemType := entryCursor.bytes(4)
+
+		if entryCursor.err == nil && string(itemType) == "Exif" {
+			return itemID, nil
+		}
+	}
+
+	return 0, errHEICNoExif
+}
+
+// findHEICItemExtents reads the item location box and returns the file extents of itemID
+func findHEICItemExtents(r io.ReaderAt, iloc isoBox, itemID uint64) ([]heicExtent, error) {
+	data, err := readISOBoxPayload(r, iloc)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor := &byteCursor{data: data}
+	version := cursor.uint(1)
+	cursor.skip(3) // flags
+
+	sizes := cursor.uint(1)
+	offsetSize, lengthSize := int(sizes>>4), int(sizes&0x0f)
+	sizes = cursor.uint(1)
+	baseOffsetSize, indexSize := int(sizes>>4), int(sizes&0x0f)
+	if version == 0 {
+		indexSize = 0 // reserved in version 0
+	}
+
+	var itemCount uint64
+	if version < 2 {
+		itemCount = cursor.uint(2)
+	} else {
+		itemCount = cursor.uint(4)
+	}
+
+	for i := uint64(0); i < itemCount && cursor.err == nil; i++ {
+		var id uint64
+		if version < 2 {
+			id = cursor.uint(2)
+		} else {
+			id = cursor.uint(4)
+		}
+
+		constructionMethod := uint64(0)
+		if version == 1 || version == 2 {
+			constructionMethod = cursor.uint(2) & 0x0f
+		}
+		cursor.uint(2) // data_reference_index
+		baseOffset := cursor.uint(baseOffsetSize)
+		extentCount := cursor.uint(2)
+
+		var extents []heicExtent
+		for e := uint64(0); e < extentCount && cursor.err == nil; e++ {
+			if indexSize > 0 {
+				cursor.uint(indexSize) // extent_index
+			}
+			extentOffset := cursor.uint(offsetSize)
+			extentLength := cursor.uint(lengthSize)
+			extents = append(extents, heicExtent{
+				offset: int64(baseOffset + extentOffset),
+				length: int64(extentLength),
+			})
+		}
+
+		if id != itemID {
+			continue
+		}
+		if cursor.err != nil {
+			break
+		}
+
+		// Only items stored directly in the file (not in idat or other items) are supported
+		if constructionMethod != 0 {
+			return nil, fmt.Errorf("heic: unsupported Exif construction method %d", constructionMethod)
+		}
+		if len(extents) == 0 {
+			return nil, errHEICNoExif
+		}
+		return extents, nil
+	}
+
+	if cursor.err != nil {
+		return nil, cursor.err
+	}
+	return nil, errHEICNoExif
+}
+
+// byteCursor reads big-endian fields from a byte slice, remembering the first error
+type byteCursor struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// uint reads an n-byte big-endian unsigned integer (n may be 0)
+func (c *byteCursor) uint(n int) uint64 {
+	var value uint64
+	for _, b := range c.bytes(n) {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+// bytes returns the next n bytes
+func (c *byteCursor) bytes(n int) []byte {
+	if c.err != nil {
+		return nil
+	}
+	if n < 0 || c.pos+n > len(c.data) {
+		c.err = errors.New("heic: truncated box")
+		return nil
+	}
+	b := c.data[c.pos : c.pos+n]
+	c.pos += n
+	return b
+}
+
+// skip advances past n bytes
+func (c *byteCursor) skip(n int) {
+	c.bytes(n)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IgnoreFileName is the marker file that keeps a folder, or the entries below it matching
+// its patterns, out of scans
+const IgnoreFileName = ".organizer-ignore"
+
+// outputIgnoreMarker is written into the output folder after a successful run. Holding
+// no patterns, it keeps the whole folder out of scans that include it.
+const outputIgnoreMarker = `# Written by Media Organizer: this folder holds organized files, so scans of a folder
+# containing it skip it. Delete this file to have it scanned again, or list patterns
+# (one per line, like .gitignore) to skip only the entries matching them.
+`
+
+// ignorePattern is one line of an ignore marker
+type ignorePattern struct {
+	pattern  string
+	dirOnly  bool // The line ended in "/": only folders match
+	anchored bool // The pattern has a "/": matched against the path below the marker's folder
+}
+
+// ignoreRules are the patterns of the marker in one folder; a marker without patterns
+// ignores its whole folder
+type ignoreRules struct {
+	patterns []ignorePattern
+	all      bool
+}
+
+// parseIgnoreFile reads an ignore marker. Blank lines and lines starting with "#" are
+// skipped; every other line is a path.Match pattern.
+func parseIgnoreFile(data []byte) ignoreRules {
+	var rules ignoreRules
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var pattern ignorePattern
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			pattern.anchored = true
+			line = strings.TrimLeft(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		pattern.pattern = line
+		rules.patterns = append(rules.patterns, pattern)
+	}
+	rules.all = len(rules.patterns) == 0
+	return rules
+}
+
+// matches reports whether the entry at rel, a slash-separated path below the marker's
+// folder, matches the pattern. Unanchored patterns match the entry's name at any depth.
+func (p ignorePattern) matches(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if !p.anchored {
+		rel = path.Base(rel)
+	}
+	ok, _ := path.Match(p.pattern, rel)
+	return ok
+}
+
+// loadIgnoreMarker records the marker in the folder name of fsys, shown as dir, and
+// reports whether it ignores the whole folder
+func (org *Organizer) loadIgnoreMarker(fsys fs.FS, name, dir string, scan *mediaScan) bool {
+	data, err := fs.ReadFile(fsys, path.Join(name, IgnoreFileName))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			org.safeLog(fmt.Sprintf("Warning: Could not read %s: %v\n", filepath.Join(dir, IgnoreFileName), err))
+		}
+		return false
+	}
+
+	rules := parseIgnoreFile(data)
+	if rules.all {
+		// The folder being scanned was chosen explicitly, so organizing an existing
+		// library still works
+		if dir == scan.root {
+			org.safeLog(fmt.Sprintf("Scanning %s despite its %s, since it was chosen as the source\n", dir, IgnoreFileName))
+			return false
+		}
+		return true
+	}
+	scan.ignores[dir] = rules
+	return false
+}
+
+// isIgnored reports whether the entry at entryPath matches a pattern of a marker in one of
+// the folders above it
+func (scan *mediaScan) isIgnored(entryPath string, isDir bool) bool {
+	if len(scan.ignores) == 0 {
+		return false
+	}
+	for dir := filepath.Dir(entryPath); ; dir = filepath.Dir(dir) {
+		if rules, ok := scan.ignores[dir]; ok {
+			if rel, err := filepath.Rel(dir, entryPath); err == nil {
+				for _, pattern := range rules.patterns {
+					if pattern.matches(filepath.ToSlash(rel), isDir) {
+						return true
+					}
+				}
+			}
+		}
+		if dir == scan.root || dir == filepath.Dir(dir) {
+			return false
+		}
+	}
+}
+
+// writeOutputIgnoreMarker marks the output folder so later scans that include it don't
+// organize the organized files again. An existing marker is left as it is.
+func (org *Organizer) writeOutputIgnoreMarker() {
+	ioPath, _ := longPath(filepath.Join(org.outputFolder, IgnoreFileName))
+	file, err := os.OpenFile(ioPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if !errors.Is(err, fs.ErrExist) {
+			org.safeLog(fmt.Sprintf("Warning: Could not write %s: %v\n", IgnoreFileName, err))
+		}
+		return
+	}
+	_, err = file.WriteString(outputIgnoreMarker)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not write %s: %v\n", IgnoreFileName, err))
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// FolderMetadataFileName is the file in each location folder recording where its files
+	// were taken, so the folder can be renamed (say to "Paris") and still be recognized
+	FolderMetadataFileName = ".organizer.json"
+	// folderMetadataVersion is bumped when the folder metadata format changes incompatibly
+	folderMetadataVersion = 1
+)
+
+// errNewerFolderMetadata is returned for folder metadata written by a newer version,
+// which is neither used nor overwritten
+var errNewerFolderMetadata = errors.New("written by a newer version of the organizer")
+
+// folderPoint is the center of a cluster copied into a location folder
+type folderPoint struct {
+	Lat         float64 `json:"lat"`
+	Lng         float64 `json:"lng"`
+	Sensitivity float64 `json:"sensitivity"` // Grid size of the run that made the cluster, in degrees
+}
+
+// folderMetadata is the contents of a location folder's FolderMetadataFileName
+type folderMetadata struct {
+	Version int           `json:"version"`
+	Name    string        `json:"name"`   // Cluster name the folder was created with
+	Points  []folderPoint `json:"points"` // Centers of the clusters copied into the folder
+}
+
+// libraryFolder is a location folder already in the output folder
+type libraryFolder struct {
+	Name     string
+	Metadata folderMetadata
+}
+
+// Renamed reports whether the folder no longer has the name it was created with
+func (lf libraryFolder) Renamed() bool {
+	return lf.Name != lf.Metadata.Name
+}
+
+// readFolderMetadata reads the metadata of the location folder at folder. Metadata
+// from before versioning (version 0) reads as version 1 with no name and points sized
+// by the current sensitivity.
+func (org *Organizer) readFolderMetadata(folder string) (folderMetadata, error) {
+	var metadata folderMetadata
+	data, err := os.ReadFile(filepath.Join(folder, FolderMetadataFileName))
+	if err != nil {
+		return metadata, err
+	}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return metadata, fmt.Errorf("reading %s: %w", FolderMetadataFileName, err)
+	}
+	if metadata.Version > folderMetadataVersion {
+		return metadata, fmt.Errorf("%s has version %d: %w", FolderMetadataFileName, metadata.Version, errNewerFolderMetadata)
+	}
+
+	metadata.Version = folderMetadataVersion
+	if metadata.Name == "" {
+		metadata.Name = filepath.Base(folder)
+	}
+	for i := range metadata.Points {
+		if metadata.Points[i].Sensitivity <= 0 {
+			metadata.Points[i].Sensitivity = org.locationSensitivity
+		}
+	}
+	return metadata, nil
+}
+
+// recordFolderPoint adds a cluster center to the metadata of the location folder it was
+// copied into, unless a point already recorded there is close to it. A folder's first
+// metadata also records its name. Folders that don't exist (because nothing was copied)
+// are left alone.
+func (org *Organizer) recordFolderPoint(folder string, lat, lng float64) {
+	if stat, err := os.Stat(folder); err != nil || !stat.IsDir() {
+		return
+	}
+
+	metadata, err := org.readFolderMetadata(folder)
+	if errors.Is(err, errNewerFolderMetadata) {
+		return
+	} else if errors.Is(err, os.ErrNotExist) {
+		metadata = folderMetadata{Version: folderMetadataVersion, Name: filepath.Base(folder)}
+	} else if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Replacing unreadable folder metadata in %s: %v\n", filepath.Base(folder), err))
+		metadata = folderMetadata{Version: folderMetadataVersion, Name: filepath.Base(folder)}
+	}
+	for _, point := range metadata.Points {
+		if angularDistance(point.Lat, point.Lng, lat, lng) < org.locationSensitivity/2 {
+			return
+		}
+	}
+
+	metadata.Points = append(metadata.Points, folderPoint{Lat: lat, Lng: lng, Sensitivity: org.locationSensitivity})
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(folder, FolderMetadataFileName), data, 0644)
+	}
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not write folder metadata in %s: %v\n", filepath.Base(folder), err))
+	}
+}
+
+// loadLibraryFolders returns the top-level folders of outputFolder that have location
+// metadata from an earlier run, or a coordinate name from before it was recorded
+func (org *Organizer) loadLibraryFolders(outputFolder string) ([]libraryFolder, error) {
+	entries, err := os.ReadDir(outputFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	var folders []libraryFolder
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		metadata, err := org.readFolderMetadata(filepath.Join(outputFolder, entry.Name()))
+		if errors.Is(err, os.ErrNotExist) {
+			// Names then had four decimals whatever the sensitivity, so they seldom
+			// match the names clusters get now
+			if lat, lng, ok := parseLocationName(entry.Name()); ok {
+				metadata = folderMetadata{Version: folderMetadataVersion, Name: entry.Name(),
+					Points: []folderPoint{{Lat: lat, Lng: lng, Sensitivity: org.locationSensitivity}}}
+				err = nil
+			}
+		}
+		if errors.Is(err, errNewerFolderMetadata) {
+			org.safeLog(fmt.Sprintf("Ignoring location folder %s: %v\n", entry.Name(), err))
+			continue
+		} else if err != nil || len(metadata.Points) == 0 {
+			continue
+		}
+		folders = append(folders, libraryFolder{Name: entry.Name(), Metadata: metadata})
+	}
+	return folders, nil
+}
+
+// matchLibraryFolders renames each located cluster after the existing location folder
+// whose area covers its center. A folder's area is everything within the recorded
+// sensitivity of a center recorded in it, and the nearest folder wins. Folders that
+// still have the coordinate name they were created with always match, so names don't
+// drift as later runs' centers shift a little; in merge mode renamed folders match
+// too, so a run adds to them instead of minting coordinate folders beside them.
+func (org *Organizer) matchLibraryFolders(clusters []LocationCluster) {
+	folders, err := org.loadLibraryFolders(org.outputFolder)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		org.safeLog(fmt.Sprintf("Warning: Could not read existing location folders: %v\n", err))
+		return
+	}
+
+	merged := 0
+	for i := range clusters {
+		cluster := &clusters[i]
+		if !cluster.HasLocation || cluster.Place {
+			// A labeled place's name wins over any existing folder
+			continue
+		}
+
+		best, bestDistance := "", math.Inf(1)
+		for _, folder := range folders {
+			if folder.Renamed() && !org.mergeLibrary {
+				continue
+			}
+			for _, point := range folder.Metadata.Points {
+				distance := angularDistance(point.Lat, point.Lng, cluster.CenterLat, cluster.CenterLng)
+				if distance <= point.Sensitivity && distance < bestDistance {
+					best, bestDistance = folder.Name, distance
+				}
+			}
+		}
+
+		if best != "" && best != cluster.Name {
+			org.safeLog(fmt.Sprintf("Using existing folder %s for cluster %s\n", best, cluster.Name))
+			cluster.Name = best
+			merged++
+		}
+	}
+
+	if merged > 0 {
+		org.safeLog(fmt.Sprintf("Placed %d clusters in existing location folders\n", merged))
+	}
+}
+
+// locationNamePattern matches the coordinate names formatLocation makes, e.g. 48.8566N_2.3522E
+var locationNamePattern = regexp.MustCompile(`^(\d{1,2}(?:\.\d+)?)([NS])_(\d{1,3}(?:\.\d+)?)([EW])$`)
+
+// parseLocationName returns the position a coordinate folder name such as
+// 48.8566N_2.3522E stands for
+func parseLocationName(name string) (lat, lng float64, ok bool) {
+	matches := locationNamePattern.FindStringSubmatch(name)
+	if matches == nil {
+		return 0, 0, false
+	}
+	lat, _ = strconv.ParseFloat(matches[1], 64)
+	lng, _ = strconv.ParseFloat(matches[3], 64)
+	if matches[2] == "S" {
+		lat = -lat
+	}
+	if matches[4] == "W" {
+		lng = -lng
+	}
+	return lat, lng, lat <= 90 && lng <= 180
+}
+
+// angularDistance returns the great-circle distance between two positions in degrees of arc
+func angularDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	x1, y1, z1 := unitVector(lat1, lng1)
+	x2, y2, z2 := unitVector(lat2, lng2)
+	// Chord length is well-conditioned even for the tiny distances between neighbors,
+	// where the arccosine of a dot product loses all precision
+	chord := math.Sqrt((x1-x2)*(x1-x2) + (y1-y2)*(y1-y2) + (z1-z2)*(z1-z2))
+	return 2 * math.Asin(min(chord/2, 1)) * 180 / math.Pi
+}
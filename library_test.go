@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFolderMetadataRoundTrip(t *testing.T) {
+	org := NewOrganizer(nil)
+	org.outputFolder = t.TempDir()
+	folder := filepath.Join(org.outputFolder, "48.857N_2.352E")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	org.recordFolderPoint(folder, 48.8566, 2.3522)
+	org.recordFolderPoint(folder, 48.8567, 2.3522) // Within half a cell of the first
+	org.recordFolderPoint(folder, 48.8600, 2.3600)
+	metadata, err := org.readFolderMetadata(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Version != folderMetadataVersion || metadata.Name != "48.857N_2.352E" || len(metadata.Points) != 2 {
+		t.Fatalf("read back %+v, want the folder's name and two points", metadata)
+	}
+	if point := metadata.Points[0]; point.Lat != 48.8566 || point.Lng != 2.3522 || point.Sensitivity != org.locationSensitivity {
+		t.Errorf("first point read back as %+v", point)
+	}
+
+	// Renamed, the folder only takes new clusters in merge mode
+	if err := os.Rename(folder, filepath.Join(org.outputFolder, "Paris")); err != nil {
+		t.Fatal(err)
+	}
+	for _, merge := range []bool{false, true} {
+		org.mergeLibrary = merge
+		clusters := []LocationCluster{{Name: "48.857N_2.353E", CenterLat: 48.8568, CenterLng: 2.3525, HasLocation: true}}
+		org.matchLibraryFolders(clusters)
+		if want := map[bool]string{false: "48.857N_2.353E", true: "Paris"}[merge]; clusters[0].Name != want {
+			t.Errorf("merge mode %v: cluster named %s, want %s", merge, clusters[0].Name, want)
+		}
+	}
+}
+
+func TestNewerFolderMetadataIsLeftAlone(t *testing.T) {
+	org := NewOrganizer(nil)
+	org.outputFolder = t.TempDir()
+	folder := filepath.Join(org.outputFolder, "Paris")
+	if err := os.Mkdir(folder, 0755); err != nil {
+		t.Fatal(err)
+	}
+	newer := []byte(`{"version": 99, "name": "48.857N_2.352E", "points": [{"lat": 48.8566, "lng": 2.3522}]}`)
+	path := filepath.Join(folder, FolderMetadataFileName)
+	if err := os.WriteFile(path, newer, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	org.recordFolderPoint(folder, 10, 10)
+	if data, err := os.ReadFile(path); err != nil || string(data) != string(newer) {
+		t.Errorf("newer metadata rewritten as %s (%v)", data, err)
+	}
+	org.mergeLibrary = true
+	clusters := []LocationCluster{{Name: "48.857N_2.352E", CenterLat: 48.8566, CenterLng: 2.3522, HasLocation: true}}
+	org.matchLibraryFolders(clusters)
+	if clusters[0].Name != "48.857N_2.352E" {
+		t.Errorf("cluster placed in %s, whose metadata can't be read", clusters[0].Name)
+	}
+}
+
+func TestLegacyCoordinateFoldersMatch(t *testing.T) {
+	org := NewOrganizer(nil)
+	org.outputFolder = t.TempDir()
+	for _, name := range []string{"48.8566N_2.3522E", "33.8688S_151.2093E", "Paris"} {
+		if err := os.Mkdir(filepath.Join(org.outputFolder, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clusters := []LocationCluster{
+		{Name: "48.857N_2.352E", CenterLat: 48.85664, CenterLng: 2.35218, HasLocation: true},
+		{Name: "33.869S_151.209E", CenterLat: -33.86884, CenterLng: 151.20934, HasLocation: true},
+		{Name: "48.870N_2.330E", CenterLat: 48.87, CenterLng: 2.33, HasLocation: true}, // Too far from any
+	}
+	org.matchLibraryFolders(clusters)
+	for i, want := range []string{"48.8566N_2.3522E", "33.8688S_151.2093E", "48.870N_2.330E"} {
+		if clusters[i].Name != want {
+			t.Errorf("cluster at %v, %v named %s, want %s", clusters[i].CenterLat, clusters[i].CenterLng, clusters[i].Name, want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+// longPath returns path unchanged: only Windows limits whole paths to MAX_PATH
+func longPath(path string) (string, bool) {
+	return path, false
+}
+
+// isPathTooLong reports whether err came from a path or filename longer than the
+// filesystem allows
+func isPathTooLong(err error) bool {
+	return errors.Is(err, syscall.ENAMETOOLONG)
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// longPath returns path in the extended-length \\?\ form when it is too long for
+// Windows' classic MAX_PATH limit, and whether it did. Directories are limited to 12
+// characters less than files, so the check leaves room for either.
+func longPath(path string) (string, bool) {
+	if len(path) < windowsMaxPath-12 || strings.HasPrefix(path, `\\?\`) {
+		return path, false
+	}
+	// The extended form must be absolute and clean, as Windows no longer resolves
+	// relative paths or . and .. components in it
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path, false
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:], true
+	}
+	return `\\?\` + abs, true
+}
+
+// isPathTooLong reports whether err came from a path or filename longer than the
+// filesystem allows
+func isPathTooLong(err error) bool {
+	return errors.Is(err, syscall.ENAMETOOLONG) || errors.Is(err, windows.ERROR_FILENAME_EXCED_RANGE)
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLongPath(t *testing.T) {
+	short := `C:\Photos\Paris\03-15-2024\IMG_1234.jpg`
+	if got, extended := longPath(short); got != short || extended {
+		t.Errorf("longPath(%q) = %q, %v; want it unchanged", short, got, extended)
+	}
+
+	deep := `C:\Photos\` + strings.Repeat(`Very long location name\`, 12) + `IMG_1234.jpg`
+	got, extended := longPath(deep)
+	if !extended || got != `\\?\`+filepath.Clean(deep) {
+		t.Errorf("longPath of a %d-character path = %q, %v; want the \\\\?\\ form", len(deep), got, extended)
+	}
+	if again, extended := longPath(got); again != got || extended {
+		t.Errorf("longPath of an extended path changed it to %q", again)
+	}
+
+	share := `\\server\share\` + strings.Repeat(`Very long location name\`, 12) + `IMG_1234.jpg`
+	if got, _ := longPath(share); got != `\\?\UNC\`+filepath.Clean(share)[2:] {
+		t.Errorf("longPath of a long network path = %q, want the \\\\?\\UNC\\ form", got)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// LookupCache is a bounded, least-recently-used cache for lookups derived from a
+// location, such as timezones or place names. Keying it by grid cell lets every file in
+// a cell share one lookup. It is safe for concurrent use by the workers.
+type LookupCache[V any] struct {
+	mutex    sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // Entries, most recently used first
+	hits     atomic.Int64
+	misses   atomic.Int64
+}
+
+// lookupEntry is a cached lookup; done is closed once value is set, so concurrent
+// requests for a cell still being looked up wait for that lookup instead of repeating it
+type lookupEntry[V any] struct {
+	key   string
+	value V
+	done  chan struct{}
+}
+
+// NewLookupCache creates a cache holding at most capacity entries
+func NewLookupCache[V any](capacity int) *LookupCache[V] {
+	return &LookupCache[V]{
+		capacity: max(capacity, 1),
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns the cached value for key, calling lookup to produce it on a miss
+func (lc *LookupCache[V]) Get(key string, lookup func() V) V {
+	lc.mutex.Lock()
+	if element, ok := lc.entries[key]; ok {
+		lc.order.MoveToFront(element)
+		lc.mutex.Unlock()
+		lc.hits.Add(1)
+		entry := element.Value.(*lookupEntry[V])
+		<-entry.done
+		return entry.value
+	}
+
+	entry := &lookupEntry[V]{key: key, done: make(chan struct{})}
+	lc.entries[key] = lc.order.PushFront(entry)
+	for lc.order.Len() > lc.capacity {
+		oldest := lc.order.Back()
+		lc.order.Remove(oldest)
+		delete(lc.entries, oldest.Value.(*lookupEntry[V]).key)
+	}
+	lc.mutex.Unlock()
+	lc.misses.Add(1)
+
+	// Look up outside the lock, so a slow lookup only holds up files in the same cell
+	entry.value = lookup()
+	close(entry.done)
+	return entry.value
+}
+
+// Stats returns how many lookups were answered from the cache and how many weren't
+func (lc *LookupCache[V]) Stats() (hits, misses int64) {
+	return lc.hits.Load(), lc.misses.Load()
+}
+
+// gridCellKey identifies the grid cell of the given size containing a point
+func gridCellKey(lat, lng, sensitivity float64) string {
+	gridLat := math.Floor(lat/sensitivity) * sensitivity
+	gridLng := math.Floor(lng/sensitivity) * sensitivity
+	return fmt.Sprintf("%.6f,%.6f", gridLat, gridLng)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// ManifestFileName is the manifest of organized files kept in the output folder
+	ManifestFileName = ".media-organizer-manifest.json"
+	// manifestVersion is bumped when the manifest format changes incompatibly
+	manifestVersion = 1
+)
+
+// manifestEntry records a source file as it was when a run organized it
+type manifestEntry struct {
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mtime"`
+	Destination string    `json:"destination"`
+}
+
+// Manifest lists the source files already copied into an output folder, so that later
+// runs over the same source skip them unless they have changed since
+type Manifest struct {
+	path  string
+	files map[string]manifestEntry // By absolute source path
+	dirty bool
+	mutex sync.Mutex
+
+	// stat reads the size and modification time of source files; os.Stat unless they
+	// are somewhere else, such as in an archive
+	stat func(path string) (fs.FileInfo, error)
+}
+
+// manifestFile is the on-disk form of a Manifest
+type manifestFile struct {
+	Version int                      `json:"version"`
+	Files   map[string]manifestEntry `json:"files"`
+}
+
+// LoadManifest reads the manifest in outputFolder. A missing manifest is not an error:
+// it loads as an empty one that Save will create.
+func LoadManifest(outputFolder string) (*Manifest, error) {
+	manifest := &Manifest{
+		path:  filepath.Join(outputFolder, ManifestFileName),
+		files: make(map[string]manifestEntry),
+		stat:  os.Stat,
+	}
+
+	data, err := os.ReadFile(manifest.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	} else if err != nil {
+		return manifest, err
+	}
+
+	var stored manifestFile
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return manifest, fmt.Errorf("reading %s: %w", ManifestFileName, err)
+	}
+	if stored.Version != manifestVersion {
+		return manifest, fmt.Errorf("%s has unsupported version %d", ManifestFileName, stored.Version)
+	}
+	if stored.Files != nil {
+		manifest.files = stored.Files
+	}
+	return manifest, nil
+}
+
+// Unchanged reports whether path was organized by a previous run and still has the
+// size and modification time it had then
+func (m *Manifest) Unchanged(path string) bool {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	m.mutex.Lock()
+	entry, ok := m.files[key]
+	m.mutex.Unlock()
+	if !ok {
+		return false
+	}
+
+	fileInfo, err := m.stat(path)
+	return err == nil && fileInfo.Size() == entry.Size && fileInfo.ModTime().Equal(entry.ModTime)
+}
+
+// Record notes that path was copied to destination
+func (m *Manifest) Record(path, destination string) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	fileInfo, err := m.stat(path)
+	if err != nil {
+		return
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.files[key] = manifestEntry{Size: fileInfo.Size(), ModTime: fileInfo.ModTime(), Destination: destination}
+	m.dirty = true
+}
+
+// Save writes the manifest if anything was recorded since it was last saved, via a
+// temporary file so an interrupted run never leaves it truncated
+func (m *Manifest) Save() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if !m.dirty {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(manifestFile{Version: manifestVersion, Files: m.files}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.path), ".manifest-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), m.path); err != nil {
+		return err
+	}
+	m.dirty = false
+	return nil
+}
+
+// skipOrganizedFiles drops the files the manifest lists as already organized and unchanged
+func (org *Organizer) skipOrganizedFiles(mediaFiles []string) []string {
+	remaining := mediaFiles[:0]
+	skipped := 0
+	for _, path := range mediaFiles {
+		if org.manifest.Unchanged(path) {
+			skipped++
+			continue
+		}
+		remaining = append(remaining, path)
+	}
+
+	if skipped > 0 {
+		org.safeLog(fmt.Sprintf("Skipping %d files organized by a previous run and unchanged since (see %s)\n", skipped, ManifestFileName))
+	}
+	return remaining
+}
+
+// saveManifest writes the manifest, logging rather than failing the run when it can't
+func (org *Organizer) saveManifest() {
+	if err := org.manifest.Save(); err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not save %s: %v\n", ManifestFileName, err))
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestManifestRoundTrip(t *testing.T) {
+	source, output := t.TempDir(), t.TempDir()
+	var paths []string
+	for _, name := range []string{"kept.jpg", "resized.jpg", "touched.jpg", "new.jpg"} {
+		path := filepath.Join(source, name)
+		if err := os.WriteFile(path, []byte("photo"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	kept, resized, touched, unrecorded := paths[0], paths[1], paths[2], paths[3]
+
+	manifest, err := LoadManifest(output)
+	if err != nil {
+		t.Fatalf("loading a missing manifest: %v", err)
+	}
+	for _, path := range []string{kept, resized, touched} {
+		manifest.Record(path, filepath.Join(output, "Paris", filepath.Base(path)))
+	}
+	if err := manifest.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Change two of the recorded files after the run
+	if err := os.WriteFile(resized, []byte("edited photo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(touched, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadManifest(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]bool{kept: true, resized: false, touched: false, unrecorded: false} {
+		if got := loaded.Unchanged(path); got != want {
+			t.Errorf("%s unchanged %v after reloading, want %v", filepath.Base(path), got, want)
+		}
+	}
+	if got := loaded.files[kept].Destination; got != filepath.Join(output, "Paris", "kept.jpg") {
+		t.Errorf("destination reloaded as %q", got)
+	}
+}
+
+func TestManifestRejectsOtherVersions(t *testing.T) {
+	output := t.TempDir()
+	if err := os.WriteFile(filepath.Join(output, ManifestFileName), []byte(`{"version": 99, "files": {}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := LoadManifest(output)
+	if err == nil || !strings.Contains(err.Error(), "unsupported version") {
+		t.Errorf("loading a newer manifest returned %v, want an unsupported version error", err)
+	}
+	if manifest == nil || len(manifest.files) != 0 {
+		t.Error("a manifest that can't be read doesn't load as an empty one")
+	}
+}
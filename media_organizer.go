@@ -1,24 +1,39 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"math"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/rwcarlsen/goexif/exif"
 )
@@ -26,14 +41,192 @@ import (
 const (
 	// BatchSize controls how many files to process at once to manage memory usage
 	DefaultBatchSize = 50
+	// Automatic batch sizing starts small and stays within the slider's range
+	MinAutoBatchSize     = 10
+	InitialAutoBatchSize = 25
+	MaxAutoBatchSize     = 500
+	// DefaultMemoryCeiling is the heap size automatic batch sizing aims to stay under
+	DefaultMemoryCeiling = 512 << 20
+	// MaxDefaultExifToolWorkers caps the default exiftool worker count on many-core machines,
+	// where one exiftool process per worker would cost more memory than it saves time
+	MaxDefaultExifToolWorkers = 16
+	// DefaultLookupCacheSize is how many grid cells' location lookups are kept in memory
+	DefaultLookupCacheSize = 10000
 	// MaxLogLines limits the number of log lines displayed in UI
 	MaxLogLines = 500
 	// UI update interval for better performance
 	UIUpdateInterval = 250 * time.Millisecond
+	// MaxRecentFolders limits how many recent source/output folders are remembered
+	MaxRecentFolders = 10
+)
+
+// Capture time zone interpretations for EXIF timestamps that carry no timezone
+const (
+	TimeZoneLocal = "Local time"
+	TimeZoneUTC   = "UTC"
+)
+
+// The catch-all cluster for files without GPS data. It has no coordinates and never
+// takes part in distance calculations or map exports.
+const (
+	noLocationKey         = "no-location"
+	NoLocationClusterName = "No-Location"
+)
+
+// How files are placed into the output folder. Clones and hard links only work within
+// one filesystem; each mode falls back to the next when it can't be used.
+const (
+	CopyModeCopy     = "Copy"
+	CopyModeHardLink = "Hard link, else copy"
+	CopyModeClone    = "Clone (copy-on-write), else hard link, else copy"
+)
+
+// UnsortedFolder collects files exiftool couldn't read, when that option is enabled
+const UnsortedFolder = "_Unsorted"
+
+// Where a file's date came from
+const (
+	DateSourceMetadata = "Metadata (EXIF/QuickTime)"
+	DateSourceFilename = "Filename timestamp"
+	DateSourceModTime  = "File modification time"
+)
+
+// DefaultDatePriority is the order date sources are trusted in unless configured otherwise
+var DefaultDatePriority = []string{DateSourceMetadata, DateSourceFilename, DateSourceModTime}
+
+// Files dated only by their modification time go to UndatedFolder, when that option is
+// enabled, if the time is implausible: more than UndatedNeighborGap after every properly
+// dated file in the same source folder, or, with no such neighbors, less than
+// UndatedRecentAge old, as happens with freshly downloaded or copied files
+const (
+	UndatedFolder      = "Undated"
+	UndatedNeighborGap = 30 * 24 * time.Hour
+	UndatedRecentAge   = 7 * 24 * time.Hour
+)
+
+// What happens to files without GPS data
+const (
+	NoGPSFolder        = "No-Location folder"
+	NoGPSDateOnly      = "Date folders only"
+	NoGPSNearestInTime = "Borrow nearest-in-time location"
+	NoGPSInterpolate   = "Interpolate between geotagged photos"
+)
+
+// noGPSWindows are the selectable time windows for borrowing a nearby photo's location
+var noGPSWindows = map[string]time.Duration{
+	"15 minutes": 15 * time.Minute,
+	"1 hour":     time.Hour,
+	"3 hours":    3 * time.Hour,
+	"12 hours":   12 * time.Hour,
+}
+
+// memoryCeilings are the selectable heap targets for automatic batch sizing
+var memoryCeilings = map[string]uint64{
+	"256 MB": 256 << 20,
+	"512 MB": 512 << 20,
+	"1 GB":   1 << 30,
+	"2 GB":   2 << 30,
+}
+
+// DefaultElevationFolderBand is the height in meters of each elevation folder when
+// clusters aren't split into elevation bands
+const DefaultElevationFolderBand = 100
+
+// elevationBands are the selectable band heights for elevation-aware clustering
+var elevationBands = map[string]float64{
+	"Off":    0,
+	"100 m":  100,
+	"250 m":  250,
+	"500 m":  500,
+	"1000 m": 1000,
+}
+
+// nameDecimalChoices are the selectable precisions of coordinate folder names
+var nameDecimalChoices = map[string]int{
+	"Match sensitivity":   AutoNameDecimals,
+	"2 decimals (~1 km)":  2,
+	"3 decimals (~110 m)": 3,
+	"4 decimals (~11 m)":  4,
+	"5 decimals (~1 m)":   5,
+}
+
+// Folder organization modes
+const (
+	ModeLocationAndDate = "Location + Date"
+	ModeDateOnly        = "Date only"
+	ModeLocationOnly    = "Location only"
+)
+
+// Conflict policies for when a destination file already exists
+const (
+	ConflictRename     = "Rename (keep both)"
+	ConflictSkip       = "Skip"
+	ConflictOverwrite  = "Overwrite"
+	ConflictKeepNewest = "Keep newest"
+	ConflictKeepLatest = "Keep latest capture"
+)
+
+// How flattened output records a file's original location
+const (
+	AnnotateFilename = "Add to filename"
+	AnnotateSidecar  = "XMP sidecar"
+	AnnotateNone     = "Don't record"
+)
+
+// DefaultAnnotationFormat is the default filename format for flattened files with a location
+const DefaultAnnotationFormat = "{name}_{location}"
+
+// DefaultRenameTemplate is the default filename template for rename-on-copy
+const DefaultRenameTemplate = "{date}_{time}_{original-name}"
+
+// Tokens accepted by the flatten annotation format and the rename template
+var (
+	annotationTokens = []string{"name", "location"}
+	renameTokens     = []string{"date", "time", "original-name", "sequence", "location", "elevation"}
+)
+
+// Date folder granularities
+const (
+	GranularityDay   = "Day"
+	GranularityWeek  = "Week"
+	GranularityMonth = "Month"
+	GranularityYear  = "Year"
+)
+
+// captureTimeKind describes how a parsed timestamp relates to the place it was captured
+type captureTimeKind int
+
+const (
+	// wallClockTime is a naive timestamp with no timezone (EXIF DateTimeOriginal, formatted filename dates)
+	wallClockTime captureTimeKind = iota
+	// absoluteTime is an instant whose local wall clock is unknown (file mtime, QuickTime UTC dates, Unix timestamp names)
+	absoluteTime
+	// zonedTime already carries the capture timezone (EXIF offset tags, exiftool dates with an offset)
+	zonedTime
+)
+
+// dateCandidate is one source's idea of when a file was captured
+type dateCandidate struct {
+	date time.Time
+	kind captureTimeKind
+}
+
+// Preference keys for persisted settings
+const (
+	prefRecentSourceFolders = "recentSourceFolders"
+	prefRecentOutputFolders = "recentOutputFolders"
+	prefExifToolPath        = "exiftoolPath"
+	prefNamedPlaces         = "namedPlaces" // JSON list of NamedPlace
+	prefSettings            = "settings"    // JSON Settings
+	prefNotifyOnComplete    = "notifyOnComplete"
+	prefProgressMode        = "progressMode"
 )
 
 var exiftoolPath string
 
+// exiftoolSource describes where exiftoolPath came from ("custom", "system" or "bundled")
+var exiftoolSource string
+
 // ProcessingResult holds the result of processing a single media file
 type ProcessingResult struct {
 	Info  *ImageInfo
@@ -42,11 +235,19 @@ type ProcessingResult struct {
 
 // WorkerPool manages concurrent media file processing
 type WorkerPool struct {
-	WorkerCount int
-	Jobs        chan string
-	Results     chan ProcessingResult
-	wg          sync.WaitGroup
-	closed      bool
+	DecodeWorkers   int             // Workers for files read in-process
+	ExifToolWorkers int             // Workers for files read with exiftool
+	ctx             context.Context // Cancelling it stops workers and unblocks Submit
+	Jobs            chan string     // Files whose metadata is decoded in-process
+	ExifToolJobs    chan string     // Files whose metadata is read with exiftool
+	Results         chan ProcessingResult
+	wg              sync.WaitGroup
+	mutex           sync.Mutex // Guards closed and sends on the job channels
+	closed          bool
+	closing         chan struct{} // Closed by Close before it takes mutex, ending a Submit waiting for room
+	closingOnce     sync.Once     // Closes closing exactly once
+	jobsOnce        sync.Once     // Closes the job channels exactly once
+	resultsOnce     sync.Once     // Closes Results exactly once, after all workers exit
 }
 
 // LogBuffer manages a circular buffer for UI logging
@@ -55,21 +256,94 @@ type LogBuffer struct {
 	maxLines int
 	current  int
 	full     bool
+	total    uint64 // Lines ever added, used as a high-water mark by readers
+	mutex    sync.RWMutex
+}
+
+// FolderPreview accumulates the destination folder tree for display while organizing.
+// Node IDs are slash-separated paths relative to the output folder; "" is the root.
+type FolderPreview struct {
+	children map[string][]string
+	counts   map[string]int      // Files placed at or below each node
+	files    map[string][]string // Source paths of the files placed directly in each node
+	dirty    bool
 	mutex    sync.RWMutex
 }
 
+// RunStats accumulates summary statistics for a single organize run
+type RunStats struct {
+	TotalFiles   int
+	FormatCounts map[string]int // Organized files per lowercase extension
+	WithGPS      int
+	WithoutGPS   int
+	Estimated    int // GPS-less files given an interpolated location
+	Clusters     int
+	LargestName  string
+	LargestCount int
+	Earliest     time.Time
+	Latest       time.Time
+	Lowest       float64 // Elevation range, when HasElevation is set
+	Highest      float64
+	HasElevation bool
+	Copied       int
+	Linked       int // Copied files placed as clones or hard links rather than copied
+	LongPaths    int // Copies whose path is over Windows' MAX_PATH
+	BytesCopied  int64
+	Errors       int64
+	CacheHits    int64             // Location lookups answered from the lookup cache
+	CacheMisses  int64             // Location lookups that had to be made
+	Problems     map[string]string // Reasons exiftool couldn't read files, by path
+	Failed       map[string]string // Reasons files couldn't be read or copied, by path
+	SuspectDates map[string]string // Why files' capture dates are implausible, by path
+	mutex        sync.Mutex
+}
+
+// ProblemFile is a file that exiftool couldn't read or that couldn't be organized at
+// all, with the reason
+type ProblemFile struct {
+	Path   string
+	Reason string
+}
+
 // SpatialGrid for efficient location clustering
 type SpatialGrid struct {
-	cells       map[string]*GridCell
-	sensitivity float64
-	mutex       sync.RWMutex
+	cells          map[string]*GridCell
+	sensitivity    float64
+	recordTimeline bool         // Keep capture times for time-based location borrowing
+	timeline       []timedImage // Captures in insertion order, when recordTimeline is set
+	elevationBand  float64      // Split cells into elevation bands this many meters tall (0 to ignore elevation)
+	nameDecimals   int          // Decimal places in cluster names (AutoNameDecimals to follow sensitivity)
+	places         []NamedPlace // Labeled places that name the clusters centered in them
+	splitCells     bool         // Keep points so cells holding separate places can be split
+	mutex          sync.RWMutex
+}
+
+// timedImage records when (and for geotagged files, where) an image was captured
+type timedImage struct {
+	Path     string
+	Date     time.Time
+	Key      string // Grid cell key, or noLocationKey for GPS-less images
+	Lat, Lng float64
+	Device   string // Camera make and model, so interpolation stays within one source
+}
+
+// locationEstimate describes a GPS-less image placed by interpolation
+type locationEstimate struct {
+	Path     string
+	Lat, Lng float64
 }
 
 type GridCell struct {
-	CenterLat float64
-	CenterLng float64
-	Images    []string
-	Count     int
+	XSum           float64 // Sums of the located images' unit vectors; the center is their mean direction
+	YSum           float64
+	ZSum           float64
+	Images         []string
+	Count          int
+	ElevationSum   float64 // Sum over the images that have an elevation
+	ElevationCount int
+	Band           int // Elevation band index, when HasBand is set
+	HasBand        bool
+	Points         []cellPoint // Each image's position, kept only when separated places are split
 }
 
 type ImageInfo struct {
@@ -79,37 +353,103 @@ type ImageInfo struct {
 	HasGPS       bool
 	Latitude     float64
 	Longitude    float64
+	Elevation    float64 // Meters above sea level, only meaningful when HasElevation is set
+	HasElevation bool
+	GPSError     float64 // Estimated horizontal error of the position in meters, when HasGPSError is set
+	HasGPSError  bool
+	CameraMake   string
+	CameraModel  string
+	ReadProblem  string // Why exiftool couldn't read the file; empty when it could
+	DateSource   string // Which DateSource* value Date came from; empty when there was none
+	Undated      bool   // Date is an implausible modification time; see UndatedFolder
+	CheckDate    bool   // Date is in the future or long past, and the file goes to CheckDateFolder
+	SourceDir    string // Folder relative to the source folder; empty at its top level
+	BurstReject  bool   // A burst frame passed over for a better one; see RejectsFolder
+	Size         int64  // File size in bytes, read when the copy is planned
+
+	// Duplicates lists sources with the same contents, copied only as this file
+	Duplicates []string
+
+	// dateKind records how Date should be interpreted when localizing it
+	dateKind captureTimeKind
+	// dateCandidates holds every date found, by DateSource* value, until one is chosen
+	dateCandidates map[string]dateCandidate
+}
+
+// offerDate records the date a source reports for info; chooseDate picks between them
+func (info *ImageInfo) offerDate(source string, date time.Time, kind captureTimeKind) {
+	if info.dateCandidates == nil {
+		info.dateCandidates = make(map[string]dateCandidate)
+	}
+	info.dateCandidates[source] = dateCandidate{date: date, kind: kind}
+}
+
+// chooseDate sets info's date from the first source in priority that offered one,
+// leaving it unchanged when none did
+func (info *ImageInfo) chooseDate(priority []string) {
+	for _, source := range priority {
+		if candidate, ok := info.dateCandidates[source]; ok {
+			info.Date, info.dateKind, info.DateSource = candidate.date, candidate.kind, source
+			return
+		}
+	}
 }
 
 type LocationCluster struct {
-	Name      string
-	CenterLat float64
-	CenterLng float64
-	Images    []string
+	Name        string
+	CenterLat   float64 // Only meaningful when HasLocation is set
+	CenterLng   float64
+	HasLocation bool // False for the No-Location catch-all
+	Place       bool // Named after a labeled place, whose position is the center
+	Images      []string
+
+	// Mean elevation of the files that recorded one
+	Elevation    float64
+	HasElevation bool
 }
 
 type App struct {
-	window              fyne.Window
-	sourceFolder        string
-	outputFolder        string
-	locationSensitivity float64
-	workerCount         int
-	batchSize           int
-	progressBar         *widget.ProgressBar
-	logText             *widget.Entry
-	sourceFolderLabel   *widget.Label
-	outputFolderLabel   *widget.Label
-	
+	*Organizer // Settings and run state; the App observes its progress
+
+	fyneApp           fyne.App
+	window            fyne.Window
+	notifyOnComplete  bool
+	progressMode      string // What the progress bar counts while copying: ProgressFiles or ProgressBytes
+	progressBar       *widget.ProgressBar
+	discoveryBar      *widget.ProgressBarInfinite
+	previewTree       *widget.Tree
+	thumbnailGrid     *fyne.Container
+	thumbnailLabel    *widget.Label
+	thumbnailCache    *ThumbnailCache
+	thumbnailSeq      atomic.Uint64 // Bumped per selection so stale loads stop
+	statsLabel        *widget.Label
+	statsCard         *widget.Card
+	failuresCard      *widget.Card
+	failuresList      *widget.List
+	failures          []ProblemFile // Files the last run couldn't read or copy
+	logText           *widget.Entry
+	logScroll         *container.Scroll
+	autoScrollLog     bool
+	logFilter         string // Only show log lines containing this text
+	logFilterChanged  bool
+	sourceFolderLabel *widget.Label
+	outputFolderLabel *widget.Label
+	outputDropZone    fyne.CanvasObject
+
 	// Enhanced components for better performance
-	logBuffer           *LogBuffer
-	spatialGrid         *SpatialGrid
-	globalWorkerPool    *WorkerPool
-	logUpdateTimer      *time.Ticker
-	
-	// Thread-safe counters
-	processedFiles      int64
-	totalFiles          int64
-	counterMutex        sync.RWMutex
+	logBuffer      *LogBuffer
+	logUpdateTimer *time.Ticker
+	logUpdateStop  chan struct{} // Closed to end the UI update goroutine
+	logSeq         uint64        // LogBuffer high-water mark already shown
+	logLineCount   int           // Lines currently in logText
+	dispatch       func(func())  // Runs widget updates on Fyne's main goroutine; fyne.Do outside tests
+
+	// Run controls, shared by the buttons, the menu and the keyboard shortcuts
+	running    atomic.Bool
+	startBtn   *widget.Button
+	cancelBtn  *widget.Button
+	runItem    *fyne.MenuItem
+	cancelItem *fyne.MenuItem
 }
 
 // NewLogBuffer creates a new circular log buffer
@@ -132,13 +472,58 @@ func (lb *LogBuffer) Add(line string) {
 	if lb.current == 0 {
 		lb.full = true
 	}
+	lb.total++
 }
 
 // GetLines returns all current log lines in order
 func (lb *LogBuffer) GetLines() []string {
 	lb.mutex.RLock()
 	defer lb.mutex.RUnlock()
-	
+	return lb.linesLocked()
+}
+
+// Clear removes all retained lines; the high-water mark keeps counting
+func (lb *LogBuffer) Clear() {
+	lb.mutex.Lock()
+	defer lb.mutex.Unlock()
+
+	lb.lines = make([]string, lb.maxLines)
+	lb.current = 0
+	lb.full = false
+}
+
+// Snapshot returns all current log lines along with the high-water mark they cover
+func (lb *LogBuffer) Snapshot() ([]string, uint64) {
+	lb.mutex.RLock()
+	defer lb.mutex.RUnlock()
+	return lb.linesLocked(), lb.total
+}
+
+// GetLinesSince returns the lines added after the high-water mark since, and the new mark.
+// If some of those lines were already overwritten, it returns every retained line with
+// complete set, and the caller should replace rather than append.
+func (lb *LogBuffer) GetLinesSince(since uint64) (lines []string, next uint64, complete bool) {
+	lb.mutex.RLock()
+	defer lb.mutex.RUnlock()
+
+	retained := uint64(lb.current)
+	if lb.full {
+		retained = uint64(lb.maxLines)
+	}
+	if since < lb.total-retained || since > lb.total {
+		return lb.linesLocked(), lb.total, true
+	}
+
+	count := int(lb.total - since)
+	lines = make([]string, 0, count)
+	for i := count; i > 0; i-- {
+		lines = append(lines, lb.lines[(lb.current-i+lb.maxLines)%lb.maxLines])
+	}
+	return lines, lb.total, false
+}
+
+// linesLocked returns the retained lines in order; the caller must hold the mutex
+func (lb *LogBuffer) linesLocked() []string {
 	if !lb.full {
 		return lb.lines[:lb.current]
 	}
@@ -149,913 +534,3167 @@ func (lb *LogBuffer) GetLines() []string {
 	return result
 }
 
-// NewSpatialGrid creates a new spatial grid for efficient clustering
-func NewSpatialGrid(sensitivity float64) *SpatialGrid {
-	return &SpatialGrid{
-		cells:       make(map[string]*GridCell),
-		sensitivity: sensitivity,
-	}
+// NewFolderPreview creates an empty destination preview
+func NewFolderPreview() *FolderPreview {
+	fp := &FolderPreview{}
+	fp.Reset()
+	return fp
 }
 
-// GetGridKey generates a grid key for given coordinates
-func (sg *SpatialGrid) GetGridKey(lat, lng float64) string {
-	// Create grid cells based on sensitivity
-	gridLat := math.Floor(lat/sg.sensitivity) * sg.sensitivity
-	gridLng := math.Floor(lng/sg.sensitivity) * sg.sensitivity
-	return fmt.Sprintf("%.6f,%.6f", gridLat, gridLng)
-}
+// Reset clears the preview for a new run
+func (fp *FolderPreview) Reset() {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
 
-// AddImage adds an image to the spatial grid
-func (sg *SpatialGrid) AddImage(info *ImageInfo) {
-	if !info.HasGPS {
-		sg.addToNoLocationCluster(info.OriginalPath)
-		return
-	}
-	
-	sg.mutex.Lock()
-	defer sg.mutex.Unlock()
-	
-	key := sg.GetGridKey(info.Latitude, info.Longitude)
-	
-	if cell, exists := sg.cells[key]; exists {
-		cell.Images = append(cell.Images, info.OriginalPath)
-		cell.Count++
-		// Update weighted center
-		cell.CenterLat = (cell.CenterLat*float64(cell.Count-1) + info.Latitude) / float64(cell.Count)
-		cell.CenterLng = (cell.CenterLng*float64(cell.Count-1) + info.Longitude) / float64(cell.Count)
-	} else {
-		sg.cells[key] = &GridCell{
-			CenterLat: info.Latitude,
-			CenterLng: info.Longitude,
-			Images:    []string{info.OriginalPath},
-			Count:     1,
-		}
-	}
+	fp.children = make(map[string][]string)
+	fp.counts = make(map[string]int)
+	fp.files = make(map[string][]string)
+	fp.dirty = true
 }
 
-// addToNoLocationCluster handles images without GPS data
-func (sg *SpatialGrid) addToNoLocationCluster(imagePath string) {
-	sg.mutex.Lock()
-	defer sg.mutex.Unlock()
-	
-	const noLocationKey = "no-location"
-	if cell, exists := sg.cells[noLocationKey]; exists {
-		cell.Images = append(cell.Images, imagePath)
-		cell.Count++
-	} else {
-		sg.cells[noLocationKey] = &GridCell{
-			CenterLat: 0,
-			CenterLng: 0,
-			Images:    []string{imagePath},
-			Count:     1,
+// AddFile records the file at sourcePath being placed in relDir, creating any missing
+// folder nodes
+func (fp *FolderPreview) AddFile(relDir, sourcePath string) {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+
+	parent := ""
+	for _, segment := range strings.Split(filepath.ToSlash(relDir), "/") {
+		if segment == "" || segment == "." {
+			continue
+		}
+		id := segment
+		if parent != "" {
+			id = parent + "/" + segment
+		}
+		if _, exists := fp.counts[id]; !exists {
+			fp.children[parent] = append(fp.children[parent], id)
+			sort.Strings(fp.children[parent])
 		}
+		fp.counts[id]++
+		parent = id
 	}
+	fp.files[parent] = append(fp.files[parent], sourcePath)
+	fp.dirty = true
 }
 
-// GetClusters returns location clusters from the spatial grid
-func (sg *SpatialGrid) GetClusters(app *App) []LocationCluster {
-	sg.mutex.RLock()
-	defer sg.mutex.RUnlock()
-	
-	clusters := make([]LocationCluster, 0, len(sg.cells))
-	
-	for key, cell := range sg.cells {
-		var name string
-		if key == "no-location" {
-			name = "No-Location"
-		} else {
-			name = app.formatLocation(cell.CenterLat, cell.CenterLng)
-		}
-		
-		clusters = append(clusters, LocationCluster{
-			Name:      name,
-			CenterLat: cell.CenterLat,
-			CenterLng: cell.CenterLng,
-			Images:    cell.Images,
-		})
+// Files returns the source paths of the files placed at or below id
+func (fp *FolderPreview) Files(id string) []string {
+	fp.mutex.RLock()
+	defer fp.mutex.RUnlock()
+
+	var files []string
+	pending := []string{id}
+	for len(pending) > 0 {
+		node := pending[0]
+		pending = pending[1:]
+		files = append(files, fp.files[node]...)
+		pending = append(pending, fp.children[node]...)
 	}
-	
-	return clusters
+	return files
 }
 
-// Clear cleans up the spatial grid
-func (sg *SpatialGrid) Clear() {
-	sg.mutex.Lock()
-	defer sg.mutex.Unlock()
-	sg.cells = make(map[string]*GridCell)
+// Children returns the child node IDs of id
+func (fp *FolderPreview) Children(id string) []string {
+	fp.mutex.RLock()
+	defer fp.mutex.RUnlock()
+	return append([]string(nil), fp.children[id]...)
 }
 
-// NewWorkerPool creates a new worker pool
-func NewWorkerPool(workerCount int, bufferSize int) *WorkerPool {
-	return &WorkerPool{
-		WorkerCount: workerCount,
-		Jobs:        make(chan string, bufferSize),
-		Results:     make(chan ProcessingResult, bufferSize),
-	}
+// IsBranch reports whether id has child folders
+func (fp *FolderPreview) IsBranch(id string) bool {
+	fp.mutex.RLock()
+	defer fp.mutex.RUnlock()
+	return id == "" || len(fp.children[id]) > 0
 }
 
-// Start initializes the worker pool
-func (wp *WorkerPool) Start(app *App) {
-	for i := 0; i < wp.WorkerCount; i++ {
-		wp.wg.Add(1)
-		go app.worker(wp)
-	}
+// Label returns the display text for id
+func (fp *FolderPreview) Label(id string) string {
+	fp.mutex.RLock()
+	defer fp.mutex.RUnlock()
+	return fmt.Sprintf("%s (%d)", path.Base(id), fp.counts[id])
 }
 
-// Submit adds a job to the pool
-func (wp *WorkerPool) Submit(filePath string) {
-	if !wp.closed {
-		wp.Jobs <- filePath
-	}
+// TakeDirty reports whether the preview changed since the last call
+func (fp *FolderPreview) TakeDirty() bool {
+	fp.mutex.Lock()
+	defer fp.mutex.Unlock()
+	dirty := fp.dirty
+	fp.dirty = false
+	return dirty
 }
 
-// Close shuts down the worker pool
-func (wp *WorkerPool) Close() {
-	if !wp.closed {
-		wp.closed = true
-		close(wp.Jobs)
-	}
+// NewRunStats creates empty run statistics
+func NewRunStats() *RunStats {
+	return &RunStats{FormatCounts: make(map[string]int), Problems: make(map[string]string), Failed: make(map[string]string),
+		SuspectDates: make(map[string]string)}
 }
 
-// Wait waits for all workers to finish
-func (wp *WorkerPool) Wait() {
-	wp.wg.Wait()
-	if !wp.closed {
-		close(wp.Results)
-	}
+// RecordProblem notes that exiftool couldn't read path
+func (rs *RunStats) RecordProblem(path, reason string) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.Problems[path] = reason
 }
 
-func main() {
-	myApp := app.New()
-	myApp.SetIcon(nil) // You can set an icon here if you have one
-
-	myWindow := myApp.NewWindow("Media Organizer")
-	myWindow.Resize(fyne.NewSize(800, 600))
+// Problem returns why exiftool couldn't read path, if it couldn't
+func (rs *RunStats) Problem(path string) (string, bool) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	reason, ok := rs.Problems[path]
+	return reason, ok
+}
 
-	app := &App{
-		window:              myWindow,
-		locationSensitivity: 0.001,            // Default ~100m sensitivity
-		workerCount:         runtime.NumCPU(), // Use number of CPU cores
-		batchSize:           DefaultBatchSize, // Default batch size for memory management
-		logBuffer:           NewLogBuffer(MaxLogLines),
+// MoveProblem files the problem recorded for the file at from, if any, under to instead
+func (rs *RunStats) MoveProblem(from, to string) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	if reason, ok := rs.Problems[from]; ok {
+		delete(rs.Problems, from)
+		rs.Problems[to] = reason
 	}
+}
 
-	// Set up exiftool path
-	setupExifTool()
-
-	app.setupUI()
+// ProblemFiles returns the files exiftool couldn't read, sorted by path
+func (rs *RunStats) ProblemFiles() []ProblemFile {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	return sortedProblemFiles(rs.Problems)
+}
 
-	// Check for exiftool availability and log status
-	app.checkExifToolAvailability()
+// RecordFailure notes that path couldn't be read or copied, so it wasn't organized
+func (rs *RunStats) RecordFailure(path, reason string) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.Failed[path] = reason
+}
 
-	myWindow.ShowAndRun()
+// FailedFiles returns the files that couldn't be read or copied, sorted by path
+func (rs *RunStats) FailedFiles() []ProblemFile {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	return sortedProblemFiles(rs.Failed)
 }
 
-func (app *App) setupUI() {
-	// Title
-	title := widget.NewLabel("Media Organizer by Location and Date")
-	title.TextStyle.Bold = true
+// RecordSuspectDate notes that path's capture date is implausible
+func (rs *RunStats) RecordSuspectDate(path, reason string) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.SuspectDates[path] = reason
+}
 
-	// Source folder selection
-	app.sourceFolderLabel = widget.NewLabel("No source folder selected")
-	selectSourceBtn := widget.NewButton("Select Source Folder", app.selectSourceFolder)
+// SuspectDateFiles returns the files with implausible capture dates, sorted by path
+func (rs *RunStats) SuspectDateFiles() []ProblemFile {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	return sortedProblemFiles(rs.SuspectDates)
+}
 
-	// Output folder selection
-	app.outputFolderLabel = widget.NewLabel("No output folder selected")
-	selectOutputBtn := widget.NewButton("Select Output Folder", app.selectOutputFolder)
+// sortedProblemFiles lists reasons by path, sorted by path
+func sortedProblemFiles(reasons map[string]string) []ProblemFile {
+	files := make([]ProblemFile, 0, len(reasons))
+	for path, reason := range reasons {
+		files = append(files, ProblemFile{Path: path, Reason: reason})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files
+}
 
-	// Location sensitivity slider
-	sensitivityLabel := widget.NewLabel("Location Grouping Sensitivity:")
-	sensitivityInfo := widget.NewLabel("Lower = Group closer locations together")
-	sensitivitySlider := widget.NewSlider(0.0001, 0.01)
-	sensitivitySlider.Value = app.locationSensitivity
-	sensitivitySlider.Step = 0.0001
+// RecordImage counts a file that will be organized
+func (rs *RunStats) RecordImage(info *ImageInfo) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
 
-	sensitivityValueLabel := widget.NewLabel(fmt.Sprintf("%.4f (~%.0fm)", app.locationSensitivity, app.locationSensitivity*111000))
+	rs.FormatCounts[strings.ToLower(filepath.Ext(info.OriginalPath))]++
+	if info.HasGPS {
+		rs.WithGPS++
+	} else {
+		rs.WithoutGPS++
+	}
 
-	sensitivitySlider.OnChanged = func(value float64) {
-		app.locationSensitivity = value
-		distance := value * 111000 // Rough conversion to meters
-		sensitivityValueLabel.SetText(fmt.Sprintf("%.4f (~%.0fm)", value, distance))
+	if info.HasElevation {
+		if !rs.HasElevation || info.Elevation < rs.Lowest {
+			rs.Lowest = info.Elevation
+		}
+		if !rs.HasElevation || info.Elevation > rs.Highest {
+			rs.Highest = info.Elevation
+		}
+		rs.HasElevation = true
 	}
 
-	// Worker count slider
-	workerLabel := widget.NewLabel("Processing Threads:")
-	workerInfo := widget.NewLabel("More threads = faster processing (uses more CPU)")
-	workerSlider := widget.NewSlider(1, float64(runtime.NumCPU()*2))
-	workerSlider.Value = float64(app.workerCount)
-	workerSlider.Step = 1
+	if !info.Date.IsZero() {
+		if rs.Earliest.IsZero() || info.Date.Before(rs.Earliest) {
+			rs.Earliest = info.Date
+		}
+		if info.Date.After(rs.Latest) {
+			rs.Latest = info.Date
+		}
+	}
+}
 
-	workerValueLabel := widget.NewLabel(fmt.Sprintf("%d threads (CPU cores: %d)", app.workerCount, runtime.NumCPU()))
+// RecordClusters notes the cluster count and the largest cluster
+func (rs *RunStats) RecordClusters(clusters []LocationCluster) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
 
-	workerSlider.OnChanged = func(value float64) {
-		app.workerCount = int(value)
-		workerValueLabel.SetText(fmt.Sprintf("%d threads (CPU cores: %d)", app.workerCount, runtime.NumCPU()))
+	rs.Clusters = len(clusters)
+	for _, cluster := range clusters {
+		if len(cluster.Images) > rs.LargestCount {
+			rs.LargestName = cluster.Name
+			rs.LargestCount = len(cluster.Images)
+		}
 	}
+}
 
-	// Batch size slider
-	batchLabel := widget.NewLabel("Batch Size:")
-	batchInfo := widget.NewLabel("Smaller batches = less memory usage (but slower processing)")
-	batchSlider := widget.NewSlider(10, 500)  // Reduced max for large datasets
-	batchSlider.Value = float64(app.batchSize)
-	batchSlider.Step = 10
-
-	batchValueLabel := widget.NewLabel(fmt.Sprintf("%d files per batch", app.batchSize))
+// AddBytesCopied adds n to the total bytes copied
+func (rs *RunStats) AddBytesCopied(n int64) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.BytesCopied += n
+}
 
-	batchSlider.OnChanged = func(value float64) {
-		app.batchSize = int(value)
-		batchValueLabel.SetText(fmt.Sprintf("%d files per batch", app.batchSize))
-	}
+// AddLinked counts a file cloned or hard-linked instead of copied
+func (rs *RunStats) AddLinked() {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.Linked++
+}
 
-	// Progress bar
-	app.progressBar = widget.NewProgressBar()
-	app.progressBar.Hide()
+// AddLongPath counts a copy written through the extended-length path form
+func (rs *RunStats) AddLongPath() {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.LongPaths++
+}
 
-	// Log output
-	app.logText = widget.NewMultiLineEntry()
-	app.logText.SetText("Ready to organize media files...\n")
-	app.logText.Disable()
-	
-	// Set minimum size for better readability
-	app.logText.Resize(fyne.NewSize(600, 200)) // Minimum width and height
+// SetCopied records how many files were copied
+func (rs *RunStats) SetCopied(n int) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.Copied = n
+}
 
-	// Start button
-	startBtn := widget.NewButton("Start Organizing", app.startOrganizing)
-	startBtn.Importance = widget.HighImportance
+// SetEstimated records how many locations were interpolated
+func (rs *RunStats) SetEstimated(n int) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.Estimated = n
+}
 
-	// Layout
-	folderSection := container.NewVBox(
-		widget.NewLabel("Source Folder:"),
-		container.NewHBox(selectSourceBtn, app.sourceFolderLabel),
-		widget.NewLabel("Output Folder:"),
-		container.NewHBox(selectOutputBtn, app.outputFolderLabel),
-	)
+// SetErrors records the final error count
+func (rs *RunStats) SetErrors(n int64) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.Errors = n
+}
 
-	sensitivitySection := container.NewVBox(
-		sensitivityLabel,
-		sensitivityInfo,
-		sensitivitySlider,
-		sensitivityValueLabel,
-	)
+// SetLookups records how the location lookup cache fared
+func (rs *RunStats) SetLookups(hits, misses int64) {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+	rs.CacheHits, rs.CacheMisses = hits, misses
+}
 
-	workerSection := container.NewVBox(
-		workerLabel,
-		workerInfo,
-		workerSlider,
-		workerValueLabel,
-	)
+// Summary renders the statistics as multi-line text
+func (rs *RunStats) Summary() string {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
 
-	batchSection := container.NewVBox(
-		batchLabel,
-		batchInfo,
-		batchSlider,
-		batchValueLabel,
-	)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Total files found: %d\n", rs.TotalFiles)
 
-	controlSection := container.NewVBox(
-		folderSection,
-		widget.NewSeparator(),
-		sensitivitySection,
-		widget.NewSeparator(),
-		workerSection,
-		widget.NewSeparator(),
-		batchSection,
-		widget.NewSeparator(),
-		startBtn,
-		app.progressBar,
-	)
+	formats := make([]string, 0, len(rs.FormatCounts))
+	for ext := range rs.FormatCounts {
+		formats = append(formats, ext)
+	}
+	sort.Strings(formats)
+	for i, ext := range formats {
+		formats[i] = fmt.Sprintf("%s: %d", ext, rs.FormatCounts[ext])
+	}
+	if len(formats) > 0 {
+		fmt.Fprintf(&sb, "Formats: %s\n", strings.Join(formats, ", "))
+	}
 
-	// Create a better log section with more prominent styling
-	logLabel := widget.NewLabel("🔍 Processing Log:")
-	logLabel.TextStyle.Bold = true
-	
-	logScroll := container.NewScroll(app.logText)
-	logScroll.SetMinSize(fyne.NewSize(400, 150)) // Ensure minimum scroll area size
-	
-	logSection := container.NewVBox(
-		logLabel,
-		logScroll,
-	)
+	fmt.Fprintf(&sb, "With GPS: %d, without GPS: %d", rs.WithGPS, rs.WithoutGPS)
+	if rs.Estimated > 0 {
+		fmt.Fprintf(&sb, " (%d with estimated locations)", rs.Estimated)
+	}
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "Clusters: %d", rs.Clusters)
+	if rs.LargestCount > 0 {
+		name := rs.LargestName
+		if name == "" {
+			name = "all files"
+		}
+		fmt.Fprintf(&sb, " (largest: %s with %d files)", name, rs.LargestCount)
+	}
+	sb.WriteString("\n")
 
-	content := container.NewVSplit(
-		container.NewVBox(title, controlSection),
-		logSection,
+	if !rs.Earliest.IsZero() {
+		fmt.Fprintf(&sb, "Date range: %s to %s\n", rs.Earliest.Format("2006-01-02"), rs.Latest.Format("2006-01-02"))
+	}
+	if rs.HasElevation {
+		fmt.Fprintf(&sb, "Elevation range: %.0f m to %.0f m\n", rs.Lowest, rs.Highest)
+	}
+	fmt.Fprintf(&sb, "Copied: %d files (%s)", rs.Copied, formatBytes(rs.BytesCopied))
+	if rs.Linked > 0 {
+		fmt.Fprintf(&sb, ", %d of them cloned or hard-linked", rs.Linked)
+	}
+	sb.WriteString("\n")
+	if rs.LongPaths > 0 {
+		fmt.Fprintf(&sb, "Paths over %d characters: %d (some programs can't open these; a shorter output folder avoids them)\n", windowsMaxPath, rs.LongPaths)
+	}
+	if len(rs.Problems) > 0 {
+		fmt.Fprintf(&sb, "Unreadable metadata: %d files (listed in the log)\n", len(rs.Problems))
+	}
+	if len(rs.SuspectDates) > 0 {
+		fmt.Fprintf(&sb, "Implausible dates: %d files (listed in the log)\n", len(rs.SuspectDates))
+	}
+	if lookups := rs.CacheHits + rs.CacheMisses; lookups > 0 {
+		fmt.Fprintf(&sb, "Location lookups: %d (%d cached, %d looked up)\n", lookups, rs.CacheHits, rs.CacheMisses)
+	}
+	fmt.Fprintf(&sb, "Errors: %d", rs.Errors)
+
+	return sb.String()
+}
+
+// formatBytes renders a byte count with a binary unit suffix
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// NewSpatialGrid creates a new spatial grid for efficient clustering
+func NewSpatialGrid(sensitivity float64) *SpatialGrid {
+	return &SpatialGrid{
+		cells:       make(map[string]*GridCell),
+		sensitivity: sensitivity,
+	}
+}
+
+// GetGridKey generates a grid key for given coordinates
+func (sg *SpatialGrid) GetGridKey(lat, lng float64) string {
+	// Create grid cells based on sensitivity
+	return gridCellKey(lat, lng, sg.sensitivity)
+}
+
+// AddImage adds an image to the spatial grid
+func (sg *SpatialGrid) AddImage(info *ImageInfo) {
+	// GPS-less images go to the No-Location cell and never enter the center math
+	if !info.HasGPS {
+		sg.addToNoLocationCluster(info.OriginalPath)
+		sg.recordCapture(info, noLocationKey)
+		return
+	}
+	
+	key := sg.GetGridKey(info.Latitude, info.Longitude)
+	band, banded := 0, sg.elevationBand > 0 && info.HasElevation
+	if banded {
+		// Elevation-aware clustering keeps, say, a summit apart from the valley below it
+		band = int(math.Floor(info.Elevation / sg.elevationBand))
+		key = fmt.Sprintf("%s,e%d", key, band)
+	}
+
+	sg.mutex.Lock()
+	sg.addLocatedLocked(key, cellPoint{
+		Path:         info.OriginalPath,
+		Lat:          info.Latitude,
+		Lng:          info.Longitude,
+		Elevation:    info.Elevation,
+		HasElevation: info.HasElevation,
+	})
+	cell := sg.cells[key]
+	cell.Band, cell.HasBand = band, banded
+	sg.mutex.Unlock()
+
+	sg.recordCapture(info, key)
+}
+
+// addLocatedLocked adds a geotagged image to the grid cell key; the caller must hold the mutex
+func (sg *SpatialGrid) addLocatedLocked(key string, point cellPoint) {
+	cell, exists := sg.cells[key]
+	if !exists {
+		cell = &GridCell{}
+		sg.cells[key] = cell
+	}
+	cell.addPoint(point, sg.splitCells)
+}
+
+// addPoint adds the image at point to the cell, keeping the point itself when keep is
+// set. Borrowed points don't count towards the center or elevation.
+func (cell *GridCell) addPoint(point cellPoint, keep bool) {
+	cell.Images = append(cell.Images, point.Path)
+	cell.Count++
+	if keep {
+		cell.Points = append(cell.Points, point)
+	}
+	if point.Borrowed {
+		return
+	}
+
+	// Summing and converting back once in Center doesn't drift with cluster size or
+	// insertion order, as updating a running mean does, and averaging directions rather
+	// than raw degrees keeps clusters on the antimeridian or near a pole in place
+	x, y, z := unitVector(point.Lat, point.Lng)
+	cell.XSum += x
+	cell.YSum += y
+	cell.ZSum += z
+	if point.HasElevation {
+		cell.ElevationSum += point.Elevation
+		cell.ElevationCount++
+	}
+}
+
+// Center returns the mean position of the cell's located images
+func (cell *GridCell) Center() (lat, lng float64) {
+	return fromUnitVector(cell.XSum, cell.YSum, cell.ZSum)
+}
+
+// addToNoLocationCluster handles images without GPS data
+func (sg *SpatialGrid) addToNoLocationCluster(imagePath string) {
+	sg.mutex.Lock()
+	defer sg.mutex.Unlock()
+	
+	// The center is deliberately left unset: this cell has no position
+	if cell, exists := sg.cells[noLocationKey]; exists {
+		cell.Images = append(cell.Images, imagePath)
+		cell.Count++
+	} else {
+		sg.cells[noLocationKey] = &GridCell{
+			Images: []string{imagePath},
+			Count:  1,
+		}
+	}
+}
+
+// recordCapture adds a capture to the timeline when it is being kept
+func (sg *SpatialGrid) recordCapture(info *ImageInfo, key string) {
+	sg.mutex.Lock()
+	defer sg.mutex.Unlock()
+	if sg.recordTimeline {
+		sg.timeline = append(sg.timeline, timedImage{
+			Path:   info.OriginalPath,
+			Date:   info.Date,
+			Key:    key,
+			Lat:    info.Latitude,
+			Lng:    info.Longitude,
+			Device: info.CameraMake + " " + info.CameraModel,
+		})
+	}
+}
+
+// BorrowNearestLocations moves each No-Location image into the cell of the geotagged
+// image captured nearest to it in time, if that is within window. Borrowed images
+// don't shift the cell's center. It returns how many images were moved.
+func (sg *SpatialGrid) BorrowNearestLocations(window time.Duration) int {
+	sg.mutex.Lock()
+	defer sg.mutex.Unlock()
+
+	noLocation, exists := sg.cells[noLocationKey]
+	if !exists {
+		return 0
+	}
+
+	var located []timedImage
+	for _, capture := range sg.timeline {
+		if capture.Key != noLocationKey {
+			located = append(located, capture)
+		}
+	}
+	if len(located) == 0 {
+		return 0
+	}
+	sortTimeline(located)
+
+	moved := make(map[string]bool)
+	for _, capture := range sg.timeline {
+		if capture.Key != noLocationKey {
+			continue
+		}
+		nearest, ok := nearestInTime(located, capture.Date, window)
+		if !ok {
+			continue
+		}
+		sg.cells[nearest.Key].addPoint(cellPoint{Path: capture.Path, Lat: nearest.Lat, Lng: nearest.Lng, Borrowed: true}, sg.splitCells)
+		moved[capture.Path] = true
+	}
+
+	sg.removeFromNoLocationLocked(noLocation, moved)
+	return len(moved)
+}
+
+// InterpolateLocations places each No-Location image between the geotagged images from
+// the same camera taken just before and just after it, linearly interpolating their
+// coordinates by time, when those two are at most maxGap apart. Interpolated images
+// cluster like geotagged ones. It returns the estimates made.
+func (sg *SpatialGrid) InterpolateLocations(maxGap time.Duration) []locationEstimate {
+	sg.mutex.Lock()
+	defer sg.mutex.Unlock()
+
+	noLocation, exists := sg.cells[noLocationKey]
+	if !exists {
+		return nil
+	}
+
+	// Geotagged captures per camera, in time order
+	byDevice := make(map[string][]timedImage)
+	for _, capture := range sg.timeline {
+		if capture.Key != noLocationKey {
+			byDevice[capture.Device] = append(byDevice[capture.Device], capture)
+		}
+	}
+	for _, located := range byDevice {
+		sortTimeline(located)
+	}
+
+	var estimates []locationEstimate
+	moved := make(map[string]bool)
+	for _, capture := range sg.timeline {
+		if capture.Key != noLocationKey {
+			continue
+		}
+
+		before, after, ok := bracketInTime(byDevice[capture.Device], capture.Date)
+		if !ok || after.Date.Sub(before.Date) > maxGap {
+			continue
+		}
+
+		fraction := 0.0
+		if span := after.Date.Sub(before.Date); span > 0 {
+			fraction = float64(capture.Date.Sub(before.Date)) / float64(span)
+		}
+		lat := before.Lat + (after.Lat-before.Lat)*fraction
+		lng := wrapLongitude(before.Lng + longitudeDelta(before.Lng, after.Lng)*fraction)
+
+		sg.addLocatedLocked(sg.GetGridKey(lat, lng), cellPoint{Path: capture.Path, Lat: lat, Lng: lng})
+		moved[capture.Path] = true
+		estimates = append(estimates, locationEstimate{Path: capture.Path, Lat: lat, Lng: lng})
+	}
+
+	sg.removeFromNoLocationLocked(noLocation, moved)
+	return estimates
+}
+
+// bracketInTime returns the captures in sorted taken at or just before and at or just
+// after date; ok is false when date falls outside the sorted range
+func bracketInTime(sorted []timedImage, date time.Time) (before, after timedImage, ok bool) {
+	i := sort.Search(len(sorted), func(i int) bool {
+		return !sorted[i].Date.Before(date)
+	})
+	if i == len(sorted) {
+		return timedImage{}, timedImage{}, false
+	}
+	if sorted[i].Date.Equal(date) {
+		return sorted[i], sorted[i], true
+	}
+	if i == 0 {
+		return timedImage{}, timedImage{}, false
+	}
+	return sorted[i-1], sorted[i], true
+}
+
+// removeFromNoLocationLocked drops moved images from the No-Location cell, deleting it
+// once empty; the caller must hold the mutex
+func (sg *SpatialGrid) removeFromNoLocationLocked(noLocation *GridCell, moved map[string]bool) {
+	remaining := noLocation.Images[:0]
+	for _, path := range noLocation.Images {
+		if !moved[path] {
+			remaining = append(remaining, path)
+		}
+	}
+	noLocation.Images = remaining
+	noLocation.Count = len(remaining)
+	if len(remaining) == 0 {
+		delete(sg.cells, noLocationKey)
+	}
+}
+
+// sortTimeline sorts captures by date, breaking ties by path. Workers record captures in
+// whatever order they finish, so ties must not be left to that order.
+func sortTimeline(captures []timedImage) {
+	sort.Slice(captures, func(i, j int) bool {
+		if !captures[i].Date.Equal(captures[j].Date) {
+			return captures[i].Date.Before(captures[j].Date)
+		}
+		return captures[i].Path < captures[j].Path
+	})
+}
+
+// nearestInTime returns the capture in sorted closest to date, if it is within window
+func nearestInTime(sorted []timedImage, date time.Time, window time.Duration) (timedImage, bool) {
+	i := sort.Search(len(sorted), func(i int) bool {
+		return !sorted[i].Date.Before(date)
+	})
+
+	best, bestGap := -1, time.Duration(0)
+	for _, candidate := range []int{i - 1, i} {
+		if candidate < 0 || candidate >= len(sorted) {
+			continue
+		}
+		gap := sorted[candidate].Date.Sub(date)
+		if gap < 0 {
+			gap = -gap
+		}
+		if best == -1 || gap < bestGap {
+			best, bestGap = candidate, gap
+		}
+	}
+
+	if best == -1 || bestGap > window {
+		return timedImage{}, false
+	}
+	return sorted[best], true
+}
+
+// GetClusters returns location clusters from the spatial grid
+func (sg *SpatialGrid) GetClusters() []LocationCluster {
+	sg.mutex.RLock()
+	defer sg.mutex.RUnlock()
+	
+	clusters := make([]LocationCluster, 0, len(sg.cells))
+	names, placed := sg.clusterNames()
+
+	// Every cell in a labeled place goes into the one cluster named after it
+	type placeTotals struct {
+		cluster        *LocationCluster
+		elevationSum   float64
+		elevationCount int
+	}
+	byPlace := make(map[string]*placeTotals)
+
+	for key, cell := range sg.cells {
+		if key == noLocationKey {
+			clusters = append(clusters, LocationCluster{
+				Name:   NoLocationClusterName,
+				Images: sortedImages(cell.Images),
+			})
+			continue
+		}
+
+		if place, ok := placed[key]; ok {
+			totals := byPlace[names[key]]
+			if totals == nil {
+				totals = &placeTotals{cluster: &LocationCluster{
+					Name:        names[key],
+					CenterLat:   place.Lat,
+					CenterLng:   place.Lng,
+					HasLocation: true,
+					Place:       true,
+				}}
+				byPlace[names[key]] = totals
+			}
+			totals.cluster.Images = append(totals.cluster.Images, cell.Images...)
+			totals.elevationSum += cell.ElevationSum
+			totals.elevationCount += cell.ElevationCount
+			continue
+		}
+
+		centerLat, centerLng := cell.Center()
+		cluster := LocationCluster{
+			Name:        names[key],
+			CenterLat:   centerLat,
+			CenterLng:   centerLng,
+			HasLocation: true,
+			Images:      sortedImages(cell.Images),
+		}
+		if cell.ElevationCount > 0 {
+			cluster.Elevation = cell.ElevationSum / float64(cell.ElevationCount)
+			cluster.HasElevation = true
+		}
+		clusters = append(clusters, cluster)
+	}
+	for _, totals := range byPlace {
+		cluster := *totals.cluster
+		cluster.Images = sortedImages(cluster.Images)
+		if totals.elevationCount > 0 {
+			cluster.Elevation = totals.elevationSum / float64(totals.elevationCount)
+			cluster.HasElevation = true
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	// Map iteration order is random; sort so repeated runs produce identical output
+	sortClusters(clusters)
+	return clusters
+}
+
+// sortClusters orders clusters by name, then by center
+func sortClusters(clusters []LocationCluster) {
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Name != clusters[j].Name {
+			return clusters[i].Name < clusters[j].Name
+		}
+		if clusters[i].CenterLat != clusters[j].CenterLat {
+			return clusters[i].CenterLat < clusters[j].CenterLat
+		}
+		return clusters[i].CenterLng < clusters[j].CenterLng
+	})
+}
+
+// sortedImages returns a sorted copy of paths, since workers finish in arbitrary order
+func sortedImages(paths []string) []string {
+	images := append([]string(nil), paths...)
+	sort.Strings(images)
+	return images
+}
+
+// Clear cleans up the spatial grid
+func (sg *SpatialGrid) Clear() {
+	sg.mutex.Lock()
+	defer sg.mutex.Unlock()
+	sg.cells = make(map[string]*GridCell)
+}
+
+// NewWorkerPool creates a new worker pool that stops when ctx is cancelled. In-process
+// decoding is CPU-bound and exiftool mostly waits on its process and the disk, so each
+// kind of file has its own queue and number of workers.
+func NewWorkerPool(ctx context.Context, decodeWorkers, exiftoolWorkers int, bufferSize int) *WorkerPool {
+	return &WorkerPool{
+		DecodeWorkers:   decodeWorkers,
+		ExifToolWorkers: exiftoolWorkers,
+		ctx:             ctx,
+		Jobs:            make(chan string, bufferSize),
+		ExifToolJobs:    make(chan string, bufferSize),
+		Results:         make(chan ProcessingResult, bufferSize),
+		closing:         make(chan struct{}),
+	}
+}
+
+// Start initializes the worker pool
+func (wp *WorkerPool) Start(org *Organizer) {
+	for i := 0; i < wp.DecodeWorkers; i++ {
+		wp.wg.Add(1)
+		go org.worker(wp, wp.Jobs)
+	}
+	for i := 0; i < wp.ExifToolWorkers; i++ {
+		wp.wg.Add(1)
+		go org.worker(wp, wp.ExifToolJobs)
+	}
+}
+
+// Submit adds a job to the pool and reports whether it was queued; external jobs go to
+// the exiftool workers. Jobs submitted after Close are dropped, and a Submit blocked on
+// a full queue returns once the pool is closed or cancelled.
+func (wp *WorkerPool) Submit(filePath string, external bool) bool {
+	wp.mutex.Lock()
+	defer wp.mutex.Unlock()
+
+	if wp.closed {
+		return false
+	}
+
+	jobs := wp.Jobs
+	if external {
+		jobs = wp.ExifToolJobs
+	}
+	select {
+	case jobs <- filePath:
+		return true
+	case <-wp.ctx.Done():
+		return false
+	case <-wp.closing:
+		return false
+	}
+}
+
+// Close stops accepting jobs; workers exit once the queued jobs are drained. A Submit
+// waiting for room holds the mutex, so it is told to give up before Close takes it.
+func (wp *WorkerPool) Close() {
+	wp.closingOnce.Do(func() {
+		close(wp.closing)
+	})
+
+	wp.mutex.Lock()
+	defer wp.mutex.Unlock()
+
+	wp.jobsOnce.Do(func() {
+		wp.closed = true
+		close(wp.Jobs)
+		close(wp.ExifToolJobs)
+	})
+}
+
+// Wait waits for all workers to finish and then closes Results
+func (wp *WorkerPool) Wait() {
+	wp.wg.Wait()
+	wp.resultsOnce.Do(func() {
+		close(wp.Results)
+	})
+}
+
+func main() {
+	options, headless := parseCommandLine()
+	if headless {
+		os.Exit(runHeadless(options))
+	}
+
+	myApp := app.NewWithID("com.digitallysavvy.mediaorganizer")
+	myApp.SetIcon(nil) // You can set an icon here if you have one
+
+	myWindow := myApp.NewWindow("Media Organizer")
+	myWindow.Resize(fyne.NewSize(800, 600))
+
+	app := &App{
+		fyneApp:          myApp,
+		window:           myWindow,
+		logBuffer:        NewLogBuffer(MaxLogLines),
+		thumbnailCache:   NewThumbnailCache(),
+		notifyOnComplete: true, // Notify when long runs finish
+		progressMode:     ProgressFiles,
+		autoScrollLog:    true, // Follow new log output
+		dispatch:         fyne.Do,
+	}
+	app.Organizer = NewOrganizer(app)
+	app.cpuProfilePath = options.cpuProfile
+	app.memProfilePath = options.memProfile
+	app.namedPlaces = app.loadNamedPlaces()
+	app.loadSettings()
+
+	// Set up exiftool path, honoring a user-configured location
+	setupExifTool(myApp.Preferences().String(prefExifToolPath))
+
+	app.setupUI()
+
+	// Check for exiftool availability and log status
+	app.checkExifToolAvailability()
+
+	myWindow.ShowAndRun()
+}
+
+func (app *App) setupUI() {
+	// Title
+	title := widget.NewLabel("Media Organizer by Location and Date")
+	title.TextStyle.Bold = true
+
+	// Source folder selection
+	app.sourceFolderLabel = widget.NewLabel("No source folder selected")
+	selectSourceBtn := widget.NewButton("Select Source Folder", app.selectSourceFolder)
+	selectArchiveBtn := widget.NewButtonWithIcon("Archive", theme.FileIcon(), app.selectSourceArchive)
+	var recentSourceBtn *widget.Button
+	recentSourceBtn = widget.NewButtonWithIcon("Recent", theme.HistoryIcon(), func() {
+		app.showRecentFolders(prefRecentSourceFolders, recentSourceBtn, app.setSourceFolder)
+	})
+
+	// Output folder selection
+	app.outputFolderLabel = widget.NewLabel("No output folder selected")
+	selectOutputBtn := widget.NewButton("Select Output Folder", app.selectOutputFolder)
+	var recentOutputBtn *widget.Button
+	recentOutputBtn = widget.NewButtonWithIcon("Recent", theme.HistoryIcon(), func() {
+		app.showRecentFolders(prefRecentOutputFolders, recentOutputBtn, app.setOutputFolder)
+	})
+
+	fullRunCheck := widget.NewCheck("Force full re-run (also process files organized by previous runs)", func(checked bool) {
+		app.forceFullRun = checked
+	})
+	fullRunCheck.SetChecked(app.forceFullRun)
+
+	// Progress bar, with an indeterminate bar while the total is still unknown
+	app.progressBar = widget.NewProgressBar()
+	app.progressBar.TextFormatter = func() string {
+		_, text := app.progress()
+		return text
+	}
+	app.progressBar.Hide()
+	app.discoveryBar = widget.NewProgressBarInfinite()
+	app.discoveryBar.Stop()
+	app.discoveryBar.Hide()
+
+	// Log output
+	app.logText = widget.NewMultiLineEntry()
+	app.logText.SetText("Ready to organize media files...\n")
+	app.logText.Disable()
+	
+	// Set minimum size for better readability
+	app.logText.Resize(fyne.NewSize(600, 200)) // Minimum width and height
+
+	// Run summary, shown once a run finishes
+	app.statsLabel = widget.NewLabel("")
+	app.statsCard = widget.NewCard("📈 Run Summary", "", app.statsLabel)
+	app.statsCard.Hide()
+
+	// Destination preview, refreshed on the same timer as the log
+	app.previewTree = widget.NewTree(
+		app.folderPreview.Children,
+		app.folderPreview.IsBranch,
+		func(branch bool) fyne.CanvasObject {
+			return widget.NewLabel("Folder")
+		},
+		func(id widget.TreeNodeID, branch bool, obj fyne.CanvasObject) {
+			obj.(*widget.Label).SetText(app.folderPreview.Label(id))
+		},
 	)
-	content.SetOffset(0.25)
+	app.previewTree.OnSelected = app.showThumbnails
+
+	// Thumbnails of the selected destination folder
+	app.thumbnailLabel = widget.NewLabel("Select a folder to preview its files")
+	app.thumbnailGrid = container.NewGridWrap(fyne.NewSize(ThumbnailSize, ThumbnailSize))
+
+	// Start and cancel buttons, also in the menu and on shortcuts
+	app.startBtn = widget.NewButton("Start Organizing", app.startOrganizing)
+	app.startBtn.Importance = widget.HighImportance
+	app.cancelBtn = widget.NewButton("Cancel", app.cancelOrganizing)
+	app.cancelBtn.Disable()
+	preferencesBtn := widget.NewButtonWithIcon("Preferences", theme.SettingsIcon(), app.showPreferences)
+
+	// Layout
+	// The output rows double as a drop zone; drops anywhere else set the source folder
+	app.outputDropZone = container.NewVBox(
+		widget.NewLabel("Output Folder:"),
+		container.NewHBox(selectOutputBtn, recentOutputBtn, app.outputFolderLabel),
+	)
+	dropHint := widget.NewLabel("Tip: drop a folder or .zip onto the window to set the source, or a folder onto the output row to set the output")
+	dropHint.TextStyle.Italic = true
+
+	folderSection := container.NewVBox(
+		widget.NewLabel("Source Folder:"),
+		container.NewHBox(selectSourceBtn, selectArchiveBtn, recentSourceBtn, app.sourceFolderLabel),
+		app.outputDropZone,
+		dropHint,
+	)
+
+	controlSection := container.NewVBox(
+		folderSection,
+		widget.NewSeparator(),
+		fullRunCheck,
+		container.NewHBox(app.startBtn, app.cancelBtn, preferencesBtn),
+		app.discoveryBar,
+		app.progressBar,
+		app.statsCard,
+		app.newFailuresCard(),
+	)
+
+	// Create a better log section with more prominent styling
+	logLabel := widget.NewLabel("🔍 Processing Log:")
+	logLabel.TextStyle.Bold = true
+	
+	app.logScroll = container.NewScroll(app.logText)
+	app.logScroll.SetMinSize(fyne.NewSize(400, 150)) // Ensure minimum scroll area size
+
+	clearLogBtn := widget.NewButton("Clear Log", app.clearLog)
+	autoScrollCheck := widget.NewCheck("Auto-scroll", func(checked bool) {
+		app.autoScrollLog = checked
+	})
+	autoScrollCheck.SetChecked(app.autoScrollLog)
+
+	logFilterEntry := widget.NewEntry()
+	logFilterEntry.SetPlaceHolder("Filter log (e.g. warning, error)")
+	logFilterEntry.OnChanged = app.setLogFilter
+
+	logSection := container.NewVBox(
+		container.NewHBox(logLabel, layout.NewSpacer(), autoScrollCheck, clearLogBtn),
+		logFilterEntry,
+		app.logScroll,
+	)
+
+	previewLabel := widget.NewLabel("📂 Destination Preview:")
+	previewLabel.TextStyle.Bold = true
+
+	previewSection := container.NewVSplit(
+		container.NewBorder(previewLabel, nil, nil, nil, app.previewTree),
+		container.NewBorder(app.thumbnailLabel, nil, nil, nil, container.NewVScroll(app.thumbnailGrid)),
+	)
+
+	outputSplit := container.NewHSplit(logSection, previewSection)
+	outputSplit.SetOffset(0.65)
+
+	content := container.NewVSplit(
+		container.NewVBox(title, controlSection),
+		outputSplit,
+	)
+	content.SetOffset(0.25)
+
+	app.window.SetContent(content)
+	app.window.SetOnDropped(app.handleDrop)
+	app.window.SetMainMenu(app.buildMainMenu())
+	app.addShortcuts()
+}
+
+func (app *App) selectSourceFolder() {
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
+			return
+		}
+		app.setSourceFolder(uri.Path())
+	}, app.window)
+}
+
+// selectSourceArchive chooses a zip archive to organize in place of a source folder
+func (app *App) selectSourceArchive() {
+	open := dialog.NewFileOpen(func(file fyne.URIReadCloser, err error) {
+		if err != nil || file == nil {
+			return
+		}
+		file.Close()
+		app.setSourceFolder(file.URI().Path())
+	}, app.window)
+	open.SetFilter(storage.NewExtensionFileFilter([]string{ArchiveExtension}))
+	open.Show()
+}
+
+func (app *App) selectOutputFolder() {
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
+			return
+		}
+		app.setOutputFolder(uri.Path())
+	}, app.window)
+}
+
+// setSourceFolder records the source folder and updates the UI
+func (app *App) setSourceFolder(path string) {
+	app.sourceFolder = path
+	app.sourceFolderLabel.SetText(app.sourceFolder)
+	app.safeLog(fmt.Sprintf("Source folder selected: %s\n", app.sourceFolder))
+	app.addRecentFolder(prefRecentSourceFolders, path)
+}
+
+// setOutputFolder records the output folder and updates the UI
+func (app *App) setOutputFolder(path string) {
+	app.outputFolder = path
+	app.outputFolderLabel.SetText(app.outputFolder)
+	app.safeLog(fmt.Sprintf("Output folder selected: %s\n", app.outputFolder))
+	app.addRecentFolder(prefRecentOutputFolders, path)
+}
+
+// loadNamedPlaces reads the persisted labeled places
+func (app *App) loadNamedPlaces() []NamedPlace {
+	stored := app.fyneApp.Preferences().String(prefNamedPlaces)
+	if stored == "" {
+		return nil
+	}
+	places, err := parseNamedPlaces([]byte(stored))
+	if err != nil {
+		app.safeLog(fmt.Sprintf("Warning: Ignoring saved labeled places: %v\n", err))
+		return nil
+	}
+	return places
+}
+
+// saveNamedPlaces persists the labeled places
+func (app *App) saveNamedPlaces() {
+	data, err := json.Marshal(app.namedPlaces)
+	if err != nil {
+		app.safeLog(fmt.Sprintf("Warning: Could not save labeled places: %v\n", err))
+		return
+	}
+	app.fyneApp.Preferences().SetString(prefNamedPlaces, string(data))
+}
+
+// editNamedPlace shows a form for the labeled place at index, or for a new place when
+// index is -1, and calls onSaved after saving the change
+func (app *App) editNamedPlace(index int, onSaved func()) {
+	place := NamedPlace{Radius: DefaultPlaceRadius}
+	title := "Add Labeled Place"
+	if index >= 0 {
+		place = app.namedPlaces[index]
+		title = "Edit Labeled Place"
+	}
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(place.Name)
+	nameEntry.SetPlaceHolder("e.g. Home")
+	latEntry := widget.NewEntry()
+	lngEntry := widget.NewEntry()
+	if index >= 0 {
+		latEntry.SetText(strconv.FormatFloat(place.Lat, 'f', -1, 64))
+		lngEntry.SetText(strconv.FormatFloat(place.Lng, 'f', -1, 64))
+	}
+	latEntry.SetPlaceHolder("e.g. 51.5007")
+	lngEntry.SetPlaceHolder("e.g. -0.1246")
+	radiusEntry := widget.NewEntry()
+	radiusEntry.SetText(strconv.FormatFloat(place.Radius, 'f', -1, 64))
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Latitude", latEntry),
+		widget.NewFormItem("Longitude", lngEntry),
+		widget.NewFormItem("Radius (m)", radiusEntry),
+	}
+	dialog.ShowForm(title, "Save", "Cancel", items, func(save bool) {
+		if !save {
+			return
+		}
+
+		edited := NamedPlace{Name: strings.TrimSpace(nameEntry.Text)}
+		var errs []error
+		for _, field := range []struct {
+			entry *widget.Entry
+			value *float64
+			label string
+		}{
+			{latEntry, &edited.Lat, "latitude"},
+			{lngEntry, &edited.Lng, "longitude"},
+			{radiusEntry, &edited.Radius, "radius"},
+		} {
+			value, err := strconv.ParseFloat(strings.TrimSpace(field.entry.Text), 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a number", field.label))
+			}
+			*field.value = value
+		}
+		err := errors.Join(errs...)
+		if err == nil {
+			err = edited.Validate()
+		}
+		if err != nil {
+			dialog.ShowError(err, app.window)
+			return
+		}
+
+		places := slices.Clone(app.namedPlaces)
+		if index >= 0 {
+			places[index] = edited
+		} else {
+			places = append(places, edited)
+		}
+		app.namedPlaces = places
+		app.saveNamedPlaces()
+		app.safeLog(fmt.Sprintf("Labeled place saved: %s\n", edited))
+		onSaved()
+	}, app.window)
+}
+
+// addRecentFolder moves path to the front of the persisted recent folder list stored under key
+func (app *App) addRecentFolder(key, path string) {
+	prefs := app.fyneApp.Preferences()
+
+	recent := []string{path}
+	for _, existing := range prefs.StringList(key) {
+		if existing != path && len(recent) < MaxRecentFolders {
+			recent = append(recent, existing)
+		}
+	}
+
+	prefs.SetStringList(key, recent)
+}
+
+// recentFolders returns the persisted recent folders stored under key,
+// dropping (and forgetting) any entries that no longer exist on disk
+func (app *App) recentFolders(key string) []string {
+	prefs := app.fyneApp.Preferences()
+	stored := prefs.StringList(key)
+
+	var existing []string
+	for _, folder := range stored {
+		if fileInfo, err := os.Stat(folder); err == nil && fileInfo.IsDir() {
+			existing = append(existing, folder)
+		}
+	}
+
+	if len(existing) != len(stored) {
+		prefs.SetStringList(key, existing)
+	}
+
+	return existing
+}
+
+// showRecentFolders pops up a menu of recent folders below anchor, calling onSelect with the chosen path
+func (app *App) showRecentFolders(key string, anchor fyne.CanvasObject, onSelect func(string)) {
+	var items []*fyne.MenuItem
+	for _, folder := range app.recentFolders(key) {
+		folder := folder
+		items = append(items, fyne.NewMenuItem(folder, func() {
+			onSelect(folder)
+		}))
+	}
+
+	if len(items) == 0 {
+		placeholder := fyne.NewMenuItem("No recent folders", nil)
+		placeholder.Disabled = true
+		items = append(items, placeholder)
+	}
+
+	pos := app.fyneApp.Driver().AbsolutePositionForObject(anchor)
+	pos = pos.Add(fyne.NewPos(0, anchor.Size().Height))
+	widget.ShowPopUpMenuAtPosition(fyne.NewMenu("", items...), app.window.Canvas(), pos)
+}
+
+// handleDrop sets folders dropped onto the window as the source folder,
+// or as the output folder when dropped onto the output folder section
+func (app *App) handleDrop(pos fyne.Position, uris []fyne.URI) {
+	var folders []string
+	for _, uri := range uris {
+		path := uri.Path()
+		if uri.Scheme() != "file" {
+			app.safeLog(fmt.Sprintf("Ignoring dropped item (not a local file): %s\n", uri.String()))
+			continue
+		}
+		if fileInfo, err := os.Stat(path); err != nil || !(fileInfo.IsDir() || isArchive(path)) {
+			app.safeLog(fmt.Sprintf("Ignoring dropped item (not a folder or %s archive): %s\n", ArchiveExtension, path))
+			continue
+		}
+		folders = append(folders, path)
+	}
+
+	if len(folders) == 0 {
+		return
+	}
+
+	if app.isPositionOver(pos, app.outputDropZone) {
+		app.setOutputFolder(folders[0])
+	} else {
+		app.setSourceFolder(folders[0])
+	}
+
+	// Only a single source and output folder are supported
+	for _, ignored := range folders[1:] {
+		app.safeLog(fmt.Sprintf("Ignoring additional dropped folder: %s\n", ignored))
+	}
+}
+
+// isPositionOver reports whether a window position falls within the bounds of obj
+func (app *App) isPositionOver(pos fyne.Position, obj fyne.CanvasObject) bool {
+	if obj == nil || !obj.Visible() {
+		return false
+	}
+
+	objPos := app.fyneApp.Driver().AbsolutePositionForObject(obj)
+	size := obj.Size()
+	return pos.X >= objPos.X && pos.X <= objPos.X+size.Width &&
+		pos.Y >= objPos.Y && pos.Y <= objPos.Y+size.Height
+}
+
+func (app *App) startOrganizing() {
+	if app.running.Load() {
+		return
+	}
+	if err := app.Validate(); err != nil {
+		dialog.ShowError(err, app.window)
+		return
+	}
+
+	app.setRunning(true)
+	app.progressBar.SetValue(0)
+	app.statsCard.Hide()
+	app.showFailures(nil)
+	
+	// Start UI update timer
+	app.startUIUpdateTimer()
+
+	// Run organization in a goroutine to prevent UI blocking
+	go app.runOrganizer()
+}
+
+// runOrganizer runs the organizer and reports the outcome once it finishes
+func (app *App) runOrganizer() {
+	err := app.Run()
+
+	app.stopUIUpdateTimer()
+	failures := app.runStats.FailedFiles()
+	app.runOnMain(func() {
+		app.updateUIFromBuffer() // Final update
+		app.setRunning(false)
+		app.showFailures(failures) // Also after a cancelled or failed run
+	})
+
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	if err != nil {
+		app.sendNotification("Media organization failed", fmt.Sprintf("Error %v", err))
+		app.runOnMain(func() {
+			dialog.ShowError(err, app.window)
+		})
+		return
+	}
+
+	app.sendNotification("Media organization complete",
+		fmt.Sprintf("%d files organized into %d location clusters (%d errors)", app.runStats.Copied, app.runStats.Clusters, app.runStats.Errors))
+
+	// Show the run summary
+	app.runOnMain(func() {
+		app.statsLabel.SetText(app.runStats.Summary())
+		app.statsCard.Show()
+	})
+
+	// Open file explorer to output folder
+	app.openFileExplorer(app.outputFolder)
+}
+
+// OnLog adds a timestamped log message to the log buffer
+func (app *App) OnLog(message string) {
+	timestamp := time.Now().Format("15:04:05")
+	app.logBuffer.Add(fmt.Sprintf("[%s] %s", timestamp, message))
+}
+
+// ConfirmPlan asks whether to go ahead with the copy plan, holding the run until the
+// dialog is answered. It is called from the organizer's goroutine.
+func (app *App) ConfirmPlan(plan CopyPlan) bool {
+	answer := make(chan bool, 1)
+	app.runOnMain(func() {
+		confirm := dialog.NewConfirm("Start Copying?", plan.Summary(), func(ok bool) {
+			answer <- ok
+		}, app.window)
+		confirm.SetConfirmText("Start")
+		confirm.Show()
+	})
+	return <-answer
+}
+
+// OnProgress does nothing: the UI timer reads the counters, so the progress
+// bar isn't redrawn for every file
+func (app *App) OnProgress(processed, total int64) {}
+
+// OnPhaseChange switches between the discovery and progress bars. It is called from
+// the organizer's goroutine.
+func (app *App) OnPhaseChange(phase Phase) {
+	app.runOnMain(func() {
+		switch phase {
+		case PhaseDiscovering:
+			app.discoveryBar.Show()
+			app.discoveryBar.Start()
+		case PhaseExtracting:
+			// The total is known now, so swap to the determinate bar
+			app.discoveryBar.Stop()
+			app.discoveryBar.Hide()
+			app.progressBar.Show()
+		case PhaseDone:
+			app.discoveryBar.Stop()
+			app.discoveryBar.Hide()
+
+			// Hide progress bar after a delay
+			time.AfterFunc(2*time.Second, func() {
+				app.runOnMain(app.progressBar.Hide)
+			})
+		}
+	})
+}
+
+// runOnMain queues fn, which updates widgets, to run on Fyne's main goroutine and returns
+// without waiting for it. Widgets may only be changed on that goroutine, so background
+// goroutines make every widget change through here; UI callbacks already run on it.
+func (app *App) runOnMain(fn func()) {
+	app.dispatch(fn)
+}
+
+// startUIUpdateTimer starts a timer for periodic UI updates
+func (app *App) startUIUpdateTimer() {
+	ticker := time.NewTicker(UIUpdateInterval)
+	stop := make(chan struct{})
+	app.logUpdateTimer, app.logUpdateStop = ticker, stop
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				app.runOnMain(app.updateUIFromBuffer)
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopUIUpdateTimer stops the UI update timer and its goroutine
+func (app *App) stopUIUpdateTimer() {
+	if app.logUpdateTimer != nil {
+		app.logUpdateTimer.Stop()
+		close(app.logUpdateStop)
+		app.logUpdateTimer, app.logUpdateStop = nil, nil
+	}
+}
+
+// updateUIFromBuffer updates the UI with buffered log content
+func (app *App) updateUIFromBuffer() {
+	app.refreshLogView()
+
+	if app.folderPreview.TakeDirty() && app.previewTree != nil {
+		app.previewTree.Refresh()
+	}
+
+	// Update progress bar
+	if progress, text := app.progress(); text != "" {
+		app.progressBar.SetValue(progress)
+	}
+}
+
+// showThumbnails fills the thumbnail grid with previews of the files in the selected
+// folder. Placeholders appear at once; thumbnails are decoded in the background.
+func (app *App) showThumbnails(id widget.TreeNodeID) {
+	files := app.folderPreview.Files(id)
+	sort.Strings(files)
+	seq := app.thumbnailSeq.Add(1)
+
+	if len(files) > MaxThumbnails {
+		app.thumbnailLabel.SetText(fmt.Sprintf("Showing %d of %d files", MaxThumbnails, len(files)))
+		files = files[:MaxThumbnails]
+	} else {
+		app.thumbnailLabel.SetText(fmt.Sprintf("%d files", len(files)))
+	}
+
+	cells := make([]*fyne.Container, len(files))
+	app.thumbnailGrid.RemoveAll()
+	for i := range files {
+		cells[i] = container.NewStack(widget.NewIcon(theme.FileImageIcon()))
+		app.thumbnailGrid.Add(cells[i])
+	}
+
+	go func() {
+		for i, file := range files {
+			// A newer selection has replaced this grid
+			if app.thumbnailSeq.Load() != seq {
+				return
+			}
+
+			// Videos and audio have no image to decode, so don't read them through to
+			// hash them for the cache
+			if kind := app.mediaKind(file); kind == MediaVideo || kind == MediaAudio {
+				continue
+			}
+			thumbnail, err := app.thumbnailCache.Thumbnail(file)
+			if err != nil {
+				continue // Formats we can't decode keep the placeholder
+			}
+			img := canvas.NewImageFromImage(thumbnail)
+			img.FillMode = canvas.ImageFillContain
+			cell := cells[i] // The update runs later, once i has moved on
+			app.runOnMain(func() {
+				cell.Objects = []fyne.CanvasObject{img}
+				cell.Refresh()
+			})
+		}
+	}()
+}
+
+// refreshLogView brings the log view up to date with the log buffer, honoring the filter
+func (app *App) refreshLogView() {
+	var lines []string
+	var next uint64
+	complete := app.logFilterChanged
+	app.logFilterChanged = false
+
+	if app.logFilter != "" {
+		// Filtering works on a snapshot of the buffer, leaving stored lines untouched
+		all, mark := app.logBuffer.Snapshot()
+		if !complete && mark == app.logSeq {
+			return
+		}
+		lines, next, complete = filterLogLines(all, app.logFilter), mark, true
+	} else if complete {
+		lines, next = app.logBuffer.Snapshot()
+	} else {
+		// Only append what's new; rebuild when the buffer wrapped past us or the
+		// widget has grown well beyond what the buffer retains
+		lines, next, complete = app.logBuffer.GetLinesSince(app.logSeq)
+		if !complete && app.logLineCount+len(lines) > 2*MaxLogLines {
+			lines, next = app.logBuffer.Snapshot()
+			complete = true
+		}
+	}
+	app.logSeq = next
+
+	if !complete && len(lines) == 0 {
+		return
+	}
+
+	// Keep the reader's place when auto-scroll is off
+	offset := app.logScroll.Offset
+
+	if complete {
+		app.logText.SetText(strings.Join(lines, ""))
+		app.logLineCount = len(lines)
+	} else {
+		app.logText.Append(strings.Join(lines, ""))
+		app.logLineCount += len(lines)
+	}
+
+	if app.autoScrollLog {
+		app.logScroll.ScrollToBottom()
+	} else {
+		app.logScroll.Offset = offset
+		app.logScroll.Refresh()
+	}
+}
+
+// filterLogLines returns the lines containing filter, ignoring case
+func filterLogLines(lines []string, filter string) []string {
+	filter = strings.ToLower(filter)
+	var matched []string
+	for _, line := range lines {
+		if strings.Contains(strings.ToLower(line), filter) {
+			matched = append(matched, line)
+		}
+	}
+	return matched
+}
+
+// setLogFilter changes the log filter and redraws the log view
+func (app *App) setLogFilter(filter string) {
+	app.logFilter = strings.TrimSpace(filter)
+	app.logFilterChanged = true
+	app.refreshLogView()
+}
+
+// clearLog empties both the log buffer and the log view
+func (app *App) clearLog() {
+	app.logBuffer.Clear()
+	app.logText.SetText("")
+	app.logLineCount = 0
+}
+
+// sendNotification posts a system notification when notifications are enabled
+func (app *App) sendNotification(title, content string) {
+	if !app.notifyOnComplete || app.fyneApp == nil {
+		return
+	}
+	app.fyneApp.SendNotification(fyne.NewNotification(title, content))
+}
+
+
+// MediaKind classifies supported formats by how their metadata is extracted
+type MediaKind int
+
+const (
+	MediaUnknown MediaKind = iota
+	MediaImage             // EXIF decoded in-process with goexif
+	MediaHEIF              // HEIC/HEIF, metadata read with exiftool
+	MediaVideo             // Metadata read with exiftool
+	MediaAudio             // Creation date read with exiftool, no GPS
+)
+
+// mediaFormat describes a supported file extension
+type mediaFormat struct {
+	Kind        MediaKind
+	Description string
+}
+
+// mediaFormats lists the built-in supported extensions; each gets a handler for its
+// kind in registerBuiltinHandlers, which discovery and extraction then consult
+var mediaFormats = map[string]mediaFormat{
+	".jpg":  {MediaImage, "JPEG"},
+	".jpeg": {MediaImage, "JPEG"},
+	".png":  {MediaImage, "PNG"},
+	".tiff": {MediaImage, "TIFF"},
+	".tif":  {MediaImage, "TIFF"},
+	".bmp":  {MediaImage, "Bitmap"},
+	".gif":  {MediaImage, "GIF"},
+	".avif": {MediaImage, "AV1 Image File Format"},
+	".webp": {MediaImage, "WebP format"},
+	".dng":  {MediaImage, "Digital Negative (RAW)"},
+	".cr2":  {MediaImage, "Canon RAW"},
+	".nef":  {MediaImage, "Nikon RAW"},
+	".arw":  {MediaImage, "Sony RAW"},
+	".heic": {MediaHEIF, "iPhone HEVC images"},
+	".heif": {MediaHEIF, "HEIF images"},
+	".mov":  {MediaVideo, "QuickTime Movie"},
+	".mp4":  {MediaVideo, "MPEG-4 Video"},
+	".m4v":  {MediaVideo, "iTunes Video"},
+	".avi":  {MediaVideo, "Audio Video Interleave"},
+	".mkv":  {MediaVideo, "Matroska Video"},
+	".wmv":  {MediaVideo, "Windows Media Video"},
+	".flv":  {MediaVideo, "Flash Video"},
+	".webm": {MediaVideo, "WebM Video"},
+	".3gp":  {MediaVideo, "3GPP mobile video"},
+	".mts":  {MediaVideo, "AVCHD video"},
+	".m2ts": {MediaVideo, "Blu-ray/AVCHD video"},
+	".m4a":  {MediaAudio, "Apple voice memos / AAC audio"},
+	".mp3":  {MediaAudio, "MPEG audio"},
+	".wav":  {MediaAudio, "Waveform audio"},
+	".aac":  {MediaAudio, "Raw AAC audio"},
+	".flac": {MediaAudio, "Free Lossless Audio Codec"},
+	".aiff": {MediaAudio, "Audio Interchange File Format"},
+	".amr":  {MediaAudio, "Adaptive Multi-Rate (phone voice recordings)"},
+}
+
+// isOrganizedKind reports whether files of the given kind should be organized.
+// Audio files carry no GPS and are only included when audio support is enabled.
+func (org *Organizer) isOrganizedKind(kind MediaKind) bool {
+	switch kind {
+	case MediaUnknown:
+		return false
+	case MediaAudio:
+		return org.includeAudio
+	default:
+		return true
+	}
+}
+
+// discoveryHeartbeatInterval is how many discovered files pass between progress log lines
+const discoveryHeartbeatInterval = 1000
+
+// mediaScan holds the state shared by a source folder scan, including any
+// symlinked directories it follows
+type mediaScan struct {
+	files        []string
+	skippedPaths int
+	skippedLinks int
+	skippedJunk  int
+	skippedMarks int               // Entries skipped because of an ignore marker
+	root         string            // Folder being scanned
+	followLinks  bool              // Follow symbolic links, which only makes sense on disk
+	excludeDir   string            // Output folder, pruned when it lives under the source
+	dirPaths     map[string]string // Walked directory paths mapped to their resolved paths
+	visitedDirs  map[string]bool   // Resolved paths of directories already walked
+	seenFiles    map[string]bool   // Resolved paths of files already added
+
+	// Patterns of the ignore markers found, by folder
+	ignores map[string]ignoreRules
+}
+
+// findMediaFiles returns the media files in the folder root on disk, or in the archive
+// root when it is one
+func (org *Organizer) findMediaFiles(root string) ([]string, error) {
+	if isArchive(root) {
+		return org.findArchiveMediaFiles(root)
+	}
+	return org.FindMediaFiles(os.DirFS(root), root)
+}
+
+// FindMediaFiles returns the media files in fsys, as paths under root, skipping junk,
+// the organizer's own files and the output folder just as a run does. Any filesystem
+// will do, such as an in-memory fstest.MapFS, but symbolic links are followed on disk,
+// so following them only makes sense when fsys is os.DirFS(root).
+func (org *Organizer) FindMediaFiles(fsys fs.FS, root string) ([]string, error) {
+	return org.scanMediaFiles(fsys, root, org.followSymlinks)
+}
+
+// scanMediaFiles does the work of FindMediaFiles, following symbolic links only when
+// followLinks is set
+func (org *Organizer) scanMediaFiles(fsys fs.FS, root string, followLinks bool) ([]string, error) {
+	scan := &mediaScan{
+		root:        filepath.Clean(root),
+		followLinks: followLinks,
+		excludeDir:  org.outputFolder,
+		ignores:     make(map[string]ignoreRules),
+		dirPaths:    make(map[string]string),
+		visitedDirs: make(map[string]bool),
+		seenFiles:   make(map[string]bool),
+	}
+
+	err := org.walkMediaFiles(fsys, root, scan)
+
+	if scan.skippedPaths > 0 {
+		org.safeLog(fmt.Sprintf("Skipped %d unreadable paths while scanning %s\n", scan.skippedPaths, root))
+	}
+	if scan.skippedJunk > 0 {
+		org.safeLog(fmt.Sprintf("Skipped %d hidden or system files and folders\n", scan.skippedJunk))
+	}
+	if scan.skippedMarks > 0 {
+		org.safeLog(fmt.Sprintf("Skipped %d files and folders marked by %s\n", scan.skippedMarks, IgnoreFileName))
+	}
+	if scan.skippedLinks > 0 {
+		org.safeLog(fmt.Sprintf("Skipped %d symbolic links (enable \"Follow symbolic links\" to include them)\n", scan.skippedLinks))
+	}
+
+	return scan.files, err
+}
+
+// walkMediaFiles walks fsys and records media files as if they lived under displayRoot,
+// so files reached through a symlinked directory keep the link's path
+func (org *Organizer) walkMediaFiles(fsys fs.FS, displayRoot string, scan *mediaScan) error {
+	// WalkDir avoids stat-ing every entry; the extension check only needs the name
+	return fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		path := filepath.Join(displayRoot, filepath.FromSlash(name))
+		if err != nil {
+			// An unreadable source folder is fatal, but one bad entry below it shouldn't
+			// stop everything else from being discovered
+			if name == "." {
+				return err
+			}
+			org.safeLog(fmt.Sprintf("Warning: Skipping %s: %v\n", path, err))
+			scan.skippedPaths++
+			if entry != nil && entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if org.skipJunkFiles && name != "." && isJunkFile(entry.Name()) {
+			scan.skippedJunk++
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if name != "." && scan.isIgnored(path, entry.IsDir()) {
+			scan.skippedMarks++
+			if entry.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			return org.handleSymlink(path, scan)
+		}
+
+		// Never organize the organizer's own bookkeeping, whatever handlers are registered
+		if !entry.IsDir() && isOrganizerFile(entry.Name()) {
+			return nil
+		}
+
+		if entry.IsDir() {
+			if scan.excludeDir != "" && name != "." && isSameFolder(path, scan.excludeDir) {
+				org.safeLog(fmt.Sprintf("Skipping output folder %s\n", path))
+				return fs.SkipDir
+			}
+
+			// Only followed links can lead back into a directory we've already walked
+			if scan.followLinks && !org.markDirVisited(path, scan) {
+				org.safeLog(fmt.Sprintf("Symlink loop detected at %s, skipping\n", path))
+				return fs.SkipDir
+			}
+
+			if org.loadIgnoreMarker(fsys, name, path, scan) {
+				org.safeLog(fmt.Sprintf("Skipping %s, which is marked by %s\n", path, IgnoreFileName))
+				scan.skippedMarks++
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if org.isOrganizedKind(org.mediaKind(path)) {
+			if scan.followLinks {
+				// Remember the real location so a link to this file isn't added again
+				resolvedDir, ok := scan.dirPaths[filepath.Dir(path)]
+				if ok {
+					resolved := filepath.Join(resolvedDir, filepath.Base(path))
+					if scan.seenFiles[resolved] {
+						return nil
+					}
+					scan.seenFiles[resolved] = true
+				}
+			}
+			scan.files = append(scan.files, path)
+
+			// Heartbeat so huge trees don't look frozen during discovery
+			if len(scan.files)%discoveryHeartbeatInterval == 0 {
+				org.safeLog(fmt.Sprintf("Found %d media files so far...\n", len(scan.files)))
+			}
+		}
+		return nil
+	})
+}
+
+// handleSymlink skips a symbolic link, or follows it when enabled while guarding
+// against loops and files reached twice through different links
+func (org *Organizer) handleSymlink(path string, scan *mediaScan) error {
+	if !scan.followLinks {
+		scan.skippedLinks++
+		return nil
+	}
+
+	target, err := os.Stat(path)
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Skipping broken symbolic link %s: %v\n", path, err))
+		scan.skippedPaths++
+		return nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Skipping symbolic link %s: %v\n", path, err))
+		scan.skippedPaths++
+		return nil
+	}
+
+	if !target.IsDir() {
+		if org.isOrganizedKind(org.mediaKind(path)) && !scan.seenFiles[resolved] {
+			scan.seenFiles[resolved] = true
+			scan.files = append(scan.files, path)
+		}
+		return nil
+	}
+
+	if scan.visitedDirs[resolved] {
+		org.safeLog(fmt.Sprintf("Symlink loop detected: %s -> %s, skipping\n", path, resolved))
+		return nil
+	}
+
+	// The nested walk marks resolved as visited when it enters it
+	if err := org.walkMediaFiles(os.DirFS(resolved), path, scan); err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Skipping %s: %v\n", path, err))
+		scan.skippedPaths++
+	}
+	return nil
+}
+
+// isWithinFolder reports whether path is folder itself or somewhere below it
+func isWithinFolder(path, folder string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absFolder, err := filepath.Abs(folder)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(absFolder, absPath)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isSameFolder reports whether a and b refer to the same folder path
+func isSameFolder(a, b string) bool {
+	return isWithinFolder(a, b) && isWithinFolder(b, a)
+}
+
+// junkFileNames lists OS-generated files that are never worth organizing (lowercase)
+var junkFileNames = map[string]bool{
+	"thumbs.db":   true,
+	"desktop.ini": true,
+}
+
+// isJunkFile reports whether name is a hidden file or folder (including macOS
+// .DS_Store and ._ AppleDouble companions) or known OS junk like Thumbs.db
+func isJunkFile(name string) bool {
+	return strings.HasPrefix(name, ".") || junkFileNames[strings.ToLower(name)]
+}
+
+// isOrganizerFile reports whether name is a manifest, folder metadata or ignore marker
+// file of the organizer itself
+func isOrganizerFile(name string) bool {
+	return name == ManifestFileName || name == FolderMetadataFileName || name == IgnoreFileName
+}
+
+// markDirVisited records the resolved path of dir, returning false if it was already walked
+func (org *Organizer) markDirVisited(dir string, scan *mediaScan) bool {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return true
+	}
+	if scan.visitedDirs[resolved] {
+		return false
+	}
+	scan.visitedDirs[resolved] = true
+	scan.dirPaths[dir] = resolved
+	return true
+}
+
+// filenameDatePatterns lists the timestamp formats commonly found in media filenames.
+// Each layout describes the capture groups concatenated together. Specific patterns come
+// first and the generic ones are anchored on non-digits so they can't match inside a
+// longer run of digits.
+var filenameDatePatterns = []struct {
+	regex  *regexp.Regexp
+	layout string
+}{
+	// iPhone format: IMG_20240315_143022.heic
+	{regexp.MustCompile(`IMG_(\d{8})_(\d{6})`), "20060102150405"},
+	// Pixel format: PXL_20240315_143022123.jpg (trailing milliseconds are ignored)
+	{regexp.MustCompile(`PXL_(\d{8})_(\d{6})\d{3}`), "20060102150405"},
+	// Android video format: VID_20240315_143022.mp4
+	{regexp.MustCompile(`VID_(\d{8})_(\d{6})`), "20060102150405"},
+	// Screenshot format: Screenshot_20240315-143022.png
+	{regexp.MustCompile(`Screenshot_(\d{8})-(\d{6})`), "20060102150405"},
+	// WhatsApp format: WhatsApp Image 2024-03-15 at 14.30.22.jpeg
+	{regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2}) at (\d{2})\.(\d{2})\.(\d{2})`), "20060102150405"},
+	// Signal format: signal-2024-03-15-143022.jpg
+	{regexp.MustCompile(`signal-(\d{4})-(\d{2})-(\d{2})-(\d{6})`), "20060102150405"},
+	// ISO format: 2024-03-15T14-30-22.jpg
+	{regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})T(\d{2})-(\d{2})-(\d{2})`), "20060102150405"},
+	// Underscore-separated format: 2024_03_15_14_30_22.jpg
+	{regexp.MustCompile(`(?:^|\D)(\d{4})_(\d{2})_(\d{2})_(\d{2})_(\d{2})_(\d{2})(?:\D|$)`), "20060102150405"},
+	// Android format: 20240315_143022.jpg
+	{regexp.MustCompile(`(?:^|\D)(\d{8})_(\d{6})(?:\D|$)`), "20060102150405"},
+	// Timestamp format: 1710508222.jpg (Unix timestamp)
+	{regexp.MustCompile(`^(\d{10})$`), "unix"},
+	// Generic YYYYMMDD format: 20240315.jpg
+	{regexp.MustCompile(`(?:^|\D)(\d{8})(?:\D|$)`), "20060102"},
+}
+
+// isPlausibleFilenameDate reports whether a date parsed from a filename is a plausible
+// capture date (digit runs outside that range are almost certainly counters or IDs)
+// and, for formatted layouts, formats back to the digits it was parsed from
+// (rejecting overflowed values such as a 31st of February)
+func isPlausibleFilenameDate(parsed time.Time, layout, digits string) bool {
+	if _, implausible := implausibleDate(parsed, time.Now()); implausible {
+		return false
+	}
+	return layout == "unix" || parsed.Format(layout) == digits
+}
+
+// extractDateFromFilename attempts to extract a timestamp from the filename
+// Supports various common timestamp formats found in media filenames. It also returns
+// how the date should be localized: Unix timestamps are instants, while the formatted
+// dates are wall-clock times like EXIF's.
+func (org *Organizer) extractDateFromFilename(filename string) (time.Time, captureTimeKind, bool) {
+	// Remove extension for cleaner parsing
+	basename := strings.TrimSuffix(filename, filepath.Ext(filename))
+
+	for _, pattern := range filenameDatePatterns {
+		matches := pattern.regex.FindStringSubmatch(basename)
+		if len(matches) < 2 {
+			continue
+		}
+
+		if pattern.layout == "unix" {
+			// Handle Unix timestamp
+			if timestamp, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
+				if parsedTime := time.Unix(timestamp, 0); isPlausibleFilenameDate(parsedTime, pattern.layout, matches[1]) {
+					return parsedTime, absoluteTime, true
+				}
+			}
+			continue
+		}
+
+		// Handle formatted date strings
+		dateStr := strings.Join(matches[1:], "")
+		if parsedTime, err := time.Parse(pattern.layout, dateStr); err == nil && isPlausibleFilenameDate(parsedTime, pattern.layout, dateStr) {
+			return parsedTime, wallClockTime, true
+		}
+	}
+
+	return time.Time{}, wallClockTime, false
+}
+
+// extractImageInfo reads the metadata of a media file and localizes its capture date
+func (org *Organizer) extractImageInfo(imagePath string) (*ImageInfo, error) {
+	info, err := org.readImageInfo(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info.chooseDate(org.datePriority)
+	if !slices.Equal(org.datePriority, DefaultDatePriority) && len(info.dateCandidates) > 1 {
+		org.safeLog(fmt.Sprintf("Date for %s taken from %s\n", filepath.Base(imagePath), strings.ToLower(info.DateSource)))
+	}
+	info.Date = org.localizeCaptureTime(info)
+	info.ReadProblem, _ = org.runStats.Problem(imagePath)
+	return info, nil
+}
+
+// localizeCaptureTime interprets info.Date according to how it was recorded and the
+// timezone settings, so that its wall clock reflects local time where it was captured
+func (org *Organizer) localizeCaptureTime(info *ImageInfo) time.Time {
+	if info.dateKind == zonedTime {
+		return info.Date
+	}
+
+	target := time.Local
+	if org.useGPSTimeZone && info.HasGPS {
+		target = org.captureTimeZone(info.Latitude, info.Longitude)
+	}
+
+	if info.dateKind == absoluteTime {
+		return info.Date.In(target)
+	}
+
+	// Re-read the naive wall clock in the zone the camera clock was set to
+	d := info.Date
+	if org.exifTimeZone == TimeZoneUTC {
+		return time.Date(d.Year(), d.Month(), d.Day(), d.Hour(), d.Minute(), d.Second(), d.Nanosecond(), time.UTC).In(target)
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), d.Hour(), d.Minute(), d.Second(), d.Nanosecond(), target)
+}
+
+// approximateTimeZone derives a fixed timezone from a longitude using 15-degree nautical
+// time zones. This offline lookup ignores political boundaries and daylight saving, but
+// is close enough to put a capture on the right calendar day.
+func approximateTimeZone(longitude float64) *time.Location {
+	offsetHours := int(math.Round(longitude / 15))
+	return time.FixedZone(fmt.Sprintf("UTC%+d", offsetHours), offsetHours*3600)
+}
+
+// captureTimeZone returns the timezone at a location, looked up once per grid cell
+func (org *Organizer) captureTimeZone(lat, lng float64) *time.Location {
+	if org.zoneCache == nil {
+		return approximateTimeZone(lng)
+	}
+	return org.zoneCache.Get(gridCellKey(lat, lng, org.locationSensitivity), func() *time.Location {
+		return approximateTimeZone(lng)
+	})
+}
+
+// readImageInfo extracts date and location metadata from a media file with the
+// handler registered for its extension, then adds the dates every file has
+func (org *Organizer) readImageInfo(imagePath string) (*ImageInfo, error) {
+	ext := strings.ToLower(filepath.Ext(imagePath))
+	registered, ok := org.handlers[ext]
+	if !ok {
+		return nil, fmt.Errorf("no handler registered for %s files", ext)
+	}
+
+	var info *ImageInfo
+	var err error
+	if entry, archived := org.archiveEntry(imagePath); archived {
+		info, err = org.readArchivedInfo(registered.handler, entry, imagePath)
+	} else {
+		info, err = registered.handler.ExtractInfo(imagePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.OriginalPath = imagePath
+	info.SourceDir = org.sourceRelativeDir(imagePath)
+	if info.Location == "" {
+		info.Location = "Unknown"
+	}
+
+	// Handlers outside this file may set Date directly rather than offering it
+	if len(info.dateCandidates) == 0 && !info.Date.IsZero() {
+		info.offerDate(DateSourceMetadata, info.Date, info.dateKind)
+	}
+
+	// Google Photos Takeout moves GPS and capture dates into a JSON sidecar
+	org.applyTakeoutSidecar(info, imagePath)
+	org.rejectUnreliableGPS(info)
+
+	// Every date found is offered as a candidate; extractImageInfo picks one by
+	// the configured priority, by default:
+	// 1. EXIF date (most accurate)
+	// 2. Filename timestamp (good fallback)
+	// 3. File modification time (last resort)
+
+	// Get file info for ultimate fallback
+	if fileInfo, err := org.statSource(imagePath); err == nil {
+		info.offerDate(DateSourceModTime, fileInfo.ModTime(), absoluteTime)
+	}
+
+	filename := filepath.Base(imagePath)
+	if filenameDate, kind, found := org.extractDateFromFilename(filename); found {
+		info.offerDate(DateSourceFilename, filenameDate, kind)
+		org.safeLog(fmt.Sprintf("Extracted date from filename: %s -> %s\n",
+			filepath.Base(imagePath), filenameDate.Format("2006-01-02 15:04:05")))
+	}
+
+	// Without any date, the file is dated now
+	if len(info.dateCandidates) == 0 {
+		info.Date, info.dateKind = time.Now(), absoluteTime
+	}
+
+	return info, nil
+}
+
+// applyExifData copies the capture date, camera and (optionally) GPS position from
+// decoded EXIF data into info. It returns errMalformedExif, with info partly filled in,
+// if a malformed tag panics goexif.
+func (org *Organizer) applyExifData(info *ImageInfo, exifData *exif.Exif, includeGPS bool) error {
+	return guardExif(func() error {
+		org.readExifTags(info, exifData, includeGPS)
+		return nil
+	})
+}
+
+// readExifTags does the work of applyExifData
+func (org *Organizer) readExifTags(info *ImageInfo, exifData *exif.Exif, includeGPS bool) {
+	// Extract date/time from EXIF (by default preferred over the filename date as it's more accurate)
+	if dateTime, err := exifData.DateTime(); err == nil {
+		dateKind := wallClockTime
+		if tz, _ := exifData.TimeZone(); tz != nil {
+			dateKind = zonedTime
+		}
+		if tag, err := exifData.Get(exif.SubSecTimeOriginal); err == nil {
+			if subsec, err := tag.StringVal(); err == nil {
+				dateTime = withSubSeconds(dateTime, subsec)
+			}
+		}
+		info.offerDate(DateSourceMetadata, dateTime, dateKind)
+	}
+
+	// Extract camera make and model
+	if tag, err := exifData.Get(exif.Make); err == nil {
+		info.CameraMake, _ = tag.StringVal()
+	}
+	if tag, err := exifData.Get(exif.Model); err == nil {
+		info.CameraModel, _ = tag.StringVal()
+	}
+	info.CameraMake = strings.TrimSpace(strings.TrimRight(info.CameraMake, "\x00"))
+	info.CameraModel = strings.TrimSpace(strings.TrimRight(info.CameraModel, "\x00"))
+
+	// Extract GPS coordinates
+	if !includeGPS {
+		return
+	}
+	if lat, long, err := exifData.LatLong(); err == nil {
+		info.HasGPS = true
+		info.Latitude = lat
+		info.Longitude = long
+		info.Location = formatLocation(lat, long, fileLocationDecimals)
+		info.Elevation, info.HasElevation = exifAltitude(exifData)
+		info.GPSError, info.HasGPSError = exifGPSError(exifData)
+	}
+}
 
-	app.window.SetContent(content)
+// withSubSeconds adds an EXIF SubSecTime value, the digits of a decimal fraction of a
+// second ("07" is 70ms), to t, which EXIF otherwise records to the second. Bursts are
+// only ordered correctly with it.
+func withSubSeconds(t time.Time, subsec string) time.Time {
+	subsec = strings.TrimSpace(strings.TrimRight(subsec, "\x00"))
+	if subsec == "" || len(subsec) > 9 {
+		return t
+	}
+	digits, err := strconv.Atoi(subsec)
+	if err != nil || digits < 0 {
+		return t
+	}
+	for i := len(subsec); i < 9; i++ {
+		digits *= 10
+	}
+	return t.Add(time.Duration(digits))
 }
 
-func (app *App) selectSourceFolder() {
-	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
-		if err != nil || uri == nil {
-			return
+// exifAltitude returns the GPS altitude in meters, negative below sea level
+func exifAltitude(exifData *exif.Exif) (float64, bool) {
+	tag, err := exifData.Get(exif.GPSAltitude)
+	if err != nil {
+		return 0, false
+	}
+	numerator, denominator, err := tag.Rat2(0)
+	if err != nil || denominator == 0 {
+		return 0, false
+	}
+	altitude := float64(numerator) / float64(denominator)
+
+	// GPSAltitudeRef is 1 for below sea level
+	if ref, err := exifData.Get(exif.GPSAltitudeRef); err == nil {
+		if value, err := ref.Int(0); err == nil && value == 1 {
+			altitude = -altitude
 		}
-		app.sourceFolder = uri.Path()
-		app.sourceFolderLabel.SetText(app.sourceFolder)
-		app.safeLog(fmt.Sprintf("Source folder selected: %s\n", app.sourceFolder))
-	}, app.window)
+	}
+	return altitude, true
 }
 
-func (app *App) selectOutputFolder() {
-	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
-		if err != nil || uri == nil {
-			return
+// geoJSONFeatureCollection is the subset of GeoJSON needed to map clusters
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // Longitude, latitude
+}
+
+// writeClustersGeoJSON writes one point per located cluster, with its name, file count
+// and mean elevation when known
+func writeClustersGeoJSON(path string, clusters []LocationCluster) error {
+	collection := geoJSONFeatureCollection{Type: "FeatureCollection", Features: []geoJSONFeature{}}
+	for _, cluster := range clusters {
+		if !cluster.HasLocation {
+			continue
 		}
-		app.outputFolder = uri.Path()
-		app.outputFolderLabel.SetText(app.outputFolder)
-		app.safeLog(fmt.Sprintf("Output folder selected: %s\n", app.outputFolder))
-	}, app.window)
+		properties := map[string]interface{}{
+			"name":  cluster.Name,
+			"count": len(cluster.Images),
+		}
+		if cluster.HasElevation {
+			properties["elevation"] = math.Round(cluster.Elevation*10) / 10
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONPoint{Type: "Point", Coordinates: [2]float64{cluster.CenterLng, cluster.CenterLat}},
+			Properties: properties,
+		})
+	}
+
+	data, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
 }
 
-func (app *App) startOrganizing() {
-	if app.sourceFolder == "" {
-		dialog.ShowError(fmt.Errorf("please select a source folder"), app.window)
-		return
+// formatLocation names a position with decimals decimal places, e.g. 48.857N_2.352E
+func formatLocation(lat, long float64, decimals int) string {
+	latDir := "N"
+	if lat < 0 {
+		latDir = "S"
+		lat = -lat
 	}
 
-	if app.outputFolder == "" {
-		dialog.ShowError(fmt.Errorf("please select an output folder"), app.window)
-		return
+	longDir := "E"
+	if long < 0 {
+		longDir = "W"
+		long = -long
 	}
 
-	app.progressBar.Show()
-	app.safeLog("Starting media organization...\n")
+	return fmt.Sprintf("%.*f%s_%.*f%s", decimals, lat, latDir, decimals, long, longDir)
+}
 
-	// Reset counters
-	app.counterMutex.Lock()
-	app.processedFiles = 0
-	app.totalFiles = 0
-	app.counterMutex.Unlock()
+// dateFolderSegment returns the date portion of a destination path for granularity.
+// Date-only mode nests by year so the tree stays navigable.
+func (org *Organizer) dateFolderSegment(date time.Time, granularity string) string {
+	nested := org.organizeMode == ModeDateOnly || org.flattenByDate
+
+	switch granularity {
+	case GranularityWeek:
+		// ISO week numbering, so the year is the ISO year the week belongs to
+		year, week := date.ISOWeek()
+		if nested {
+			return filepath.Join(strconv.Itoa(year), fmt.Sprintf("W%02d", week))
+		}
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case GranularityMonth:
+		if nested {
+			return filepath.Join(date.Format("2006"), date.Format("01"))
+		}
+		return date.Format("2006-01")
+	case GranularityYear:
+		return date.Format("2006")
+	default:
+		if nested {
+			return filepath.Join(date.Format("2006"), date.Format("01"), date.Format("02"))
+		}
+		// Format as month-day-year for better sorting and no intermediate year folders
+		return date.Format("01-02-2006")
+	}
+}
 
-	// Initialize spatial grid with current sensitivity
-	app.spatialGrid = NewSpatialGrid(app.locationSensitivity)
-	
-	// Start UI update timer
-	app.startUIUpdateTimer()
+// deviceFolderSegment returns the camera model folder name when separating by device,
+// or an empty segment (which filepath.Join drops) otherwise
+func (org *Organizer) deviceFolderSegment(info *ImageInfo) string {
+	if !org.separateByDevice {
+		return ""
+	}
 
-	// Run organization in a goroutine to prevent UI blocking
-	go app.organizeImages()
+	device := info.CameraModel
+	if device == "" {
+		device = info.CameraMake
+	}
+	if device == "" {
+		return "Unknown-Device"
+	}
+
+	// Keep the model name safe as a single path segment
+	return sanitizePathSegment(device)
 }
 
-// startUIUpdateTimer starts a timer for periodic UI updates
-func (app *App) startUIUpdateTimer() {
-	app.logUpdateTimer = time.NewTicker(UIUpdateInterval)
-	go func() {
-		for range app.logUpdateTimer.C {
-			app.updateUIFromBuffer()
+// elevationFolderSegment returns the elevation folder info is placed in, named after
+// the lower bound of its elevation band (DefaultElevationFolderBand unless clusters are
+// banded), or "" when elevation folders are off or info has no elevation
+func (org *Organizer) elevationFolderSegment(info *ImageInfo) string {
+	if !org.elevationFolders || !info.HasElevation {
+		return ""
+	}
+
+	band := org.elevationBand
+	if band <= 0 {
+		band = DefaultElevationFolderBand
+	}
+	return fmt.Sprintf("%.0fm", math.Floor(info.Elevation/band)*band)
+}
+
+// filenameTemplateToken matches {token} placeholders in filename templates
+var filenameTemplateToken = regexp.MustCompile(`\{([a-z-]+)\}`)
+
+// expandFilenameTemplate substitutes {token} placeholders with values, leaving unknown tokens as-is
+func expandFilenameTemplate(template string, values map[string]string) string {
+	return filenameTemplateToken.ReplaceAllStringFunc(template, func(token string) string {
+		if value, ok := values[token[1:len(token)-1]]; ok {
+			return value
 		}
-	}()
+		return token
+	})
 }
 
-// stopUIUpdateTimer stops the UI update timer
-func (app *App) stopUIUpdateTimer() {
-	if app.logUpdateTimer != nil {
-		app.logUpdateTimer.Stop()
-		app.logUpdateTimer = nil
+// validateFilenameTemplate checks that template is non-empty and only uses allowed tokens
+func validateFilenameTemplate(template string, allowed []string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("template is empty")
+	}
+	for _, match := range filenameTemplateToken.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, token := range allowed {
+			if match[1] == token {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown token %s (allowed: {%s})", match[0], strings.Join(allowed, "}, {"))
+		}
 	}
+	return nil
 }
 
-// updateUIFromBuffer updates the UI with buffered log content
-func (app *App) updateUIFromBuffer() {
-	lines := app.logBuffer.GetLines()
-	content := strings.Join(lines, "")
-	
-	// Update UI on main thread
-	app.logText.SetText(content)
-	
-	// Update progress bar
-	app.counterMutex.RLock()
-	if app.totalFiles > 0 {
-		progress := float64(app.processedFiles) / float64(app.totalFiles)
-		app.progressBar.SetValue(progress)
+// destinationFilename returns the name info is copied under: renamed with the template
+// when enabled, otherwise the original name, with the location added to flattened
+// files when that annotation is selected. sequence numbers files within their folder.
+func (org *Organizer) destinationFilename(info *ImageInfo, location string, sequence int) string {
+	// Legal on the source's filesystem doesn't mean legal on the output's
+	filename := sanitizeFilename(filepath.Base(info.OriginalPath))
+	ext := filepath.Ext(filename)
+
+	if org.renameOnCopy {
+		if location == "" {
+			location = NoLocationClusterName
+		}
+		name := expandFilenameTemplate(org.renameTemplate, map[string]string{
+			"date":          info.Date.Format("2006-01-02"),
+			"time":          info.Date.Format("150405"),
+			"original-name": strings.TrimSuffix(filename, ext),
+			"sequence":      fmt.Sprintf("%04d", sequence),
+			"location":      location,
+			"elevation":     elevationToken(info),
+		})
+		return sanitizePathSegment(name) + ext
 	}
-	app.counterMutex.RUnlock()
+
+	if !org.flattenByDate || org.locationAnnotation != AnnotateFilename || location == "" {
+		return filename
+	}
+
+	format := org.annotationFormat
+	if format == "" {
+		format = DefaultAnnotationFormat
+	}
+
+	name := expandFilenameTemplate(format, map[string]string{
+		"name":     strings.TrimSuffix(filename, ext),
+		"location": location,
+	})
+	return sanitizePathSegment(name) + ext
 }
 
-// safeLog adds a log message using buffered logging
-func (app *App) safeLog(message string) {
-	timestamp := time.Now().Format("15:04:05")
-	app.logBuffer.Add(fmt.Sprintf("[%s] %s", timestamp, message))
+// elevationToken renders a file's elevation for filename templates, e.g. "1234m", or
+// an empty string when it has none
+func elevationToken(info *ImageInfo) string {
+	if !info.HasElevation {
+		return ""
+	}
+	return fmt.Sprintf("%.0fm", info.Elevation)
 }
 
-// incrementProcessedFiles thread-safely increments the processed file counter
-func (app *App) incrementProcessedFiles() {
-	app.counterMutex.Lock()
-	app.processedFiles++
-	app.counterMutex.Unlock()
+// writeLocationSidecar writes an XMP sidecar next to destPath carrying the file's GPS position
+func writeLocationSidecar(destPath string, info *ImageInfo) error {
+	sidecar := fmt.Sprintf(`<?xpacket begin="\ufeff" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description rdf:about="" xmlns:exif="http://ns.adobe.com/exif/1.0/">
+   <exif:GPSLatitude>%s</exif:GPSLatitude>
+   <exif:GPSLongitude>%s</exif:GPSLongitude>%s
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>
+`, xmpCoordinate(info.Latitude, "N", "S"), xmpCoordinate(info.Longitude, "E", "W"), xmpAltitude(info))
+
+	return os.WriteFile(destPath+".xmp", []byte(sidecar), 0644)
 }
 
-func (app *App) organizeImages() {
-	defer func() {
-		app.stopUIUpdateTimer()
-		app.updateUIFromBuffer() // Final update
-		
-		// Clean up worker pool
-		if app.globalWorkerPool != nil {
-			app.globalWorkerPool.Close()
-			app.globalWorkerPool.Wait()
-			app.globalWorkerPool = nil
-		}
-		
-		// Hide progress bar after a delay
-		time.AfterFunc(2*time.Second, func() {
-			app.progressBar.Hide()
-		})
-	}()
+// xmpAltitude returns the XMP altitude elements for info, or nothing when it has no elevation
+func xmpAltitude(info *ImageInfo) string {
+	if !info.HasElevation {
+		return ""
+	}
+	ref := 0
+	if info.Elevation < 0 {
+		ref = 1 // Below sea level
+	}
+	return fmt.Sprintf("\n   <exif:GPSAltitude>%d/100</exif:GPSAltitude>\n   <exif:GPSAltitudeRef>%d</exif:GPSAltitudeRef>",
+		int64(math.Round(math.Abs(info.Elevation)*100)), ref)
+}
 
-	// Find all media files
-	mediaFiles, err := app.findMediaFiles(app.sourceFolder)
-	if err != nil {
-		app.safeLog(fmt.Sprintf("Error finding media files: %v\n", err))
-		app.progressBar.Hide()
-		return
+// xmpCoordinate formats a decimal coordinate as XMP's "DDD,MM.mmmmmmK"
+func xmpCoordinate(value float64, positive, negative string) string {
+	ref := positive
+	if value < 0 {
+		ref = negative
+		value = -value
+	}
+	degrees := math.Floor(value)
+	minutes := (value - degrees) * 60
+	return fmt.Sprintf("%d,%.6f%s", int(degrees), minutes, ref)
+}
+
+// destinationFolder returns the folder info is organized into, with date folders of
+// the given granularity
+func (org *Organizer) destinationFolder(baseFolder string, info *ImageInfo, granularity string) string {
+	switch {
+	case org.unsortedUnreadable && info.ReadProblem != "":
+		// Folder structure: one flat folder to inspect by hand
+		return filepath.Join(baseFolder, UnsortedFolder)
+	case info.Undated:
+		// Folder structure: one flat folder to date by hand
+		return filepath.Join(baseFolder, UndatedFolder)
+	case info.CheckDate:
+		// Folder structure: one flat folder to correct the dates of by hand
+		return filepath.Join(baseFolder, CheckDateFolder)
+	case info.BurstReject:
+		// Folder structure: one flat folder of frames to look through or delete
+		return filepath.Join(baseFolder, RejectsFolder)
+	case org.flattenByDate:
+		// Folder structure: year/month/day (or coarser), with no location or device levels
+		return filepath.Join(baseFolder, org.dateFolderSegment(info.Date, granularity))
+	case org.organizeMode == ModeDateOnly:
+		// Folder structure: year/month/day (or coarser)
+		return filepath.Join(baseFolder, org.deviceFolderSegment(info), org.dateFolderSegment(info.Date, granularity))
+	case info.Location == NoLocationClusterName && org.noGPSPolicy == NoGPSDateOnly:
+		// Folder structure: month-day-year (or coarser) directly, with no location folder
+		return filepath.Join(baseFolder, org.deviceFolderSegment(info), org.dateFolderSegment(info.Date, granularity))
+	case org.organizeMode == ModeLocationOnly:
+		// Folder structure: location (name collisions across dates are resolved by copyFile)
+		return filepath.Join(baseFolder, info.Location, org.elevationFolderSegment(info), org.deviceFolderSegment(info))
+	default:
+		// Folder structure: location/month-day-year (or coarser)
+		return filepath.Join(baseFolder, info.Location, org.elevationFolderSegment(info), org.deviceFolderSegment(info),
+			org.dateFolderSegment(info.Date, granularity))
 	}
+}
 
-	// Set total files for progress tracking
-	app.counterMutex.Lock()
-	app.totalFiles = int64(len(mediaFiles))
-	app.counterMutex.Unlock()
+// setAside reports whether info goes to UnsortedFolder, UndatedFolder, CheckDateFolder or
+// RejectsFolder rather than being organized
+func (org *Organizer) setAside(info *ImageInfo) bool {
+	return (org.unsortedUnreadable && info.ReadProblem != "") || info.Undated || info.CheckDate || info.BurstReject
+}
 
-	app.safeLog(fmt.Sprintf("Found %d media files\n", len(mediaFiles)))
-	app.safeLog(fmt.Sprintf("Using %d worker threads and batch size of %d for processing\n", app.workerCount, app.batchSize))
+// sourceRelativeDir returns the folder of path relative to the source folder, or "" for
+// files at its top level
+func (org *Organizer) sourceRelativeDir(path string) string {
+	rel, err := filepath.Rel(org.sourceFolder, filepath.Dir(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return rel
+}
 
-	// Create global worker pool for reuse across batches
-	app.globalWorkerPool = NewWorkerPool(app.workerCount, app.batchSize*2)
-	app.globalWorkerPool.Start(app)
+// coarserGranularity returns the bucket sparse date folders collapse into, or "" when
+// granularity is already the coarsest
+func coarserGranularity(granularity string) string {
+	switch granularity {
+	case GranularityYear:
+		return ""
+	case GranularityMonth:
+		return GranularityYear
+	default:
+		return GranularityMonth
+	}
+}
 
-	totalFiles := len(mediaFiles)
+// sparseDateFolders returns the date folders that would hold fewer than
+// sparseDateThreshold files, which are collapsed into a coarser bucket. Counting
+// across every cluster keeps the decision stable when clusters share folders.
+func (org *Organizer) sparseDateFolders(clusterInfos [][]*ImageInfo) map[string]bool {
+	if !org.collapseSparseDates || coarserGranularity(org.dateGranularity) == "" || org.organizeMode == ModeLocationOnly {
+		return nil
+	}
 
-	// Process files in batches to manage memory usage
-	for batchStart := 0; batchStart < totalFiles; batchStart += app.batchSize {
-		batchEnd := batchStart + app.batchSize
-		if batchEnd > totalFiles {
-			batchEnd = totalFiles
+	counts := make(map[string]int)
+	for _, infos := range clusterInfos {
+		for _, info := range infos {
+			counts[org.destinationFolder(org.outputFolder, info, org.dateGranularity)]++
 		}
+	}
 
-		app.safeLog(fmt.Sprintf("Processing batch %d-%d of %d files...\n", batchStart+1, batchEnd, totalFiles))
-
-		// Process current batch
-		batchFiles := mediaFiles[batchStart:batchEnd]
-		batchImageInfos := app.processFilesWithPool(batchFiles)
+	sparse := make(map[string]bool)
+	for folder, count := range counts {
+		if count < org.sparseDateThreshold {
+			sparse[folder] = true
+		}
+	}
+	return sparse
+}
 
-		// Add to spatial grid for efficient clustering
-		for _, info := range batchImageInfos {
-			if info != nil {
-				app.spatialGrid.AddImage(info)
+// markUndatedFiles flags the files whose only date is an implausible modification time
+// (see UndatedFolder), judged against the properly dated files in the same source
+// folder, and returns how many it flagged
+func markUndatedFiles(clusterInfos [][]*ImageInfo, now time.Time) int {
+	newestNeighbor := make(map[string]time.Time)
+	for _, infos := range clusterInfos {
+		for _, info := range infos {
+			if info.DateSource == DateSourceMetadata || info.DateSource == DateSourceFilename {
+				dir := filepath.Dir(info.OriginalPath)
+				if newest, ok := newestNeighbor[dir]; !ok || info.Date.After(newest) {
+					newestNeighbor[dir] = info.Date
+				}
 			}
 		}
+	}
 
-		app.safeLog(fmt.Sprintf("Batch %d-%d processed and clustered\n", batchStart+1, batchEnd))
+	undated := 0
+	for _, infos := range clusterInfos {
+		for _, info := range infos {
+			if info.DateSource != DateSourceModTime && info.DateSource != "" {
+				continue
+			}
+			if newest, ok := newestNeighbor[filepath.Dir(info.OriginalPath)]; ok {
+				info.Undated = info.Date.After(newest.Add(UndatedNeighborGap))
+			} else {
+				info.Undated = now.Sub(info.Date) < UndatedRecentAge
+			}
+			if info.Undated {
+				undated++
+			}
+		}
+	}
+	return undated
+}
 
-		// Clear batch from memory (explicit cleanup)
-		batchImageInfos = nil
-		runtime.GC() // Force garbage collection for large datasets
+// createFolderStructure creates the destination folder for info, collapsing it into
+// a coarser date bucket when it is one of the sparse folders, and recreating its source
+// subfolders below that when they are kept
+func (org *Organizer) createFolderStructure(baseFolder string, info *ImageInfo, sparse map[string]bool) string {
+	folderPath := org.plannedFolder(baseFolder, info, sparse)
+	if err := org.makeFolder(folderPath); err != nil {
+		log.Printf("Warning: Could not create directory %s: %v", folderPath, pathLengthError(err, folderPath))
+		return baseFolder
 	}
 
-	// Get final clusters from spatial grid
-	finalClusters := app.spatialGrid.GetClusters(app)
-	app.safeLog(fmt.Sprintf("Clustering complete. Total location clusters: %d\n", len(finalClusters)))
+	return folderPath
+}
+
+// plannedFolder returns the folder below baseFolder that info is placed in, without
+// creating it
+func (org *Organizer) plannedFolder(baseFolder string, info *ImageInfo, sparse map[string]bool) string {
+	folderPath := org.destinationFolder(baseFolder, info, org.dateGranularity)
+	if sparse[folderPath] {
+		folderPath = org.destinationFolder(baseFolder, info, coarserGranularity(org.dateGranularity))
+	}
 
-	// Copy files based on clusters
-	app.safeLog("Starting file organization...\n")
-	app.organizeByLocationClusters(finalClusters)
+	// Files set aside to sort by hand stay in one flat folder
+	if org.keepSourceFolders && info.SourceDir != "" && !org.setAside(info) {
+		for _, segment := range strings.Split(info.SourceDir, string(filepath.Separator)) {
+			folderPath = filepath.Join(folderPath, sanitizePathSegment(segment))
+		}
+	}
+	return folderPath
+}
 
-	app.safeLog(fmt.Sprintf("Organization complete! Processed %d media files into %d location clusters.\n", totalFiles, len(finalClusters)))
+// uniqueDestPath returns a path in destDir for filename with a _N suffix that doesn't exist yet
+func uniqueDestPath(destDir, filename string) string {
+	ext := filepath.Ext(filename)
+	name := strings.TrimSuffix(filename, ext)
 
-	// Open file explorer to output folder
-	app.openFileExplorer(app.outputFolder)
-	
-	// Clean up spatial grid
-	app.spatialGrid.Clear()
+	for counter := 1; ; counter++ {
+		destPath := filepath.Join(destDir, fmt.Sprintf("%s_%d%s", name, counter, ext))
+		ioPath, _ := longPath(destPath)
+		if _, err := os.Stat(ioPath); os.IsNotExist(err) {
+			return destPath
+		}
+	}
 }
 
-// processFilesWithPool processes media files using the global worker pool
-func (app *App) processFilesWithPool(mediaFiles []string) []*ImageInfo {
-	if len(mediaFiles) == 0 {
-		return nil
+// PartialFileSuffix ends the name of a copy while it is being written
+const PartialFileSuffix = ".part"
+
+// errConflictSkipped is returned by copyFile when the conflict policy keeps the existing file
+var errConflictSkipped = errors.New("destination exists, kept existing file")
+
+// copyFile copies the file of info into destDir as filename, resolving name collisions
+// with the conflict policy, and returns the final destination path and the bytes written
+func (org *Organizer) copyFile(info *ImageInfo, destDir, filename string) (string, int64, error) {
+	src := info.OriginalPath
+	destPath := filepath.Join(destDir, filename)
+
+	// Check if destination already exists
+	ioPath, _ := longPath(destPath)
+	if existing, err := os.Stat(ioPath); err == nil {
+		switch org.conflictPolicy {
+		case ConflictSkip:
+			org.safeLog(fmt.Sprintf("Conflict for %s: skipping, destination already exists\n", filename))
+			return destPath, 0, errConflictSkipped
+		case ConflictOverwrite:
+			org.safeLog(fmt.Sprintf("Conflict for %s: overwriting existing file\n", filename))
+		case ConflictKeepNewest:
+			source, err := org.statSource(src)
+			if err != nil {
+				return destPath, 0, err
+			}
+			if !source.ModTime().After(existing.ModTime()) {
+				org.safeLog(fmt.Sprintf("Conflict for %s: keeping existing file (same age or newer)\n", filename))
+				return destPath, 0, errConflictSkipped
+			}
+			org.safeLog(fmt.Sprintf("Conflict for %s: overwriting older existing file\n", filename))
+		case ConflictKeepLatest:
+			if !org.capturedAfter(info, destPath) {
+				return destPath, 0, errConflictSkipped
+			}
+		default:
+			destPath = uniqueDestPath(destDir, filename)
+			org.safeLog(fmt.Sprintf("Conflict for %s: renaming to %s\n", filename, filepath.Base(destPath)))
+		}
+	}
+
+	// Deep trees with long names can pass Windows' MAX_PATH, so every file operation
+	// goes through the extended-length form of the path; destPath stays readable for
+	// the log and manifest
+	ioPath, extended := longPath(destPath)
+	if extended {
+		org.runStats.AddLongPath()
 	}
 
-	// Submit jobs to global worker pool
-	for _, mediaFile := range mediaFiles {
-		app.globalWorkerPool.Submit(mediaFile)
+	// Files inside an archive can only be copied out of it
+	if _, archived := org.archiveEntry(src); !archived && org.copyMode != CopyModeCopy && org.copyMode != "" {
+		if method, ok := org.linkFile(src, ioPath); ok {
+			org.safeLog(fmt.Sprintf("%s %s\n", method, filepath.Base(destPath)))
+			org.runStats.AddLinked()
+			return destPath, 0, nil
+		}
 	}
 
-	// Collect results
-	var imageInfos []*ImageInfo
-	var errorCount int
+	written, err := org.writeCopy(src, ioPath)
+	if err != nil {
+		return destPath, written, pathLengthError(err, destPath)
+	}
+	return destPath, written, nil
+}
 
-	for i := 0; i < len(mediaFiles); i++ {
-		result := <-app.globalWorkerPool.Results
-		app.incrementProcessedFiles()
+// writeCopy copies src to destPath through a PartialFileSuffix file beside it, renamed
+// into place only once complete, so the output never holds a half-written file under a
+// final name. On failure the partial file is removed.
+func (org *Organizer) writeCopy(src, destPath string) (int64, error) {
+	sourceFile, err := org.openSource(src)
+	if err != nil {
+		return 0, err
+	}
+	defer sourceFile.Close()
 
-		if result.Error != nil {
-			errorCount++
-			app.safeLog(fmt.Sprintf("Warning: Could not extract info from %s: %v\n",
-				filepath.Base(result.Info.OriginalPath), result.Error))
-		} else {
-			imageInfos = append(imageInfos, result.Info)
+	partFile, err := os.CreateTemp(filepath.Dir(destPath), filepath.Base(destPath)+".*"+PartialFileSuffix)
+	if err != nil {
+		return 0, err
+	}
+	partPath := partFile.Name()
+	fail := func(err error) error {
+		partFile.Close()
+		os.Remove(partPath)
+		return err
+	}
+
+	buffer := make([]byte, 64*1024)
+	written, err := io.CopyBuffer(partFile, countingReader{sourceFile, &org.copyBytesDone}, buffer)
+	if err != nil {
+		return written, fail(err)
+	}
+	if org.syncCopies {
+		if err := partFile.Sync(); err != nil {
+			return written, fail(err)
 		}
 	}
+	// Temporary files are private to their owner; copies get the usual permissions
+	if err := partFile.Chmod(0644); err != nil {
+		return written, fail(err)
+	}
+	if err := partFile.Close(); err != nil {
+		return written, fail(err)
+	}
 
-	if errorCount > 0 {
-		app.safeLog(fmt.Sprintf("Batch completed with %d errors\n", errorCount))
+	// Carry the source modification time over so keep-newest compares like with like
+	if sourceInfo, err := org.statSource(src); err == nil {
+		if err := os.Chtimes(partPath, sourceInfo.ModTime(), sourceInfo.ModTime()); err != nil {
+			org.safeLog(fmt.Sprintf("Warning: Could not keep the modification time of %s: %v\n", filepath.Base(src), err))
+		}
 	}
 
-	return imageInfos
+	// Replaces an existing destination the conflict policy chose to overwrite
+	if err := os.Rename(partPath, destPath); err != nil {
+		os.Remove(partPath)
+		return written, err
+	}
+	return written, nil
 }
 
+// capturedAfter reports whether info was captured after the existing file at destPath,
+// logging the comparison. Only capture dates are compared, from metadata or a filename
+// timestamp: modification times change whenever a file is copied or edited, so when
+// either file has no capture date the existing file is kept.
+func (org *Organizer) capturedAfter(info *ImageInfo, destPath string) bool {
+	filename := filepath.Base(destPath)
+	if !hasCaptureDate(info) {
+		org.safeLog(fmt.Sprintf("Conflict for %s: keeping existing file (incoming file has no capture date)\n", filename))
+		return false
+	}
+	existing, err := org.extractImageInfo(destPath)
+	if err != nil || !hasCaptureDate(existing) {
+		org.safeLog(fmt.Sprintf("Conflict for %s: keeping existing file (it has no capture date to compare)\n", filename))
+		return false
+	}
 
-func (app *App) findMediaFiles(root string) ([]string, error) {
-	var mediaFiles []string
-	imageExts := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".tiff": true,
-		".tif":  true,
-		".bmp":  true,
-		".gif":  true,
-		".heic": true, // iPhone HEVC images
-		".heif": true, // HEIF images
-		".avif": true, // AV1 Image File Format
-		".webp": true, // WebP format
-		".dng":  true, // Digital Negative (RAW)
-		".cr2":  true, // Canon RAW
-		".nef":  true, // Nikon RAW
-		".arw":  true, // Sony RAW
-		".mov":  true, // QuickTime Movie
-		".mp4":  true, // MPEG-4 Video
-		".m4v":  true, // iTunes Video
-		".avi":  true, // Audio Video Interleave
-		".mkv":  true, // Matroska Video
-		".wmv":  true, // Windows Media Video
-		".webm": true, // WebM Video
+	const layout = "2006-01-02 15:04:05"
+	if !info.Date.After(existing.Date) {
+		org.safeLog(fmt.Sprintf("Conflict for %s: keeping existing file captured %s (incoming captured %s)\n",
+			filename, existing.Date.Format(layout), info.Date.Format(layout)))
+		return false
 	}
+	org.safeLog(fmt.Sprintf("Conflict for %s: overwriting existing file captured %s with one captured later, %s\n",
+		filename, existing.Date.Format(layout), info.Date.Format(layout)))
+	return true
+}
 
-	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// hasCaptureDate reports whether info is dated by when it was taken rather than by its
+// modification time or the time of the run
+func hasCaptureDate(info *ImageInfo) bool {
+	return info.DateSource == DateSourceMetadata || info.DateSource == DateSourceFilename
+}
+
+// linkFile places src at destPath without copying its data, as a clone or hard link
+// depending on the copy mode. It returns how the file was placed, or false when the
+// caller should copy it.
+func (org *Organizer) linkFile(src, destPath string) (string, bool) {
+	// The conflict policy chose to replace an existing destination, but neither
+	// clones nor links can be made over one. Writing through it could also change
+	// src, if it's a hard link left by an earlier run.
+	if _, err := os.Lstat(destPath); err == nil {
+		if err := os.Remove(destPath); err != nil {
+			return "", false
 		}
+	}
 
-		if !info.IsDir() {
-			ext := strings.ToLower(filepath.Ext(path))
-			if imageExts[ext] {
-				mediaFiles = append(mediaFiles, path)
-			}
+	if org.copyMode == CopyModeClone {
+		if err := cloneFile(src, destPath); err == nil {
+			return "Cloned", true
 		}
-		return nil
-	})
+	}
+	if err := os.Link(src, destPath); err == nil {
+		return "Hard-linked", true
+	}
+	return "", false
+}
 
-	return mediaFiles, err
+// ExifToolMetadata holds the GPS and date fields read from a single exiftool invocation
+type ExifToolMetadata struct {
+	Latitude  float64
+	Longitude float64
+	HasGPS    bool
+	Altitude  float64 // Meters above sea level, when HasAltitude is set
+	Date      time.Time
+	Make      string
+	Model     string
+	// DateHasZone is set when the date carried an explicit UTC offset
+	DateHasZone bool
+	HasAltitude bool
+	// GPSError is the horizontal error of the position in meters, when HasGPSError is set
+	GPSError    float64
+	HasGPSError bool
 }
 
-// extractDateFromFilename attempts to extract a timestamp from the filename
-// Supports various common timestamp formats found in media filenames
-func (app *App) extractDateFromFilename(filename string) (time.Time, bool) {
-	// Remove extension for cleaner parsing
-	basename := strings.TrimSuffix(filename, filepath.Ext(filename))
+// exiftoolDateArgs and exiftoolGPSArgs request every tag we need from exiftool in one
+// call, with GPS coordinates in decimal form (-n)
+var (
+	exiftoolDateArgs = []string{"-DateTimeOriginal", "-SubSecTimeOriginal", "-CreateDate", "-MediaCreateDate", "-CreationDate", "-Make", "-Model", "-n"}
+	exiftoolGPSArgs  = []string{"-GPSLatitude", "-GPSLongitude", "-GPSLatitudeRef", "-GPSLongitudeRef", "-GPSAltitude", "-GPSAltitudeRef", "-GPSHPositioningError", "-GPSDOP", "-GPSCoordinates", "-LocationISO6709"}
+)
 
-	// Common timestamp patterns found in media filenames
-	patterns := []struct {
-		regex  *regexp.Regexp
-		layout string
-	}{
-		// iPhone format: IMG_20240315_143022.heic
-		{regexp.MustCompile(`IMG_(\d{8})_(\d{6})`), "20060102_150405"},
-		// Android format: 20240315_143022.jpg
-		{regexp.MustCompile(`(\d{8})_(\d{6})`), "20060102_150405"},
-		// Screenshot format: Screenshot_20240315-143022.png
-		{regexp.MustCompile(`Screenshot_(\d{8})-(\d{6})`), "20060102-150405"},
-		// WhatsApp format: WhatsApp Image 2024-03-15 at 14.30.22.jpeg
-		{regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2}) at (\d{2})\.(\d{2})\.(\d{2})`), "2006-01-02 at 15.04.05"},
-		// Generic YYYYMMDD format: 20240315.jpg
-		{regexp.MustCompile(`(\d{8})`), "20060102"},
-		// ISO format: 2024-03-15T14-30-22.jpg
-		{regexp.MustCompile(`(\d{4})-(\d{2})-(\d{2})T(\d{2})-(\d{2})-(\d{2})`), "2006-01-02T15-04-05"},
-		// Timestamp format: 1710508222.jpg (Unix timestamp)
-		{regexp.MustCompile(`^(\d{10})$`), "unix"},
-	}
-
-	for _, pattern := range patterns {
-		if pattern.regex.MatchString(basename) {
-			matches := pattern.regex.FindStringSubmatch(basename)
-			if len(matches) > 1 {
-				if pattern.layout == "unix" {
-					// Handle Unix timestamp
-					if timestamp, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
-						return time.Unix(timestamp, 0), true
-					}
-				} else {
-					// Handle formatted date strings
-					dateStr := strings.Join(matches[1:], "")
-					if pattern.layout == "2006-01-02 at 15.04.05" {
-						// Special case for WhatsApp format
-						dateStr = fmt.Sprintf("%s-%s-%s at %s.%s.%s", matches[1], matches[2], matches[3], matches[4], matches[5], matches[6])
-					} else if pattern.layout == "2006-01-02T15-04-05" {
-						// Special case for ISO format
-						dateStr = fmt.Sprintf("%s-%s-%sT%s-%s-%s", matches[1], matches[2], matches[3], matches[4], matches[5], matches[6])
-					} else if strings.Contains(pattern.layout, "_") || strings.Contains(pattern.layout, "-") {
-						// Reconstruct with separator
-						separator := "_"
-						if strings.Contains(pattern.layout, "-") {
-							separator = "-"
-						}
-						if len(matches) >= 3 {
-							dateStr = matches[1] + separator + matches[2]
-						}
-					}
+// exiftoolDateFields lists exiftool date fields in order of preference
+var exiftoolDateFields = []string{
+	"Date/Time Original",
+	"Create Date",
+	"Media Create Date",
+	"Creation Date",
+}
 
-					if parsedTime, err := time.Parse(pattern.layout, dateStr); err == nil {
-						return parsedTime, true
-					}
-				}
-			}
+// extractMetadataWithExifTool reads date (and optionally GPS) metadata from a media file
+// with a single exiftool call
+func (org *Organizer) extractMetadataWithExifTool(mediaPath string, includeGPS bool) (ExifToolMetadata, bool) {
+	// Use the configured exiftool path (either system or embedded)
+	if exiftoolPath == "" {
+		return ExifToolMetadata{}, false
+	}
+
+	args := append([]string{}, exiftoolDateArgs...)
+	if includeGPS {
+		args = append(args, exiftoolGPSArgs...)
+	}
+	args = append(args, mediaPath)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(exiftoolPath, args...)
+	cmd.Stderr = &stderr
+
+	org.acquireExifTool()
+	output, err := cmd.Output()
+	org.releaseExifTool()
+	if err != nil {
+		// A non-zero exit means exiftool ran but couldn't read this file
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			reason := exifToolFailureReason(stderr.String(), mediaPath, exitErr)
+			org.runStats.RecordProblem(mediaPath, reason)
+			org.safeLog(fmt.Sprintf("Warning: exiftool could not read %s: %s\n", filepath.Base(mediaPath), reason))
 		}
+		return ExifToolMetadata{}, false
 	}
 
-	return time.Time{}, false
+	metadata := parseExifToolOutput(string(output))
+	if metadata.HasGPS {
+		org.safeLog(fmt.Sprintf("Successfully extracted GPS from %s: lat=%.6f, lng=%.6f\n",
+			filepath.Base(mediaPath), metadata.Latitude, metadata.Longitude))
+	}
+
+	return metadata, true
 }
 
-func (app *App) extractImageInfo(imagePath string) (*ImageInfo, error) {
-	file, err := os.Open(imagePath)
-	if err != nil {
-		return nil, err
+// exifToolFailureReason extracts exiftool's own explanation from its stderr, e.g.
+// "Error: File format error - clip.mov" becomes "File format error"
+func exifToolFailureReason(stderr, mediaPath string, exitErr *exec.ExitError) string {
+	for _, line := range strings.Split(stderr, "\n") {
+		if reason, found := strings.CutPrefix(strings.TrimSpace(line), "Error: "); found {
+			return strings.TrimSuffix(reason, " - "+mediaPath)
+		}
 	}
-	defer file.Close()
+	return fmt.Sprintf("exiftool exited with status %d", exitErr.ExitCode())
+}
+
+// dmsCoordinatePattern matches exiftool's human-readable coordinates, e.g. 12 deg 34' 56.78" N
+var dmsCoordinatePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*deg\s*(\d+(?:\.\d+)?)'\s*(\d+(?:\.\d+)?)"\s*([NSEW])?$`)
 
-	info := &ImageInfo{
-		OriginalPath: imagePath,
-		Date:         time.Now(),
-		Location:     "Unknown",
-		HasGPS:       false,
+// acquireExifTool blocks until another exiftool process may be started
+func (org *Organizer) acquireExifTool() {
+	if org.exiftoolSemaphore != nil {
+		org.exiftoolSemaphore <- struct{}{}
 	}
+}
 
-	// Priority order for date extraction:
-	// 1. EXIF date (most accurate)
-	// 2. Filename timestamp (good fallback)
-	// 3. File modification time (last resort)
+// releaseExifTool frees a slot taken by acquireExifTool
+func (org *Organizer) releaseExifTool() {
+	if org.exiftoolSemaphore != nil {
+		<-org.exiftoolSemaphore
+	}
+}
 
-	// Get file info for ultimate fallback
-	fileInfo, err := os.Stat(imagePath)
-	if err == nil {
-		info.Date = fileInfo.ModTime()
+// copyMetadataWrite is the metadata written into one organized copy
+type copyMetadataWrite struct {
+	Path      string // Destination copy; source files are never written
+	Comment   string // Cluster name, stored as the user comment
+	Latitude  float64
+	Longitude float64
+	HasGPS    bool // Set for interpolated locations, which the file doesn't carry yet
+}
+
+// writeMetadataToCopies writes cluster names (and estimated GPS) into copied files with a
+// single exiftool run, using an argument file with one -execute section per file
+func (org *Organizer) writeMetadataToCopies(writes []copyMetadataWrite) {
+	if len(writes) == 0 {
+		return
+	}
+	if exiftoolPath == "" {
+		org.safeLog(fmt.Sprintf("Warning: ExifTool not found, not writing metadata into %d copies\n", len(writes)))
+		return
 	}
 
-	// Try to extract date from filename first (before EXIF for efficiency)
-	filename := filepath.Base(imagePath)
-	if filenameDate, found := app.extractDateFromFilename(filename); found {
-		info.Date = filenameDate
-		app.safeLog(fmt.Sprintf("Extracted date from filename: %s -> %s\n",
-			filepath.Base(imagePath), filenameDate.Format("2006-01-02 15:04:05")))
+	var args strings.Builder
+	for _, write := range writes {
+		args.WriteString("-overwrite_original\n")
+		fmt.Fprintf(&args, "-UserComment=%s\n", write.Comment)
+		description := fmt.Sprintf("comment %q", write.Comment)
+		if write.HasGPS {
+			latRef, lngRef := "N", "E"
+			if write.Latitude < 0 {
+				latRef = "S"
+			}
+			if write.Longitude < 0 {
+				lngRef = "W"
+			}
+			fmt.Fprintf(&args, "-GPSLatitude=%f\n-GPSLatitudeRef=%s\n", math.Abs(write.Latitude), latRef)
+			fmt.Fprintf(&args, "-GPSLongitude=%f\n-GPSLongitudeRef=%s\n", math.Abs(write.Longitude), lngRef)
+			description += fmt.Sprintf(", GPS %.6f,%.6f", write.Latitude, write.Longitude)
+		}
+		args.WriteString(write.Path + "\n")
+		args.WriteString("-execute\n")
+		org.safeLog(fmt.Sprintf("Writing metadata to %s: %s\n", filepath.Base(write.Path), description))
+	}
+
+	argsFile, err := os.CreateTemp("", "media-organizer-args-*.txt")
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not write metadata into copies: %v\n", err))
+		return
+	}
+	defer os.Remove(argsFile.Name())
+	_, err = argsFile.WriteString(args.String())
+	if closeErr := argsFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not write metadata into copies: %v\n", err))
+		return
 	}
 
-	// Check file extension to determine EXIF processing method
-	ext := strings.ToLower(filepath.Ext(imagePath))
+	org.acquireExifTool()
+	output, err := exec.Command(exiftoolPath, "-charset", "filename=utf8", "-@", argsFile.Name()).CombinedOutput()
+	org.releaseExifTool()
 
-	// Video formats - use ExifTool for metadata extraction
-	videoFormats := map[string]bool{
-		".mov": true, ".mp4": true, ".m4v": true, ".avi": true,
-		".mkv": true, ".wmv": true, ".flv": true, ".webm": true,
-		".3gp": true, ".mts": true, ".m2ts": true,
+	// exiftool keeps going after a failed file and reports it in its output
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "Error") || strings.HasPrefix(line, "Warning") {
+			org.safeLog(fmt.Sprintf("ExifTool: %s\n", strings.TrimSpace(line)))
+		}
 	}
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: ExifTool reported problems writing metadata into copies: %v\n", err))
+	}
+}
 
-	if videoFormats[ext] {
-		app.safeLog(fmt.Sprintf("Processing video file: %s\n", filepath.Base(imagePath)))
-
-		// For video files, try to extract GPS and date using exiftool
-		if lat, lng, hasGPS := app.extractHEICGPSWithExifTool(imagePath); hasGPS {
-			info.HasGPS = true
-			info.Latitude = lat
-			info.Longitude = lng
-			info.Location = app.formatLocation(lat, lng)
+// parseExifToolOutput parses exiftool's "Tag Name : value" output in a single pass
+func parseExifToolOutput(output string) ExifToolMetadata {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		// Split on the first ": " only, since values such as dates contain colons themselves
+		name, value, found := strings.Cut(line, ": ")
+		if !found {
+			continue
 		}
-
-		// Try to extract creation date from video metadata using exiftool
-		if videoDate := app.extractVideoDateWithExifTool(imagePath); !videoDate.IsZero() {
-			info.Date = videoDate
-			app.safeLog(fmt.Sprintf("Extracted video date: %s -> %s\n",
-				filepath.Base(imagePath), videoDate.Format("2006-01-02 15:04:05")))
+		name = strings.TrimSpace(name)
+		if _, exists := fields[name]; !exists {
+			fields[name] = strings.TrimSpace(value)
 		}
+	}
 
-		return info, nil
+	var metadata ExifToolMetadata
+
+	// EXIF models are printed as "Camera Model Name", QuickTime ones as "Model"
+	metadata.Make = fields["Make"]
+	metadata.Model = fields["Camera Model Name"]
+	if metadata.Model == "" {
+		metadata.Model = fields["Model"]
 	}
 
-	// For HEIC/HEIF files, EXIF extraction is limited
-	if ext == ".heic" || ext == ".heif" {
-		// For HEIC/HEIF, we rely on filename timestamp or file modification time
-		// since goexif has limited support for these formats
-		if !info.Date.Equal(fileInfo.ModTime()) {
-			app.safeLog(fmt.Sprintf("Processing HEIC/HEIF file: %s (using filename date)\n", filepath.Base(imagePath)))
-		} else {
-			app.safeLog(fmt.Sprintf("Processing HEIC/HEIF file: %s (using file date)\n", filepath.Base(imagePath)))
+	// GPS coordinates are normally decimal thanks to the -n flag, but DMS output is handled too
+	lat, latErr := parseExifToolCoordinate(fields["GPS Latitude"], fields["GPS Latitude Ref"])
+	lng, lngErr := parseExifToolCoordinate(fields["GPS Longitude"], fields["GPS Longitude Ref"])
+	if latErr == nil && lngErr == nil && lat != 0 && lng != 0 {
+		metadata.HasGPS = true
+		metadata.Latitude = lat
+		metadata.Longitude = lng
+		if altitude, err := strconv.ParseFloat(fields["GPS Altitude"], 64); err == nil {
+			// With -n the ref is printed as 0 (above) or 1 (below sea level)
+			if fields["GPS Altitude Ref"] == "1" && altitude > 0 {
+				altitude = -altitude
+			}
+			metadata.Altitude, metadata.HasAltitude = altitude, true
 		}
-
-		// Try to extract GPS data using exiftool as fallback
-		if lat, lng, hasGPS := app.extractHEICGPSWithExifTool(imagePath); hasGPS {
-			info.HasGPS = true
-			info.Latitude = lat
-			info.Longitude = lng
-			info.Location = app.formatLocation(lat, lng)
+		if gpsError, err := strconv.ParseFloat(fields["GPS Horizontal Positioning Error"], 64); err == nil {
+			metadata.GPSError, metadata.HasGPSError = gpsError, true
+		} else if dop, err := strconv.ParseFloat(fields["GPS Dilution Of Precision"], 64); err == nil {
+			metadata.GPSError, metadata.HasGPSError = dop*metersPerDOP, true
 		}
-
-		return info, nil
 	}
 
-	// Try to extract EXIF data for traditional formats
-	exifData, err := exif.Decode(file)
-	if err != nil {
-		// If no EXIF data, we already have filename or file modification time as fallback
-		return info, nil
+	// QuickTime videos (notably from iPhones) often only have a location atom:
+	// com.apple.quicktime.location.ISO6709 or the older user data "©xyz" string
+	if !metadata.HasGPS {
+		for _, field := range []string{"GPS Coordinates", "Location ISO6709"} {
+			if lat, lng, altitude, hasAltitude, err := parseGPSCoordinates(fields[field]); err == nil && lat != 0 && lng != 0 {
+				metadata.HasGPS = true
+				metadata.Latitude = lat
+				metadata.Longitude = lng
+				metadata.Altitude, metadata.HasAltitude = altitude, hasAltitude
+				break
+			}
+		}
 	}
 
-	// Extract date/time from EXIF (this overrides filename date as it's more accurate)
-	if dateTime, err := exifData.DateTime(); err == nil {
-		info.Date = dateTime
+	for _, field := range exiftoolDateFields {
+		if date, hasZone := parseExifToolDate(fields[field]); !date.IsZero() {
+			// EXIF keeps fractions of a second in a separate tag; QuickTime dates
+			// carry their own, which parseExifToolDate keeps
+			if field == "Date/Time Original" {
+				date = withSubSeconds(date, fields["Sub Sec Time Original"])
+			}
+			metadata.Date = date
+			metadata.DateHasZone = hasZone
+			break
+		}
 	}
 
-	// Extract GPS coordinates
-	if lat, long, err := exifData.LatLong(); err == nil {
-		info.HasGPS = true
-		info.Latitude = lat
-		info.Longitude = long
-		info.Location = app.formatLocation(lat, long)
+	return metadata
+}
+
+// parseExifToolCoordinate parses a coordinate printed by exiftool in either decimal
+// ("-37.7749") or degrees/minutes/seconds ("37 deg 46' 29.64\" S") form. The optional
+// ref ("N"/"S"/"E"/"W" or "North"/"South"/...) makes unsigned values negative when needed.
+func parseExifToolCoordinate(value, ref string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("empty coordinate")
 	}
 
-	return info, nil
-}
+	coordinate, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		matches := dmsCoordinatePattern.FindStringSubmatch(value)
+		if matches == nil {
+			return 0, fmt.Errorf("unrecognized coordinate %q", value)
+		}
 
-func (app *App) formatLocation(lat, long float64) string {
-	latDir := "N"
-	if lat < 0 {
-		latDir = "S"
-		lat = -lat
+		degrees, _ := strconv.ParseFloat(matches[1], 64)
+		minutes, _ := strconv.ParseFloat(matches[2], 64)
+		seconds, _ := strconv.ParseFloat(matches[3], 64)
+		coordinate = degrees + minutes/60 + seconds/3600
+
+		if matches[4] != "" {
+			ref = matches[4]
+		}
 	}
 
-	longDir := "E"
-	if long < 0 {
-		longDir = "W"
-		long = -long
+	ref = strings.ToUpper(strings.TrimSpace(ref))
+	if coordinate > 0 && (strings.HasPrefix(ref, "S") || strings.HasPrefix(ref, "W")) {
+		coordinate = -coordinate
 	}
 
-	return fmt.Sprintf("%.4f%s_%.4f%s", lat, latDir, long, longDir)
+	return coordinate, nil
 }
 
-func (app *App) createFolderStructure(baseFolder string, info *ImageInfo) string {
-	// Format as month-day-year for better sorting and no intermediate year folders
-	monthDayYear := info.Date.Format("01-02-2006")
-
-	// Folder structure: location/month-day-year
-	folderPath := filepath.Join(baseFolder, info.Location, monthDayYear)
-
-	if err := os.MkdirAll(folderPath, 0755); err != nil {
-		log.Printf("Warning: Could not create directory %s: %v", folderPath, err)
-		return baseFolder
+// iso6709Pattern matches an ISO 6709 point such as "+37.7749-122.4194+010.123/": signed
+// latitude, signed longitude, an optional signed altitude and an optional CRS suffix
+var iso6709Pattern = regexp.MustCompile(`^([+-]\d+(?:\.\d+)?)([+-]\d+(?:\.\d+)?)([+-]\d+(?:\.\d+)?)?(?:CRS[^/]*)?/?$`)
+
+// parseGPSCoordinates parses a combined coordinate value as printed by exiftool for
+// QuickTime location tags: either a raw ISO 6709 string or "lat lng [alt]" (decimal
+// with -n, or degrees/minutes/seconds separated by commas). A missing or unreadable
+// altitude leaves hasAltitude unset.
+func parseGPSCoordinates(value string) (lat, lng, altitude float64, hasAltitude bool, err error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, 0, 0, false, fmt.Errorf("empty coordinates")
 	}
 
-	return folderPath
-}
+	if iso6709Pattern.MatchString(value) {
+		return parseISO6709(value)
+	}
 
-func (app *App) copyFile(src, destDir string) error {
-	filename := filepath.Base(src)
-	destPath := filepath.Join(destDir, filename)
+	var parts []string
+	if strings.Contains(value, ",") {
+		parts = strings.Split(value, ",")
+	} else {
+		parts = strings.Fields(value)
+	}
+	if len(parts) < 2 {
+		return 0, 0, 0, false, fmt.Errorf("unrecognized coordinates %q", value)
+	}
 
-	// Check if destination already exists
-	if _, err := os.Stat(destPath); err == nil {
-		ext := filepath.Ext(filename)
-		name := strings.TrimSuffix(filename, ext)
-		counter := 1
+	if lat, err = parseExifToolCoordinate(parts[0], ""); err != nil {
+		return 0, 0, 0, false, err
+	}
+	if lng, err = parseExifToolCoordinate(parts[1], ""); err != nil {
+		return 0, 0, 0, false, err
+	}
 
-		for {
-			newName := fmt.Sprintf("%s_%d%s", name, counter, ext)
-			destPath = filepath.Join(destDir, newName)
-			if _, err := os.Stat(destPath); os.IsNotExist(err) {
-				break
+	// The altitude reads "10.123" with -n, or "10.1 m Above Sea Level" without it
+	if len(parts) > 2 {
+		if fields := strings.Fields(parts[2]); len(fields) > 0 {
+			if value, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				if strings.Contains(parts[2], "Below") && value > 0 {
+					value = -value
+				}
+				altitude, hasAltitude = value, true
 			}
-			counter++
 		}
 	}
+	return lat, lng, altitude, hasAltitude, nil
+}
 
-	sourceFile, err := os.Open(src)
-	if err != nil {
-		return err
+// parseISO6709 parses an ISO 6709 point string. Each coordinate may be given as decimal
+// degrees (+DD.D), degrees and minutes (+DDMM.M) or degrees, minutes and seconds
+// (+DDMMSS.S); hasAltitude reports whether an altitude in meters followed.
+func parseISO6709(value string) (lat, lng, altitude float64, hasAltitude bool, err error) {
+	matches := iso6709Pattern.FindStringSubmatch(strings.TrimSpace(value))
+	if matches == nil {
+		return 0, 0, 0, false, fmt.Errorf("unrecognized ISO 6709 location %q", value)
 	}
-	defer sourceFile.Close()
 
-	destFile, err := os.Create(destPath)
-	if err != nil {
-		return err
+	if lat, err = parseISO6709Coordinate(matches[1], 2); err != nil {
+		return 0, 0, 0, false, err
+	}
+	if lng, err = parseISO6709Coordinate(matches[2], 3); err != nil {
+		return 0, 0, 0, false, err
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, 0, false, fmt.Errorf("ISO 6709 location %q out of range", value)
 	}
-	defer destFile.Close()
 
-	buffer := make([]byte, 64*1024)
-	for {
-		n, err := sourceFile.Read(buffer)
-		if n == 0 || err != nil {
-			break
-		}
-		if _, err := destFile.Write(buffer[:n]); err != nil {
-			return err
+	if matches[3] != "" {
+		altitude, err = strconv.ParseFloat(matches[3], 64)
+		if err != nil {
+			return 0, 0, 0, false, err
 		}
+		hasAltitude = true
 	}
 
-	return nil
+	return lat, lng, altitude, hasAltitude, nil
 }
 
-// extractVideoDateWithExifTool attempts to extract creation date from video files using exiftool
-func (app *App) extractVideoDateWithExifTool(videoPath string) time.Time {
-	// Use the configured exiftool path (either system or embedded)
-	if exiftoolPath == "" {
-		return time.Time{}
+// parseISO6709Coordinate converts one signed ISO 6709 coordinate to decimal degrees.
+// degreeDigits is 2 for latitude and 3 for longitude.
+func parseISO6709Coordinate(value string, degreeDigits int) (float64, error) {
+	sign := 1.0
+	if value[0] == '-' {
+		sign = -1
 	}
+	digits := value[1:]
 
-	cmd := exec.Command(exiftoolPath, "-CreateDate", "-MediaCreateDate", "-CreationDate", "-DateTimeOriginal", "-n", videoPath)
-	output, err := cmd.Output()
-	if err != nil {
-		return time.Time{}
+	integer, fraction, _ := strings.Cut(digits, ".")
+	if fraction != "" {
+		fraction = "." + fraction
 	}
 
-	outputStr := string(output)
-
-	// Parse creation date from exiftool output
-	// Look for various date fields that videos might have
-	lines := strings.Split(outputStr, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if (strings.Contains(line, "Create Date") ||
-			strings.Contains(line, "Media Create Date") ||
-			strings.Contains(line, "Creation Date") ||
-			strings.Contains(line, "Date/Time Original")) && strings.Contains(line, ":") {
-			parts := strings.Split(line, ":")
-			if len(parts) >= 2 {
-				dateStr := strings.TrimSpace(strings.Join(parts[1:], ":"))
-				// Common video date formats
-				dateFormats := []string{
-					"2006:01:02 15:04:05",
-					"2006-01-02 15:04:05",
-					"2006:01:02T15:04:05",
-					"2006-01-02T15:04:05",
-				}
-
-				for _, format := range dateFormats {
-					if parsedTime, err := time.Parse(format, dateStr); err == nil {
-						return parsedTime
-					}
-				}
-			}
-		}
+	var degrees, minutes, seconds float64
+	var err error
+	switch len(integer) {
+	case degreeDigits:
+		degrees, err = strconv.ParseFloat(digits, 64)
+	case degreeDigits + 2:
+		degrees, _ = strconv.ParseFloat(integer[:degreeDigits], 64)
+		minutes, err = strconv.ParseFloat(integer[degreeDigits:]+fraction, 64)
+	case degreeDigits + 4:
+		degrees, _ = strconv.ParseFloat(integer[:degreeDigits], 64)
+		minutes, _ = strconv.ParseFloat(integer[degreeDigits:degreeDigits+2], 64)
+		seconds, err = strconv.ParseFloat(integer[degreeDigits+2:]+fraction, 64)
+	default:
+		return 0, fmt.Errorf("unrecognized ISO 6709 coordinate %q", value)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if minutes >= 60 || seconds >= 60 {
+		return 0, fmt.Errorf("invalid ISO 6709 coordinate %q", value)
 	}
 
-	return time.Time{}
+	return sign * (degrees + minutes/60 + seconds/3600), nil
 }
 
-// extractHEICGPSWithExifTool attempts to extract GPS data from HEIC files using system exiftool
-func (app *App) extractHEICGPSWithExifTool(imagePath string) (lat, lng float64, hasGPS bool) {
-	// Use the configured exiftool path (either system or embedded)
-	if exiftoolPath == "" {
-		return 0, 0, false
+// parseExifToolDate parses a date value as printed by exiftool, reporting whether it
+// carried a UTC offset. It returns the zero time if the value is empty or unrecognized.
+func parseExifToolDate(dateStr string) (time.Time, bool) {
+	if dateStr == "" {
+		return time.Time{}, false
 	}
 
-	cmd := exec.Command(exiftoolPath, "-GPS*", "-n", imagePath)
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, 0, false
+	// Dates with an explicit offset
+	zonedFormats := []string{
+		"2006:01:02 15:04:05-07:00",
+		"2006:01:02 15:04:05Z07:00",
 	}
 
-	outputStr := string(output)
-	app.safeLog(fmt.Sprintf("ExifTool output for %s:\n%s\n", filepath.Base(imagePath), outputStr))
-
-	// Parse GPS coordinates from exiftool output
-	// Look for GPSLatitude and GPSLongitude in decimal format (-n flag)
-	lines := strings.Split(outputStr, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.Contains(line, "GPS Latitude") && strings.Contains(line, ":") {
-			parts := strings.Split(line, ":")
-			if len(parts) >= 2 {
-				latStr := strings.TrimSpace(parts[1])
-				if parsedLat, err := strconv.ParseFloat(latStr, 64); err == nil {
-					lat = parsedLat
-				}
-			}
-		} else if strings.Contains(line, "GPS Longitude") && strings.Contains(line, ":") {
-			parts := strings.Split(line, ":")
-			if len(parts) >= 2 {
-				lngStr := strings.TrimSpace(parts[1])
-				if parsedLng, err := strconv.ParseFloat(lngStr, 64); err == nil {
-					lng = parsedLng
-				}
-			}
+	for _, format := range zonedFormats {
+		if parsedTime, err := time.Parse(format, dateStr); err == nil {
+			return parsedTime, true
 		}
 	}
 
-	// Check if we got valid coordinates
-	if lat != 0 && lng != 0 {
-		hasGPS = true
-		app.safeLog(fmt.Sprintf("Successfully extracted GPS from HEIC: lat=%.6f, lng=%.6f\n", lat, lng))
+	// Common image and video date formats
+	dateFormats := []string{
+		"2006:01:02 15:04:05",
+		"2006-01-02 15:04:05",
+		"2006:01:02T15:04:05",
+		"2006-01-02T15:04:05",
+	}
+
+	for _, format := range dateFormats {
+		if parsedTime, err := time.Parse(format, dateStr); err == nil {
+			return parsedTime, false
+		}
 	}
 
-	return lat, lng, hasGPS
+	return time.Time{}, false
+}
+
+// applyExifToolGPS copies exiftool GPS coordinates into info when present
+func (org *Organizer) applyExifToolGPS(info *ImageInfo, metadata ExifToolMetadata) {
+	if !metadata.HasGPS {
+		return
+	}
+	info.HasGPS = true
+	info.Latitude = metadata.Latitude
+	info.Longitude = metadata.Longitude
+	info.Location = formatLocation(metadata.Latitude, metadata.Longitude, fileLocationDecimals)
+	info.Elevation, info.HasElevation = metadata.Altitude, metadata.HasAltitude
+	info.GPSError, info.HasGPSError = metadata.GPSError, metadata.HasGPSError
 }
 
 // checkExifToolAvailability checks if exiftool is available and logs the status
@@ -1074,22 +3713,72 @@ func (app *App) checkExifToolAvailability() {
 		return
 	}
 
-	cmd := exec.Command(exiftoolPath, "-ver")
-	output, err := cmd.Output()
+	version, err := validateExifTool(exiftoolPath)
 	if err != nil {
 		app.safeLog("⚠️  ExifTool not working properly - HEIC GPS extraction will be limited\n")
 		exiftoolPath = "" // Disable it if it's not working
 	} else {
-		version := strings.TrimSpace(string(output))
-		app.safeLog(fmt.Sprintf("✅ ExifTool v%s detected - Enhanced metadata support enabled\n", version))
+		app.safeLog(fmt.Sprintf("✅ ExifTool v%s detected (%s: %s) - Enhanced metadata support enabled\n", version, exiftoolSource, exiftoolPath))
+	}
+}
+
+// setCustomExifToolPath validates and persists a user-chosen exiftool executable.
+// An empty path clears the setting and returns to auto-detection.
+func (app *App) setCustomExifToolPath(path string) {
+	path = strings.TrimSpace(path)
+	prefs := app.fyneApp.Preferences()
+
+	if path == "" {
+		prefs.SetString(prefExifToolPath, "")
+		app.safeLog("Custom ExifTool path cleared, auto-detecting ExifTool\n")
+		setupExifTool("")
+		app.checkExifToolAvailability()
+		return
 	}
+
+	version, err := validateExifTool(path)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%s is not a working exiftool: %v", path, err), app.window)
+		return
+	}
+
+	prefs.SetString(prefExifToolPath, path)
+	exiftoolPath = path
+	exiftoolSource = "custom"
+	app.safeLog(fmt.Sprintf("✅ Using custom ExifTool v%s at %s\n", version, path))
+}
+
+// validateExifTool runs "exiftool -ver" and returns the reported version
+func validateExifTool(path string) (string, error) {
+	output, err := exec.Command(path, "-ver").Output()
+	if err != nil {
+		return "", err
+	}
+
+	version := strings.TrimSpace(string(output))
+	if _, err := strconv.ParseFloat(version, 64); err != nil {
+		return "", fmt.Errorf("unexpected version output %q", version)
+	}
+
+	return version, nil
 }
 
-// setupExifTool looks for ExifTool installation in common locations
-func setupExifTool() {
+// setupExifTool looks for ExifTool, preferring customPath when it is set and working,
+// then the system installation and finally a bundled copy
+func setupExifTool(customPath string) {
+	// A user-configured path takes priority over the probed locations
+	if customPath != "" {
+		if _, err := validateExifTool(customPath); err == nil {
+			exiftoolPath = customPath
+			exiftoolSource = "custom"
+			return
+		}
+	}
+
 	// Check if exiftool is already available in PATH
 	if _, err := exec.LookPath("exiftool"); err == nil {
 		exiftoolPath = "exiftool"
+		exiftoolSource = "system"
 		return
 	}
 
@@ -1126,158 +3815,447 @@ func setupExifTool() {
 			cmd := exec.Command(path, "-ver")
 			if err := cmd.Run(); err == nil {
 				exiftoolPath = path
+				exiftoolSource = "system"
 				return
 			}
 		}
 	}
 
+	// Fall back to the copy bundled into this build, if any
+	if path, err := extractBundledExifTool(); err == nil {
+		if err := exec.Command(path, "-ver").Run(); err == nil {
+			exiftoolPath = path
+			exiftoolSource = "bundled"
+			return
+		}
+	}
+
 	// If we get here, ExifTool was not found
 	exiftoolPath = ""
+	exiftoolSource = ""
 }
 
-// worker processes media files from the jobs channel
-func (app *App) worker(pool *WorkerPool) {
+// worker processes media files from one of the pool's job channels
+func (org *Organizer) worker(pool *WorkerPool, jobs <-chan string) {
 	defer pool.wg.Done()
 
-	for mediaFile := range pool.Jobs {
+	for {
+		var mediaFile string
+		select {
+		case <-pool.ctx.Done():
+			return
+		case job, ok := <-jobs:
+			if !ok {
+				return
+			}
+			mediaFile = job
+		}
+
 		// Create a minimal ImageInfo in case of error
 		result := ProcessingResult{
 			Info: &ImageInfo{OriginalPath: mediaFile},
 		}
 
 		// Process the file
-		info, err := app.extractImageInfo(mediaFile)
+		info, err := org.extractImageInfo(mediaFile)
 		if err != nil {
 			result.Error = err
 		} else {
 			result.Info = info
 		}
 
-		// Send result
-		pool.Results <- result
+		// Send result, unless nobody is collecting them any more
+		select {
+		case pool.Results <- result:
+		case <-pool.ctx.Done():
+			return
+		}
 	}
 }
 
 
 
-// openFileExplorer opens the native file explorer to the specified folder
-func (app *App) openFileExplorer(folderPath string) {
-	var cmd *exec.Cmd
-	
-	switch runtime.GOOS {
-	case "windows":
-		cmd = exec.Command("explorer", folderPath)
-	case "darwin":
-		cmd = exec.Command("open", folderPath)
-	case "linux":
-		// Try common Linux file managers
-		for _, manager := range []string{"xdg-open", "nautilus", "dolphin", "thunar", "pcmanfm"} {
-			if _, err := exec.LookPath(manager); err == nil {
-				cmd = exec.Command(manager, folderPath)
-				break
-			}
+// organizeByLocationClusters processes each location cluster and copies files to their destinations.
+// It returns the total number of files copied, stopping early once ctx is cancelled.
+func (org *Organizer) organizeByLocationClusters(ctx context.Context, locationClusters []LocationCluster) (int, error) {
+	totalCopied := 0
+
+	// Flattened output shares one date tree, so scan it once rather than per cluster
+	var flatExistingFiles map[string][]string
+	if org.flattenByDate {
+		flatExistingFiles = org.existingFilesByName(org.outputFolder)
+	}
+
+	// Read every cluster's files first, so sparse date folders are known before
+	// anything is copied and destinations don't depend on copy order
+	clusterInfos := make([][]*ImageInfo, len(locationClusters))
+	clusterSkipped := make([]int, len(locationClusters))
+	for i, cluster := range locationClusters {
+		clusterInfos[i], clusterSkipped[i] = org.readClusterImages(cluster)
+	}
+	if org.dedupeClusters {
+		if dropped := org.dedupeAcrossClusters(locationClusters, clusterInfos); dropped > 0 {
+			org.safeLog(fmt.Sprintf("Copying %d files with the same contents as another file only once\n", dropped))
 		}
-		if cmd == nil {
-			app.safeLog("Could not find a file manager to open the output folder\n")
-			return
+	}
+	if grouped := groupCompanions(locationClusters, clusterInfos); grouped > 0 {
+		org.safeLog(fmt.Sprintf("Keeping %d edited versions and Live Photo movies with their original photos\n", grouped))
+	}
+	if passedOver := org.selectBurstFrames(clusterInfos); passedOver > 0 {
+		org.safeLog(fmt.Sprintf("Passed over %d burst frames for a better frame of the same burst\n", passedOver))
+	}
+	if suspect := org.checkCaptureDates(clusterInfos, time.Now()); suspect > 0 {
+		switch org.suspectDatePolicy {
+		case SuspectDateClamp:
+			org.safeLog(fmt.Sprintf("Clamped the implausible capture dates of %d files\n", suspect))
+		case SuspectDateFolder:
+			org.safeLog(fmt.Sprintf("Sending %d files with implausible capture dates to %s\n", suspect, CheckDateFolder))
 		}
-	default:
-		app.safeLog("Unsupported operating system - cannot open file explorer\n")
-		return
 	}
-
-	err := cmd.Start()
-	if err != nil {
-		app.safeLog(fmt.Sprintf("Failed to open file explorer: %v\n", err))
-	} else {
-		app.safeLog("📂 Opened output folder in file explorer\n")
+	if org.routeUndated {
+		if undated := markUndatedFiles(clusterInfos, time.Now()); undated > 0 {
+			org.safeLog(fmt.Sprintf("Sending %d files dated only by an implausible modification time to %s\n", undated, UndatedFolder))
+		}
 	}
-}
+	sparseFolders := org.sparseDateFolders(clusterInfos)
+	if len(sparseFolders) > 0 {
+		org.safeLog(fmt.Sprintf("Collapsing %d date folders with fewer than %d files into %s folders\n",
+			len(sparseFolders), org.sparseDateThreshold, strings.ToLower(coarserGranularity(org.dateGranularity))))
+	}
+	plan := org.planCopy(locationClusters, clusterInfos, sparseFolders)
+	org.previewPlan(clusterInfos, sparseFolders)
+	if !org.confirmPlan(plan) {
+		return 0, errPlanDeclined
+	}
+	org.startCopyProgress(plan)
 
-// organizeByLocationClusters processes each location cluster and copies files to their destinations
-func (app *App) organizeByLocationClusters(locationClusters []LocationCluster) {
-	for _, cluster := range locationClusters {
-		app.safeLog(fmt.Sprintf("Processing location cluster: %s (%d files)\n", cluster.Name, len(cluster.Images)))
+	for i, cluster := range locationClusters {
+		org.safeLog(fmt.Sprintf("Processing location cluster: %s (%d files)\n", cluster.Name, len(cluster.Images)))
 
 		// Check if location folder already exists and get existing files
-		baseLocationFolder := filepath.Join(app.outputFolder, cluster.Name)
-		existingFiles := app.getExistingFiles(baseLocationFolder)
-
-		// Create a map for quick lookup of existing files
-		existingFileMap := make(map[string]bool)
-		for _, file := range existingFiles {
-			existingFileMap[filepath.Base(file)] = true
-		}
-
-		// Extract image info for sorting, but only for files that don't already exist
-		var clusterImageInfos []*ImageInfo
-		skippedCount := 0
-		for _, imagePath := range cluster.Images {
-			filename := filepath.Base(imagePath)
-			
-			// Skip if file already exists in destination
-			if existingFileMap[filename] {
-				app.safeLog(fmt.Sprintf("Skipping existing file: %s\n", filename))
-				skippedCount++
-				continue
-			}
-
-			// Extract image info for this file
-			info, err := app.extractImageInfo(imagePath)
-			if err != nil {
-				app.safeLog(fmt.Sprintf("Error extracting info from %s: %v\n", filename, err))
-				skippedCount++
-				continue
+		existingFileMap := flatExistingFiles
+		if existingFileMap == nil {
+			clusterFolder := filepath.Join(org.outputFolder, cluster.Name)
+			if cluster.Name == NoLocationClusterName && org.noGPSPolicy == NoGPSDateOnly {
+				// These files go straight into date folders at the top of the output
+				clusterFolder = org.outputFolder
 			}
+			existingFileMap = org.existingFilesByName(clusterFolder)
+		}
 
-			// Update location name to cluster name
-			info.Location = cluster.Name
-			clusterImageInfos = append(clusterImageInfos, info)
+		// Location recorded on flattened files, if the cluster has one
+		location := ""
+		if cluster.HasLocation || cluster.Name == MiscLocationsClusterName {
+			location = cluster.Name
 		}
 
-		// Sort images within this cluster by date
-		sort.Slice(clusterImageInfos, func(i, j int) bool {
-			return clusterImageInfos[i].Date.Before(clusterImageInfos[j].Date)
-		})
+		// Overwrite and keep-newest need to see the existing file, so only the
+		// non-destructive policies skip names already present in the cluster
+		skipExistingNames := org.conflictPolicy == ConflictRename || org.conflictPolicy == ConflictSkip
+
+		clusterImageInfos, skippedCount := clusterInfos[i], clusterSkipped[i]
 
 		// Process sorted images for this cluster
+		var metadataWrites []copyMetadataWrite
 		copiedCount := 0
 		for _, info := range clusterImageInfos {
+			if ctx.Err() != nil {
+				// Keep what was copied, so the next run resumes here
+				org.saveManifest()
+				return totalCopied + copiedCount, ctx.Err()
+			}
+			bytesBefore := org.copyBytesDone.Load()
+
 			// Create destination folder structure
-			destFolder := app.createFolderStructure(app.outputFolder, info)
+			destFolder := org.createFolderStructure(org.outputFolder, info, sparseFolders)
+			// Numbered across the run, as clusters can share a folder once flattened
+			org.folderSequence[destFolder]++
+			destName := org.destinationFilename(info, location, org.folderSequence[destFolder])
+
+			// Skip if an identical file already exists in destination; a different
+			// file that merely shares the name falls through to the conflict policy
+			if skipExistingNames {
+				if existing := org.identicalFile(info.OriginalPath, existingFileMap[destName]); existing != "" {
+					org.safeLog(fmt.Sprintf("Skipping existing file: %s (identical copy already organized)\n", destName))
+					org.recordOrganized(info, existing)
+					org.countCopied(info, bytesBefore)
+					skippedCount++
+					continue
+				}
+			}
 
 			// Copy file to destination
-			if err := app.copyFile(info.OriginalPath, destFolder); err != nil {
-				app.safeLog(fmt.Sprintf("Error copying %s: %v\n", filepath.Base(info.OriginalPath), err))
+			destPath, written, err := org.copyFile(info, destFolder, destName)
+			org.countCopied(info, bytesBefore)
+			if errors.Is(err, errConflictSkipped) {
+				skippedCount++
+				continue
+			} else if err != nil {
+				org.safeLog(fmt.Sprintf("Error copying %s: %v\n", filepath.Base(info.OriginalPath), err))
+				org.emit(Event{Type: EventError, Path: info.OriginalPath, Cluster: cluster.Name, Error: err.Error()})
+				org.recordFailure(info.OriginalPath, err)
+
+				// A drive that was unplugged or turned read-only would fail every
+				// remaining copy the same way
+				if writeErr := checkWritable(org.outputFolder); writeErr != nil {
+					return totalCopied + copiedCount, writeErr
+				}
+				continue
+			}
+			copiedCount++
+			org.recordOrganized(info, destPath)
+			org.emit(Event{Type: EventFileCopied, Path: info.OriginalPath, Destination: destPath, Cluster: cluster.Name})
+			if org.writeCopyMetadata && cluster.HasLocation && destPath != info.OriginalPath {
+				write := copyMetadataWrite{Path: destPath, Comment: cluster.Name}
+				if estimate, estimated := org.estimatedLocations[info.OriginalPath]; estimated {
+					write.Latitude, write.Longitude, write.HasGPS = estimate.Lat, estimate.Lng, true
+				}
+				metadataWrites = append(metadataWrites, write)
+			}
+			org.runStats.AddBytesCopied(written)
+
+			// Flattened files lose their location folder, so record it alongside
+			if org.flattenByDate && org.locationAnnotation == AnnotateSidecar && info.HasGPS {
+				if err := writeLocationSidecar(destPath, info); err != nil {
+					org.safeLog(fmt.Sprintf("Warning: Could not write location sidecar for %s: %v\n", filepath.Base(destPath), err))
+				}
+			}
+		}
+
+		org.writeMetadataToCopies(metadataWrites)
+		org.saveManifest() // After each cluster, so an interrupted run can resume
+		if cluster.HasLocation && !org.flattenByDate {
+			org.recordFolderPoint(filepath.Join(org.outputFolder, cluster.Name), cluster.CenterLat, cluster.CenterLng)
+		}
+
+		org.safeLog(fmt.Sprintf("Cluster %s: %d files copied, %d files skipped\n", cluster.Name, copiedCount, skippedCount))
+		totalCopied += copiedCount
+	}
+
+	return totalCopied, nil
+}
+
+// recordOrganized notes in the manifest that info, and any duplicates of it that weren't
+// copied, are organized as destination, so later runs skip them all
+func (org *Organizer) recordOrganized(info *ImageInfo, destination string) {
+	org.manifest.Record(info.OriginalPath, destination)
+	for _, duplicate := range info.Duplicates {
+		org.manifest.Record(duplicate, destination)
+	}
+}
+
+// readClusterImages extracts the info of each file in cluster, sorted by date. It returns
+// the number of files that couldn't be read.
+func (org *Organizer) readClusterImages(cluster LocationCluster) ([]*ImageInfo, int) {
+	var clusterImageInfos []*ImageInfo
+	skippedCount := 0
+	for _, imagePath := range cluster.Images {
+		filename := filepath.Base(imagePath)
+
+		// Extract image info for this file
+		info, err := org.extractImageInfo(imagePath)
+		if err != nil {
+			org.safeLog(fmt.Sprintf("Error extracting info from %s: %v\n", filename, err))
+			org.emit(Event{Type: EventError, Path: imagePath, Cluster: cluster.Name, Error: err.Error()})
+			org.recordFailure(imagePath, err)
+			skippedCount++
+			continue
+		}
+
+		// Update location name to cluster name
+		info.Location = cluster.Name
+		clusterImageInfos = append(clusterImageInfos, info)
+	}
+
+	sortByCaptureTime(clusterImageInfos)
+	return clusterImageInfos, skippedCount
+}
+
+// sortByCaptureTime sorts infos by date, breaking ties by filename
+func sortByCaptureTime(infos []*ImageInfo) {
+	sort.Slice(infos, func(i, j int) bool {
+		a, b := infos[i], infos[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if nameA, nameB := filepath.Base(a.OriginalPath), filepath.Base(b.OriginalPath); nameA != nameB {
+			return nameA < nameB
+		}
+		return a.OriginalPath < b.OriginalPath
+	})
+}
+
+// editedPhotoPattern matches the names iPhones give edited versions, e.g. IMG_E1234
+// for an edit of IMG_1234
+var editedPhotoPattern = regexp.MustCompile(`^(?i)(IMG_)E(\d+)$`)
+
+// companionKey returns the key of the photo a file accompanies, when it is an edited
+// version or a Live Photo movie: the photo's folder and lowercase name without
+// extension. Photos return their own key with primary set.
+func companionKey(path string) (key string, primary bool) {
+	stem := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	dir := filepath.Dir(path)
+
+	switch kind := mediaFormats[strings.ToLower(filepath.Ext(path))].Kind; {
+	case kind == MediaVideo:
+		// The movie half of a Live Photo shares the photo's name
+		return filepath.Join(dir, strings.ToLower(stem)), false
+	case kind != MediaImage && kind != MediaHEIF:
+		return "", false
+	case editedPhotoPattern.MatchString(stem):
+		return filepath.Join(dir, strings.ToLower(editedPhotoPattern.ReplaceAllString(stem, "${1}${2}"))), false
+	default:
+		return filepath.Join(dir, strings.ToLower(stem)), true
+	}
+}
+
+// groupCompanions moves edited versions and Live Photo movies into the cluster of the
+// photo they accompany and gives them its date, so they land in the same folder and
+// sort right after it rather than being placed as independent captures. It returns
+// how many files it moved.
+func groupCompanions(clusters []LocationCluster, clusterInfos [][]*ImageInfo) int {
+	type photo struct {
+		info    *ImageInfo
+		cluster int
+	}
+	photos := make(map[string]photo)
+	for i, infos := range clusterInfos {
+		for _, info := range infos {
+			if key, primary := companionKey(info.OriginalPath); primary {
+				photos[key] = photo{info, i}
+			}
+		}
+	}
+	if len(photos) == 0 {
+		return 0
+	}
+
+	grouped := 0
+	moved := make(map[int][]*ImageInfo) // Companions to append to each cluster
+	changed := make(map[int]bool)
+	for i, infos := range clusterInfos {
+		kept := infos[:0]
+		for _, info := range infos {
+			key, primary := companionKey(info.OriginalPath)
+			original, found := photos[key]
+			if primary || !found {
+				kept = append(kept, info)
+				continue
+			}
+
+			info.Date = original.info.Date
+			info.Location = clusters[original.cluster].Name
+			if original.cluster == i {
+				kept = append(kept, info)
 			} else {
-				copiedCount++
+				moved[original.cluster] = append(moved[original.cluster], info)
+			}
+			changed[original.cluster] = true
+			grouped++
+		}
+		clusterInfos[i] = kept
+	}
+
+	for i := range changed {
+		clusterInfos[i] = append(clusterInfos[i], moved[i]...)
+		sortByCaptureTime(clusterInfos[i])
+	}
+	return grouped
+}
+
+// identicalFile returns the first of candidates with the same size and content as path,
+// or an empty string when there is none
+func (org *Organizer) identicalFile(path string, candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	source, err := org.statSource(path)
+	if err != nil {
+		return ""
+	}
+
+	var sourceHash string
+	for _, candidate := range candidates {
+		existing, err := os.Stat(candidate)
+		if err != nil || existing.Size() != source.Size() {
+			continue
+		}
+
+		// Only hash once sizes match, and the source at most once
+		if sourceHash == "" {
+			if sourceHash, err = org.sourceSHA256(path); err != nil {
+				return ""
 			}
 		}
+		if candidateHash, err := fileSHA256(candidate); err == nil && candidateHash == sourceHash {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 of a file's contents
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return readerSHA256(file)
+}
+
+// sourceSHA256 returns the hex-encoded SHA-256 of a source file's contents, which may
+// be in an archive
+func (org *Organizer) sourceSHA256(path string) (string, error) {
+	file, err := org.openSource(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	return readerSHA256(file)
+}
+
+// readerSHA256 returns the hex-encoded SHA-256 of everything r reads
+func readerSHA256(r io.Reader) (string, error) {
+	hash := sha256.New()
+	if _, err := io.Copy(hash, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
 
-		app.safeLog(fmt.Sprintf("Cluster %s: %d files copied, %d files skipped\n", cluster.Name, copiedCount, skippedCount))
+// existingFilesByName indexes the files below folder by their base name
+func (org *Organizer) existingFilesByName(folder string) map[string][]string {
+	existing := make(map[string][]string)
+	for _, file := range org.getExistingFiles(folder) {
+		name := filepath.Base(file)
+		existing[name] = append(existing[name], file)
 	}
+	return existing
 }
 
 // getExistingFiles recursively gets all files in a directory
-func (app *App) getExistingFiles(baseFolder string) []string {
+func (org *Organizer) getExistingFiles(baseFolder string) []string {
 	var files []string
 	
 	if _, err := os.Stat(baseFolder); os.IsNotExist(err) {
 		return files // Folder doesn't exist yet
 	}
 
-	err := filepath.Walk(baseFolder, func(path string, info os.FileInfo, err error) error {
+	err := filepath.WalkDir(baseFolder, func(path string, entry fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() {
+		if !entry.IsDir() {
 			files = append(files, path)
 		}
 		return nil
 	})
 
 	if err != nil {
-		app.safeLog(fmt.Sprintf("Warning: Could not scan existing files in %s: %v\n", baseFolder, err))
+		org.safeLog(fmt.Sprintf("Warning: Could not scan existing files in %s: %v\n", baseFolder, err))
 	}
 
 	return files
@@ -0,0 +1,1327 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// fakeDispatcher holds the functions passed to runOnMain instead of running them, so a
+// test can tell which widget updates were handed to the main goroutine
+type fakeDispatcher struct {
+	mutex  sync.Mutex
+	queued []func()
+}
+
+// dispatch queues fn
+func (d *fakeDispatcher) dispatch(fn func()) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.queued = append(d.queued, fn)
+}
+
+// pending returns how many functions are waiting to run
+func (d *fakeDispatcher) pending() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.queued)
+}
+
+// runAll runs the queued functions in order, as the main goroutine would
+func (d *fakeDispatcher) runAll() {
+	d.mutex.Lock()
+	queued := d.queued
+	d.queued = nil
+	d.mutex.Unlock()
+	for _, fn := range queued {
+		fn()
+	}
+}
+
+// waitFor waits up to timeout for at least n functions to be queued
+func (d *fakeDispatcher) waitFor(t *testing.T, n int, timeout time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for d.pending() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d functions dispatched after %v, want %d", d.pending(), timeout, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newTestApp builds the app's UI on Fyne's test driver, with widget updates from
+// background goroutines going to a fake dispatcher
+func newTestApp(t *testing.T) (*App, *fakeDispatcher) {
+	t.Helper()
+	fyneApp := test.NewApp()
+	t.Cleanup(fyneApp.Quit)
+
+	dispatcher := &fakeDispatcher{}
+	app := &App{
+		fyneApp:        fyneApp,
+		window:         fyneApp.NewWindow("Media Organizer"),
+		logBuffer:      NewLogBuffer(MaxLogLines),
+		thumbnailCache: NewThumbnailCache(),
+		progressMode:   ProgressFiles,
+		dispatch:       dispatcher.dispatch,
+	}
+	app.Organizer = NewOrganizer(app)
+	app.setupUI()
+	return app, dispatcher
+}
+
+func TestPhaseChangesAreDispatched(t *testing.T) {
+	app, dispatcher := newTestApp(t)
+
+	app.OnPhaseChange(PhaseExtracting)
+	if dispatcher.pending() != 1 || app.progressBar.Visible() {
+		t.Fatalf("phase change ran on the calling goroutine: %d dispatched, progress bar visible %v",
+			dispatcher.pending(), app.progressBar.Visible())
+	}
+	dispatcher.runAll()
+	if !app.progressBar.Visible() {
+		t.Fatal("progress bar not shown once the dispatched update ran")
+	}
+
+	// The progress bar is hidden later, from a timer's goroutine
+	app.OnPhaseChange(PhaseDone)
+	dispatcher.runAll()
+	dispatcher.waitFor(t, 1, 5*time.Second)
+	if !app.progressBar.Visible() {
+		t.Fatal("progress bar hidden from the timer's goroutine")
+	}
+	dispatcher.runAll()
+	if app.progressBar.Visible() {
+		t.Fatal("progress bar not hidden once the dispatched update ran")
+	}
+}
+
+func TestRunOutcomeIsDispatched(t *testing.T) {
+	app, dispatcher := newTestApp(t)
+	// A source that doesn't exist stops the run before the copy plan needs confirming
+	app.sourceFolder, app.outputFolder = filepath.Join(t.TempDir(), "missing"), t.TempDir()
+	logged := app.logText.Text
+
+	app.setRunning(true)
+	app.runOrganizer()
+	if dispatcher.pending() == 0 || app.logText.Text != logged || !app.running.Load() {
+		t.Fatalf("run outcome shown on the run's goroutine: %d dispatched, log changed %v, running %v",
+			dispatcher.pending(), app.logText.Text != logged, app.running.Load())
+	}
+	dispatcher.runAll()
+	if app.logText.Text == logged || app.running.Load() {
+		t.Fatalf("run outcome not shown once the dispatched updates ran: log changed %v, running %v",
+			app.logText.Text != logged, app.running.Load())
+	}
+}
+
+// writeTestPNG writes a small PNG image to path
+func writeTestPNG(t *testing.T, path string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(0, 0, color.White)
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestThumbnailsAreDispatched(t *testing.T) {
+	app, dispatcher := newTestApp(t)
+
+	path := filepath.Join(t.TempDir(), "photo.png")
+	writeTestPNG(t, path)
+	app.folderPreview.AddFile("place", path)
+
+	app.showThumbnails("place")
+	dispatcher.waitFor(t, 1, 5*time.Second)
+	cell := app.thumbnailGrid.Objects[0].(*fyne.Container)
+	if _, placeholder := cell.Objects[0].(*widget.Icon); !placeholder {
+		t.Fatal("thumbnail placed from the decoding goroutine")
+	}
+	dispatcher.runAll()
+	if _, thumbnail := cell.Objects[0].(*canvas.Image); !thumbnail {
+		t.Fatalf("cell holds %T once the dispatched update ran, want a thumbnail", cell.Objects[0])
+	}
+}
+
+func TestThumbnailsFillTheirOwnCells(t *testing.T) {
+	app, dispatcher := newTestApp(t)
+
+	dir := t.TempDir()
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		path := filepath.Join(dir, name)
+		writeTestPNG(t, path)
+		app.folderPreview.AddFile("place", path)
+	}
+
+	// All three updates are queued before any of them runs
+	app.showThumbnails("place")
+	dispatcher.waitFor(t, 3, 5*time.Second)
+	dispatcher.runAll()
+	for i, object := range app.thumbnailGrid.Objects {
+		if _, thumbnail := object.(*fyne.Container).Objects[0].(*canvas.Image); !thumbnail {
+			t.Errorf("cell %d holds %T, want its thumbnail", i, object.(*fyne.Container).Objects[0])
+		}
+	}
+}
+
+func TestThumbnailsSkipVideos(t *testing.T) {
+	app, dispatcher := newTestApp(t)
+
+	// A video is never decoded, even one that would decode as an image
+	dir := t.TempDir()
+	video, photo := filepath.Join(dir, "clip.mov"), filepath.Join(dir, "photo.png")
+	writeTestPNG(t, video)
+	writeTestPNG(t, photo)
+	app.folderPreview.AddFile("place", video)
+	app.folderPreview.AddFile("place", photo)
+
+	app.showThumbnails("place")
+	dispatcher.waitFor(t, 1, 5*time.Second)
+	dispatcher.runAll()
+	for _, o := range app.thumbnailGrid.Objects {
+		t.Logf("%T", o.(*fyne.Container).Objects[0])
+	}
+	if _, placeholder := app.thumbnailGrid.Objects[0].(*fyne.Container).Objects[0].(*widget.Icon); !placeholder {
+		t.Error("video decoded for a thumbnail")
+	}
+	if _, thumbnail := app.thumbnailGrid.Objects[1].(*fyne.Container).Objects[0].(*canvas.Image); !thumbnail {
+		t.Error("first thumbnail dispatched isn't the photo's")
+	}
+}
+
+func TestExtractDateFromFilename(t *testing.T) {
+	org := NewOrganizer(nil)
+	nextYear := time.Now().AddDate(1, 0, 0).Format("20060102")
+	tests := []struct {
+		filename string
+		want     string // Wall clock, in UTC for Unix timestamps; empty for no date
+	}{
+		{"IMG_20240315_143022.heic", "2024-03-15 14:30:22"},
+		{"PXL_20240315_143022123.jpg", "2024-03-15 14:30:22"},
+		{"PXL_20240315_143022123.NIGHT.jpg", "2024-03-15 14:30:22"},
+		{"VID_20240315_143022.mp4", "2024-03-15 14:30:22"},
+		{"Screenshot_20240315-143022.png", "2024-03-15 14:30:22"},
+		{"Screenshot_20240315-143022_Chrome.png", "2024-03-15 14:30:22"},
+		{"WhatsApp Image 2024-03-15 at 14.30.22.jpeg", "2024-03-15 14:30:22"},
+		{"WhatsApp Video 2024-03-15 at 14.30.22 (1).mp4", "2024-03-15 14:30:22"},
+		{"signal-2024-03-15-143022.jpg", "2024-03-15 14:30:22"},
+		{"2024-03-15T14-30-22.jpg", "2024-03-15 14:30:22"},
+		{"2024_03_15_14_30_22.jpg", "2024-03-15 14:30:22"},
+		{"20240315_143022.jpg", "2024-03-15 14:30:22"},
+		{"burst 20240315_143022 copy.jpg", "2024-03-15 14:30:22"},
+		{"1710508222.jpg", "2024-03-15 13:10:22"},
+		{"20240315.jpg", "2024-03-15 00:00:00"},
+		{"scan-20240315.tif", "2024-03-15 00:00:00"},
+
+		// Digit runs that aren't dates
+		{"DSC_0001.jpg", ""},
+		{"photo.jpg", ""},
+		{"120240315123.jpg", ""},          // Inside a longer number
+		{"IMG_1202403151_143022.jpg", ""}, // Nine digits where the date goes
+		{"12345678901.jpg", ""},           // Too long for a Unix timestamp
+		{"171050822.jpg", ""},             // Too short for one
+		{"2024_03_15_14_30_221.jpg", ""},
+
+		// Dates that can't be capture dates
+		{"20240231.jpg", ""}, // A 31st of February
+		{"IMG_20240231_143022.jpg", ""},
+		{"IMG_20241315_143022.jpg", ""},
+		{"20240315_253022.jpg", "2024-03-15 00:00:00"}, // Only the time is impossible
+		{"19000101.jpg", ""},                           // Before 1990
+		{"19891231.jpg", ""},
+		{"19900101.jpg", "1990-01-01 00:00:00"},
+		{"0000000001.jpg", ""},
+		{nextYear + ".jpg", ""}, // In the future
+		{"IMG_" + nextYear + "_120000.jpg", ""},
+	}
+	for _, tt := range tests {
+		date, _, ok := org.extractDateFromFilename(tt.filename)
+		got := ""
+		if ok {
+			got = date.UTC().Format(time.DateTime)
+		}
+		if got != tt.want {
+			t.Errorf("%s: dated %q, want %q", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestFilenameDatesLocalizeByKind(t *testing.T) {
+	org := NewOrganizer(nil)
+	tests := []struct {
+		filename string
+		kind     captureTimeKind
+		want     time.Time // Once localized at 135°E, nine hours ahead of UTC
+	}{
+		// An instant: the same moment, shown in the capture zone
+		{"1710508222.jpg", absoluteTime, time.Unix(1710508222, 0)},
+		// A wall clock: the same reading, placed in the capture zone
+		{"IMG_20240315_143022.jpg", wallClockTime, time.Date(2024, 3, 15, 14, 30, 22, 0, time.FixedZone("UTC+9", 9*3600))},
+	}
+	for _, tt := range tests {
+		date, kind, ok := org.extractDateFromFilename(tt.filename)
+		if !ok || kind != tt.kind {
+			t.Errorf("%s: found %v with kind %v, want kind %v", tt.filename, ok, kind, tt.kind)
+			continue
+		}
+		info := &ImageInfo{Date: date, dateKind: kind, HasGPS: true, Longitude: 135}
+		if got := org.localizeCaptureTime(info); !got.Equal(tt.want) {
+			t.Errorf("%s localized to %v, want %v", tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestCloseEndsBlockedSubmit(t *testing.T) {
+	// No workers, so the second job waits for room that never comes
+	pool := NewWorkerPool(context.Background(), 0, 0, 1)
+	if !pool.Submit("first.jpg", false) {
+		t.Fatal("Submit rejected a job with room in the queue")
+	}
+	submitted := make(chan bool)
+	go func() {
+		submitted <- pool.Submit("second.jpg", false)
+	}()
+	time.Sleep(50 * time.Millisecond) // Let Submit block on the full queue
+
+	closed := make(chan struct{})
+	go func() {
+		pool.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked behind a Submit waiting for room")
+	}
+	if <-submitted {
+		t.Error("Submit reported a job queued after Close")
+	}
+	if pool.Submit("third.jpg", false) {
+		t.Error("Submit accepted a job after Close")
+	}
+}
+
+func TestWorkerPoolDeliversEveryResult(t *testing.T) {
+	dir := t.TempDir()
+	pool := NewWorkerPool(context.Background(), 2, 1, 4)
+	pool.Start(NewOrganizer(nil))
+
+	received := make(map[string]int)
+	collected := make(chan struct{})
+	go func() {
+		for result := range pool.Results {
+			received[result.Info.OriginalPath]++
+		}
+		close(collected)
+	}()
+
+	want := make(map[string]int)
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("photo%d.png", i))
+		writeTestPNG(t, path)
+		if !pool.Submit(path, i%3 == 0) {
+			t.Fatalf("Submit rejected %s", path)
+		}
+		want[path] = 1
+	}
+	pool.Close()
+	pool.Wait()
+	pool.Wait() // Closing Results again would panic
+
+	select {
+	case <-collected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Results not closed after Wait")
+	}
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("received %d results for %d files: %v", len(received), len(want), received)
+	}
+}
+
+func TestCancelEndsStalledSubmit(t *testing.T) {
+	// Nobody collects results, so the worker stalls sending its second one and the
+	// queue fills up behind it
+	ctx, cancel := context.WithCancel(context.Background())
+	pool := NewWorkerPool(ctx, 1, 0, 1)
+	pool.Start(NewOrganizer(nil))
+	path := filepath.Join(t.TempDir(), "photo.png")
+	writeTestPNG(t, path)
+
+	submitted := make(chan bool)
+	go func() {
+		for {
+			if !pool.Submit(path, false) {
+				submitted <- false
+				return
+			}
+		}
+	}()
+	time.Sleep(50 * time.Millisecond) // Let the queue fill up
+	cancel()
+
+	select {
+	case queued := <-submitted:
+		if queued {
+			t.Error("Submit queued a job after cancellation")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Submit still blocked after cancellation")
+	}
+	exited := make(chan struct{})
+	go func() {
+		pool.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(5 * time.Second):
+		t.Fatal("workers still running after cancellation")
+	}
+}
+
+func TestConcurrentSubmitAndClose(t *testing.T) {
+	// Run with -race: submitters race Close, and every job Submit accepted must still
+	// produce a result
+	path := filepath.Join(t.TempDir(), "photo.png")
+	writeTestPNG(t, path)
+	for run := 0; run < 50; run++ {
+		pool := NewWorkerPool(context.Background(), 2, 1, 2)
+		pool.Start(NewOrganizer(nil))
+		results := make(chan int)
+		go func() {
+			count := 0
+			for range pool.Results {
+				count++
+			}
+			results <- count
+		}()
+
+		var accepted atomic.Int64
+		var submitters sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			submitters.Add(1)
+			go func(i int) {
+				defer submitters.Done()
+				for j := 0; j < 20; j++ {
+					if i == 0 && j == 10 {
+						pool.Close() // Closed midway, with the others still submitting
+					}
+					if pool.Submit(path, i%2 == 0) {
+						accepted.Add(1)
+					}
+				}
+			}(i)
+		}
+		submitters.Wait()
+		pool.Wait()
+
+		if count := <-results; int64(count) != accepted.Load() {
+			t.Fatalf("run %d: %d results for %d accepted jobs", run, count, accepted.Load())
+		}
+	}
+}
+
+// progressRecorder records the progress an Organizer reports
+type progressRecorder struct {
+	mutex     sync.Mutex
+	processed map[int64]int
+}
+
+func (r *progressRecorder) OnLog(message string)      {}
+func (r *progressRecorder) OnPhaseChange(phase Phase) {}
+func (r *progressRecorder) OnProgress(processed, total int64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.processed[processed]++
+}
+
+func TestProgressCountersUnderContention(t *testing.T) {
+	// Run with -race: workers count files while the progress bar reads the counters
+	const workers, perWorker = 8, 500
+	recorder := &progressRecorder{processed: make(map[int64]int)}
+	app := &App{Organizer: NewOrganizer(recorder), progressMode: ProgressFiles}
+	app.totalFiles.Store(workers * perWorker)
+
+	done := make(chan struct{})
+	readerErr := make(chan error, 1)
+	go func() {
+		last := 0.0
+		for {
+			select {
+			case <-done:
+				readerErr <- nil
+				return
+			default:
+			}
+			fraction, _ := app.progress()
+			if fraction < last || fraction > 1 {
+				readerErr <- fmt.Errorf("progress went from %v to %v", last, fraction)
+				return
+			}
+			last = fraction
+			runtime.Gosched() // Where goroutines aren't preempted, such as WebAssembly
+		}
+	}()
+
+	var counting sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		counting.Add(1)
+		go func() {
+			defer counting.Done()
+			for j := 0; j < perWorker; j++ {
+				app.incrementProcessedFiles()
+			}
+		}()
+	}
+	counting.Wait()
+	close(done)
+	if err := <-readerErr; err != nil {
+		t.Error(err)
+	}
+
+	if fraction, status := app.progress(); fraction != 1 || status != fmt.Sprintf("Reading: %d of %d files", workers*perWorker, workers*perWorker) {
+		t.Errorf("finished at %v (%q)", fraction, status)
+	}
+	// Every count was reported exactly once
+	for processed := int64(1); processed <= workers*perWorker; processed++ {
+		if recorder.processed[processed] != 1 {
+			t.Fatalf("count %d reported %d times", processed, recorder.processed[processed])
+		}
+	}
+}
+
+func TestFailedCopyLeavesNothing(t *testing.T) {
+	// An archived file whose checksum is wrong, which the zip reader only reports once
+	// it has handed over all of its data
+	data := bytes.Repeat([]byte("photo data "), 20000)
+	archivePath := filepath.Join(t.TempDir(), "export.zip")
+	var archived bytes.Buffer
+	writer := zip.NewWriter(&archived)
+	entry, err := writer.CreateRaw(&zip.FileHeader{
+		Name: "photo.jpg", Method: zip.Store, CRC32: crc32.ChecksumIEEE(data) + 1,
+		CompressedSize64: uint64(len(data)), UncompressedSize64: uint64(len(data)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.Write(data)
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(archivePath, archived.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	org := NewOrganizer(nil)
+	archive, err := openSourceArchive(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	org.archive = archive
+	defer org.closeArchive()
+
+	destDir := t.TempDir()
+	written, err := org.writeCopy(filepath.Join(archivePath, "photo.jpg"), filepath.Join(destDir, "photo.jpg"))
+	if !errors.Is(err, zip.ErrChecksum) || written == 0 {
+		t.Fatalf("copy failed after %d bytes with %v, want a checksum error midway", written, err)
+	}
+	if entries, _ := os.ReadDir(destDir); len(entries) > 0 {
+		t.Errorf("failed copy left %s behind", entries[0].Name())
+	}
+}
+
+func TestConflictPolicies(t *testing.T) {
+	older, newer := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		policy     string
+		sourceTime time.Time // The existing file is from older
+		skipped    bool
+		want       map[string]string // Contents of the destination folder afterwards
+	}{
+		{ConflictSkip, newer, true, map[string]string{"photo.jpg": "existing"}},
+		{ConflictRename, newer, false, map[string]string{"photo.jpg": "existing", "photo_1.jpg": "source"}},
+		{ConflictOverwrite, older, false, map[string]string{"photo.jpg": "source"}},
+		{ConflictKeepNewest, newer, false, map[string]string{"photo.jpg": "source"}},
+		{ConflictKeepNewest, older, true, map[string]string{"photo.jpg": "existing"}},
+	}
+	for _, tt := range tests {
+		src, destDir := filepath.Join(t.TempDir(), "photo.jpg"), t.TempDir()
+		existing := filepath.Join(destDir, "photo.jpg")
+		for path, contents := range map[string]string{src: "source", existing: "existing"} {
+			if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.Chtimes(src, tt.sourceTime, tt.sourceTime); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(existing, older, older); err != nil {
+			t.Fatal(err)
+		}
+
+		org := NewOrganizer(nil)
+		org.conflictPolicy = tt.policy
+		_, _, err := org.copyFile(&ImageInfo{OriginalPath: src}, destDir, "photo.jpg")
+		if skipped := errors.Is(err, errConflictSkipped); skipped != tt.skipped || (err != nil && !skipped) {
+			t.Errorf("%s with a source from %s: copyFile returned %v, want skipped %v", tt.policy, tt.sourceTime.Format("2006-01"), err, tt.skipped)
+		}
+
+		entries, err := os.ReadDir(destDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make(map[string]string)
+		for _, entry := range entries {
+			contents, err := os.ReadFile(filepath.Join(destDir, entry.Name()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got[entry.Name()] = string(contents)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s with a source from %s left %v, want %v", tt.policy, tt.sourceTime.Format("2006-01"), got, tt.want)
+		}
+	}
+}
+
+func TestSequenceCountsAcrossClusters(t *testing.T) {
+	source, output := t.TempDir(), t.TempDir()
+	org := NewOrganizer(nil)
+	org.outputFolder = output
+	org.flattenByDate, org.locationAnnotation = true, AnnotateNone
+	org.renameOnCopy, org.renameTemplate = true, "{sequence}"
+	org.folderSequence = make(map[string]int)
+	org.manifest, _ = LoadManifest(output)
+
+	// Two clusters whose files land in the same flattened date folder
+	var clusters []LocationCluster
+	for i, name := range []string{"IMG_20240315_090000.jpg", "IMG_20240315_120000.jpg"} {
+		path := filepath.Join(source, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		clusters = append(clusters, LocationCluster{Name: fmt.Sprintf("cluster-%d", i), Images: []string{path}})
+	}
+	if _, err := org.organizeByLocationClusters(context.Background(), clusters); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	filepath.WalkDir(output, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && filepath.Ext(path) == ".jpg" {
+			names = append(names, entry.Name())
+		}
+		return err
+	})
+	if want := []string{"0001.jpg", "0002.jpg"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("copied as %v, want %v", names, want)
+	}
+}
+
+func TestNoGPSLandsInNoLocation(t *testing.T) {
+	source, output := t.TempDir(), t.TempDir()
+	org := NewOrganizer(nil)
+	org.sourceFolder, org.outputFolder = source, output
+	org.folderSequence = make(map[string]int)
+	org.manifest, _ = LoadManifest(output)
+
+	path := filepath.Join(source, "IMG_20240315_143022.jpg")
+	if err := os.WriteFile(path, exifJPEG(t, map[exif.FieldName]string{exif.DateTimeOriginal: "2023:07:01 10:00:00"}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := org.extractImageInfo(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clusters := ClusterImages([]*ImageInfo{info}, org.locationSensitivity, org.clusterStrategy())
+	if len(clusters) != 1 || clusters[0].Name != NoLocationClusterName || clusters[0].HasLocation || clusters[0].CenterLat != 0 || clusters[0].CenterLng != 0 {
+		t.Fatalf("clustered as %+v, want one %s cluster without a location", clusters, NoLocationClusterName)
+	}
+
+	if _, err := org.organizeByLocationClusters(context.Background(), clusters); err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2023, 7, 1, 10, 0, 0, 0, time.UTC)
+	want := filepath.Join(output, NoLocationClusterName, org.dateFolderSegment(date, org.dateGranularity), filepath.Base(path))
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("not copied to %s: %v", want, err)
+	}
+}
+
+func TestKeepSourceFolders(t *testing.T) {
+	source, output := filepath.Join(string(filepath.Separator), "photos"), filepath.Join(string(filepath.Separator), "organized")
+	org := NewOrganizer(nil)
+	org.sourceFolder, org.keepSourceFolders = source, true
+	date := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
+	dated := filepath.Join(output, "Paris", org.dateFolderSegment(date, org.dateGranularity))
+
+	tests := []struct {
+		path    string
+		undated bool
+		want    string
+	}{
+		{"Vacation/Day1/IMG_1.jpg", false, filepath.Join(dated, "Vacation", "Day1")},
+		{"Vacation/IMG_2.jpg", false, filepath.Join(dated, "Vacation")},
+		{"IMG_3.jpg", false, dated},
+		// Set aside for sorting by hand, so kept flat
+		{"Vacation/Day1/IMG_4.jpg", true, filepath.Join(output, UndatedFolder)},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(source, filepath.FromSlash(tt.path))
+		info := &ImageInfo{OriginalPath: path, Date: date, Location: "Paris", Undated: tt.undated, SourceDir: org.sourceRelativeDir(path)}
+		if got := org.plannedFolder(output, info, nil); got != tt.want {
+			t.Errorf("%s placed in %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSameNameDifferentContent(t *testing.T) {
+	source, output := t.TempDir(), t.TempDir()
+	organize := func(cluster LocationCluster) {
+		org := NewOrganizer(nil)
+		org.outputFolder = output
+		org.flattenByDate, org.locationAnnotation = true, AnnotateNone
+		org.folderSequence = make(map[string]int)
+		org.manifest, _ = LoadManifest(output)
+		if _, err := org.organizeByLocationClusters(context.Background(), []LocationCluster{cluster}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cluster LocationCluster
+	for _, name := range []string{"IMG_20240315_090000.jpg", "IMG_20240315_120000.jpg"} {
+		path := filepath.Join(source, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cluster.Images = append(cluster.Images, path)
+	}
+	cluster.Name = NoLocationClusterName
+	organize(cluster)
+
+	// The same names again, one with the same content and one a different file
+	changed := filepath.Join(source, "IMG_20240315_120000.jpg")
+	if err := os.WriteFile(changed, []byte("another photo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	organize(cluster)
+
+	copies := make(map[string]string)
+	filepath.WalkDir(output, func(path string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() && filepath.Ext(path) == ".jpg" {
+			data, _ := os.ReadFile(path)
+			copies[entry.Name()] = string(data)
+		}
+		return err
+	})
+	want := map[string]string{
+		"IMG_20240315_090000.jpg":   "IMG_20240315_090000.jpg",
+		"IMG_20240315_120000.jpg":   "IMG_20240315_120000.jpg",
+		"IMG_20240315_120000_1.jpg": "another photo",
+	}
+	if !reflect.DeepEqual(copies, want) {
+		t.Errorf("output holds %v, want %v", copies, want)
+	}
+}
+
+func TestNearestInTimeWindow(t *testing.T) {
+	noon := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	sorted := []timedImage{
+		{Path: "morning.jpg", Date: noon.Add(-2 * time.Hour)},
+		{Path: "noon.jpg", Date: noon},
+		{Path: "afternoon.jpg", Date: noon.Add(2 * time.Hour)},
+	}
+	tests := []struct {
+		name string
+		date time.Time
+		want string // Empty when nothing is within the window
+	}{
+		{"same time", noon, "noon.jpg"},
+		{"at the window's edge", noon.Add(time.Hour), "noon.jpg"},
+		{"at the edge before the first", noon.Add(-3 * time.Hour), "morning.jpg"},
+		{"just past the window", noon.Add(-3*time.Hour - time.Second), ""},
+		{"past the last", noon.Add(3*time.Hour + time.Second), ""},
+		{"closer to the later", noon.Add(time.Hour + time.Second), "afternoon.jpg"},
+	}
+	for _, tt := range tests {
+		got, ok := nearestInTime(sorted, tt.date, time.Hour)
+		if ok != (tt.want != "") || got.Path != tt.want {
+			t.Errorf("%s: borrowed from %q (found %v), want %q", tt.name, got.Path, ok, tt.want)
+		}
+	}
+	if _, ok := nearestInTime(nil, noon, time.Hour); ok {
+		t.Error("borrowed a location with no geotagged photos")
+	}
+}
+
+func TestSparseDateFolderThreshold(t *testing.T) {
+	org := NewOrganizer(nil)
+	org.outputFolder = t.TempDir()
+	org.collapseSparseDates, org.sparseDateThreshold, org.dateGranularity = true, 3, GranularityDay
+
+	dated := func(day, count int) []*ImageInfo {
+		var infos []*ImageInfo
+		for i := 0; i < count; i++ {
+			infos = append(infos, &ImageInfo{
+				OriginalPath: fmt.Sprintf("/source/%d-%d.jpg", day, i),
+				Location:     "Paris",
+				Date:         time.Date(2024, 3, day, 12, i, 0, 0, time.UTC),
+			})
+		}
+		return infos
+	}
+	below, at := dated(15, 2), dated(16, 3) // One file short of the threshold, and exactly at it
+	sparse := org.sparseDateFolders([][]*ImageInfo{append(below, at...)})
+
+	belowFolder := org.destinationFolder(org.outputFolder, below[0], GranularityDay)
+	if len(sparse) != 1 || !sparse[belowFolder] {
+		t.Fatalf("sparse folders %v, want only %s", sparse, belowFolder)
+	}
+	if got, want := org.plannedFolder(org.outputFolder, below[0], sparse), org.destinationFolder(org.outputFolder, below[0], GranularityMonth); got != want {
+		t.Errorf("file below the threshold placed in %s, want %s", got, want)
+	}
+	if got, want := org.plannedFolder(org.outputFolder, at[0], sparse), org.destinationFolder(org.outputFolder, at[0], GranularityDay); got != want {
+		t.Errorf("file at the threshold placed in %s, want %s", got, want)
+	}
+
+	// Files of another cluster in the same folder count towards it
+	org.flattenByDate = true
+	other := dated(15, 1)
+	other[0].Location = "Lyon"
+	if sparse := org.sparseDateFolders([][]*ImageInfo{below, other}); len(sparse) != 0 {
+		t.Errorf("flattened folder with files from two clusters collapsed: %v", sparse)
+	}
+}
+
+func TestElevationFolders(t *testing.T) {
+	org := NewOrganizer(nil)
+	org.elevationFolders, org.dateGranularity = true, GranularityYear
+	date := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	year := org.dateFolderSegment(date, GranularityYear)
+	tests := []struct {
+		band      float64
+		elevation float64
+		has       bool
+		want      string
+	}{
+		{0, 1234, true, filepath.Join("out", "Alps", "1200m", year)},
+		{500, 1234, true, filepath.Join("out", "Alps", "1000m", year)},
+		{0, -12, true, filepath.Join("out", "Alps", "-100m", year)},
+		{0, 0, false, filepath.Join("out", "Alps", year)}, // No altitude, no elevation folder
+	}
+	for _, tt := range tests {
+		org.elevationBand = tt.band
+		info := &ImageInfo{Location: "Alps", Date: date, Elevation: tt.elevation, HasElevation: tt.has}
+		if got := org.destinationFolder("out", info, GranularityYear); got != tt.want {
+			t.Errorf("%.0f m in %.0f m bands placed in %s, want %s", tt.elevation, tt.band, got, tt.want)
+		}
+	}
+}
+
+// checkExifToolMetadata reports how got differs from want
+func checkExifToolMetadata(t *testing.T, name string, got, want ExifToolMetadata) {
+	t.Helper()
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	if got.Make != want.Make || got.Model != want.Model {
+		t.Errorf("%s: camera %q %q, want %q %q", name, got.Make, got.Model, want.Make, want.Model)
+	}
+	if !got.Date.Equal(want.Date) || got.Date.Format(time.RFC3339Nano) != want.Date.Format(time.RFC3339Nano) || got.DateHasZone != want.DateHasZone {
+		t.Errorf("%s: dated %v (zoned %v), want %v (zoned %v)", name, got.Date, got.DateHasZone, want.Date, want.DateHasZone)
+	}
+	if got.HasGPS != want.HasGPS || !near(got.Latitude, want.Latitude) || !near(got.Longitude, want.Longitude) {
+		t.Errorf("%s: at %v, %v (GPS %v), want %v, %v (GPS %v)", name, got.Latitude, got.Longitude, got.HasGPS, want.Latitude, want.Longitude, want.HasGPS)
+	}
+	if got.HasAltitude != want.HasAltitude || !near(got.Altitude, want.Altitude) || got.HasGPSError != want.HasGPSError || !near(got.GPSError, want.GPSError) {
+		t.Errorf("%s: altitude %v (%v), error %v (%v), want %v (%v), %v (%v)", name, got.Altitude, got.HasAltitude,
+			got.GPSError, got.HasGPSError, want.Altitude, want.HasAltitude, want.GPSError, want.HasGPSError)
+	}
+}
+
+func TestParseExifToolOutput(t *testing.T) {
+	tests := []struct {
+		name, output string
+		want         ExifToolMetadata
+	}{
+		{"iPhone video", `Make                            : Apple
+Model                           : iPhone 15 Pro
+Create Date                     : 2024:03:15 13:30:22
+Media Create Date               : 2024:03:15 13:30:22
+Creation Date                   : 2024:03:15 14:30:22.5+01:00
+GPS Coordinates                 : 48.8584 2.2945 35.1
+`, ExifToolMetadata{
+			// Create Date comes before the zoned Creation Date
+			Make: "Apple", Model: "iPhone 15 Pro", Date: time.Date(2024, 3, 15, 13, 30, 22, 0, time.UTC),
+			HasGPS: true, Latitude: 48.8584, Longitude: 2.2945, Altitude: 35.1, HasAltitude: true,
+		}},
+		{"video with only a zoned date", `Model                           : Pixel 8
+Creation Date                   : 2024:03:15 14:30:22.5+01:00
+Location ISO6709                : +48.8584+002.2945/
+`, ExifToolMetadata{
+			Model: "Pixel 8", Date: time.Date(2024, 3, 15, 14, 30, 22, 5e8, time.FixedZone("", 3600)), DateHasZone: true,
+			HasGPS: true, Latitude: 48.8584, Longitude: 2.2945,
+		}},
+		{"HEIC", `Make                            : Apple
+Camera Model Name               : iPhone 15 Pro
+Model                           : ignored, the EXIF name wins
+Date/Time Original              : 2024:03:15 14:30:22
+Sub Sec Time Original           : 123
+Create Date                     : 2024:03:15 14:31:00
+GPS Latitude Ref                : N
+GPS Longitude Ref               : W
+GPS Latitude                    : 37.7749
+GPS Longitude                   : -122.4194
+GPS Altitude                    : 10.5
+GPS Altitude Ref                : 1
+GPS Horizontal Positioning Error: 4.2
+GPS Dilution Of Precision       : 9
+`, ExifToolMetadata{
+			Make: "Apple", Model: "iPhone 15 Pro", Date: time.Date(2024, 3, 15, 14, 30, 22, 123e6, time.UTC),
+			HasGPS: true, Latitude: 37.7749, Longitude: -122.4194, Altitude: -10.5, HasAltitude: true, GPSError: 4.2, HasGPSError: true,
+		}},
+		{"HEIC without a capture date", `Make                            : samsung
+Camera Model Name               : SM-S918B
+Create Date                     : 2024:03:15 14:30:22
+GPS Dilution Of Precision       : 2
+`, ExifToolMetadata{
+			// Without a position the precision means nothing
+			Make: "samsung", Model: "SM-S918B", Date: time.Date(2024, 3, 15, 14, 30, 22, 0, time.UTC),
+		}},
+	}
+	for _, tt := range tests {
+		checkExifToolMetadata(t, tt.name, parseExifToolOutput(tt.output), tt.want)
+	}
+}
+
+func TestParseExifToolCoordinates(t *testing.T) {
+	date := time.Date(2024, 3, 15, 14, 30, 22, 0, time.UTC)
+	dms := func(degrees, minutes, seconds float64) float64 { return degrees + minutes/60 + seconds/3600 }
+	tests := []struct {
+		name, output string
+		want         ExifToolMetadata
+	}{
+		// -n prints signed decimals, with refs that only repeat the sign
+		{"decimal", `Date/Time Original              : 2024:03:15 14:30:22
+GPS Latitude Ref                : S
+GPS Longitude Ref               : E
+GPS Latitude                    : -33.8568
+GPS Longitude                   : 151.2153
+GPS Altitude                    : 58
+GPS Altitude Ref                : 0
+`, ExifToolMetadata{Date: date, HasGPS: true, Latitude: -33.8568, Longitude: 151.2153, Altitude: 58, HasAltitude: true}},
+		// Without -n, unsigned degrees, minutes and seconds, signed by the refs
+		{"DMS with refs", `Date/Time Original              : 2024:03:15 14:30:22
+GPS Latitude Ref                : South
+GPS Longitude Ref               : West
+GPS Latitude                    : 33 deg 51' 24.48"
+GPS Longitude                   : 70 deg 38' 59.10"
+`, ExifToolMetadata{Date: date, HasGPS: true, Latitude: -dms(33, 51, 24.48), Longitude: -dms(70, 38, 59.10)}},
+		{"DMS with the direction inline", `Date/Time Original              : 2024:03:15 14:30:22
+GPS Latitude                    : 37 deg 46' 29.64" N
+GPS Longitude                   : 122 deg 25' 9.84" W
+`, ExifToolMetadata{Date: date, HasGPS: true, Latitude: dms(37, 46, 29.64), Longitude: -dms(122, 25, 9.84)}},
+		// Only the first ": " separates a tag from its value
+		{"values with colons", `======== /photos/IMG_0001.HEIC
+Make                            : Canon
+Camera Model Name               : EOS R5: Mark II
+Date/Time Original              : 2024:03:15 14:30:22+09:00
+Date/Time Original              : 2020:01:01 00:00:00
+GPS Latitude                    : 35.6586
+GPS Longitude                   : 139.7454
+`, ExifToolMetadata{Make: "Canon", Model: "EOS R5: Mark II", Date: time.Date(2024, 3, 15, 14, 30, 22, 0, time.FixedZone("", 9*3600)),
+			DateHasZone: true, HasGPS: true, Latitude: 35.6586, Longitude: 139.7454}},
+		// Receivers without a fix write zeroes
+		{"no fix", `GPS Latitude                    : 0
+GPS Longitude                   : 0
+`, ExifToolMetadata{}},
+	}
+	for _, tt := range tests {
+		checkExifToolMetadata(t, tt.name, parseExifToolOutput(tt.output), tt.want)
+	}
+}
+
+func TestParseGPSCoordinates(t *testing.T) {
+	tests := []struct {
+		value               string
+		lat, lng, altitude  float64
+		hasAltitude, failed bool
+	}{
+		{value: "+37.7749-122.4194+010.123/", lat: 37.7749, lng: -122.4194, altitude: 10.123, hasAltitude: true},
+		{value: "+37.7749-122.4194/", lat: 37.7749, lng: -122.4194},
+		{value: "-33.8688+151.2093-002.5CRSWGS_84/", lat: -33.8688, lng: 151.2093, altitude: -2.5, hasAltitude: true},
+		{value: "+3746.494-12225.164/", lat: 37 + 46.494/60, lng: -(122 + 25.164/60)},                        // Degrees and minutes
+		{value: "+374629.64-1222509.84/", lat: 37 + 46/60.0 + 29.64/3600, lng: -(122 + 25/60.0 + 9.84/3600)}, // And seconds
+		{value: "37.7749 -122.4194 10.123", lat: 37.7749, lng: -122.4194, altitude: 10.123, hasAltitude: true},
+		{value: `37 deg 46' 29.64" N, 122 deg 25' 9.84" W, 10.1 m Below Sea Level`,
+			lat: 37 + 46/60.0 + 29.64/3600, lng: -(122 + 25/60.0 + 9.84/3600), altitude: -10.1, hasAltitude: true},
+		{value: "+97.0000+010.0000/", failed: true}, // Latitude out of range
+		{value: "+3761.0-12225.1/", failed: true},   // 61 minutes
+		{value: "", failed: true},
+	}
+	for _, tt := range tests {
+		lat, lng, altitude, hasAltitude, err := parseGPSCoordinates(tt.value)
+		if tt.failed {
+			if err == nil {
+				t.Errorf("%q parsed as %v, %v, want an error", tt.value, lat, lng)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.value, err)
+			continue
+		}
+		if math.Abs(lat-tt.lat) > 1e-9 || math.Abs(lng-tt.lng) > 1e-9 || hasAltitude != tt.hasAltitude || math.Abs(altitude-tt.altitude) > 1e-9 {
+			t.Errorf("%q parsed as %v, %v, altitude %v (%v), want %v, %v, altitude %v (%v)",
+				tt.value, lat, lng, altitude, hasAltitude, tt.lat, tt.lng, tt.altitude, tt.hasAltitude)
+		}
+	}
+}
+
+func TestMarkUndatedFiles(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	trip := now.AddDate(-1, 0, 0)
+	file := func(path string, date time.Time, source string) *ImageInfo {
+		return &ImageInfo{OriginalPath: path, Date: date, DateSource: source}
+	}
+
+	// The dated photo is in another cluster than the files beside it, as neighbors
+	// are found across clusters
+	dated := []*ImageInfo{file("/trip/photo.jpg", trip, DateSourceMetadata)}
+	others := []*ImageInfo{
+		file("/trip/at-gap.jpg", trip.Add(UndatedNeighborGap), DateSourceModTime),
+		file("/trip/past-gap.jpg", trip.Add(UndatedNeighborGap+time.Second), DateSourceModTime),
+		file("/trip/older.jpg", trip.AddDate(0, -6, 0), DateSourceModTime),
+		file("/phone/recent.jpg", now, DateSourceMetadata), // Dated properly, so never undated
+
+		// Without dated files beside them, only recent modification times are suspect
+		file("/downloads/fresh.jpg", now.Add(-time.Hour), DateSourceModTime),
+		file("/downloads/week-old.jpg", now.Add(-UndatedRecentAge), DateSourceModTime),
+	}
+	want := map[string]bool{"/trip/past-gap.jpg": true, "/downloads/fresh.jpg": true}
+
+	if got := markUndatedFiles([][]*ImageInfo{dated, others}, now); got != len(want) {
+		t.Errorf("marked %d files undated, want %d", got, len(want))
+	}
+	for _, info := range append(dated, others...) {
+		if info.Undated != want[info.OriginalPath] {
+			t.Errorf("%s undated %v, want %v", info.OriginalPath, info.Undated, want[info.OriginalPath])
+		}
+	}
+}
+
+// exifJPEG returns a small JPEG image carrying an EXIF block with the given ASCII tags
+// of the EXIF sub-IFD, such as exif.DateTimeOriginal
+func exifJPEG(t *testing.T, tags map[exif.FieldName]string) []byte {
+	t.Helper()
+	ids := map[exif.FieldName]uint16{exif.DateTimeOriginal: 0x9003, exif.SubSecTimeOriginal: 0x9291}
+	var entries []uint16
+	for name := range tags {
+		entries = append(entries, ids[name])
+	}
+	slices.Sort(entries)
+	values := make(map[uint16]string)
+	for name, value := range tags {
+		values[ids[name]] = value + "\x00"
+	}
+
+	// A little-endian TIFF header, then IFD0 pointing to the EXIF sub-IFD, whose
+	// values longer than four bytes follow it
+	le := binary.LittleEndian
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	exifIFD := uint32(8 + 2 + 12 + 4)
+	tiff = le.AppendUint16(tiff, 1)
+	tiff = le.AppendUint16(tiff, 0x8769) // ExifIFDPointer
+	tiff = le.AppendUint16(tiff, 4)      // LONG
+	tiff = le.AppendUint32(tiff, 1)
+	tiff = le.AppendUint32(tiff, exifIFD)
+	tiff = le.AppendUint32(tiff, 0)
+
+	data := exifIFD + 2 + 12*uint32(len(entries)) + 4
+	var extra []byte
+	tiff = le.AppendUint16(tiff, uint16(len(entries)))
+	for _, id := range entries {
+		value := values[id]
+		tiff = le.AppendUint16(tiff, id)
+		tiff = le.AppendUint16(tiff, 2) // ASCII
+		tiff = le.AppendUint32(tiff, uint32(len(value)))
+		if len(value) <= 4 {
+			tiff = append(tiff, (value + "\x00\x00\x00")[:4]...)
+			continue
+		}
+		tiff = le.AppendUint32(tiff, data+uint32(len(extra)))
+		extra = append(extra, value...)
+	}
+	tiff = le.AppendUint32(tiff, 0)
+	return tiffJPEG(t, append(tiff, extra...))
+}
+
+// tiffJPEG returns a small JPEG image whose EXIF block is the TIFF structure tiff
+func tiffJPEG(t *testing.T, tiff []byte) []byte {
+	t.Helper()
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, image.NewGray(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	app1 := append([]byte("Exif\x00\x00"), tiff...)
+	file := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	file = binary.BigEndian.AppendUint16(file, uint16(2+len(app1)))
+	file = append(file, app1...)
+	return append(file, encoded.Bytes()[2:]...) // The image, after its start marker
+}
+
+func TestMalformedExif(t *testing.T) {
+	dated := exifJPEG(t, map[exif.FieldName]string{exif.DateTimeOriginal: "2023:07:01 10:00:00"})
+	const tiffStart = 4 + 2 + 6 // Start marker, APP1 marker and length, "Exif\x00\x00"
+
+	// The APP1 segment claiming only part of the EXIF sub-IFD, with the image after it
+	shortSegment := bytes.Clone(dated)
+	binary.BigEndian.PutUint16(shortSegment[4:], 2+6+30)
+	shortSegment = append(shortSegment[:tiffStart+30], dated[tiffStart+8+2+12+4+2+12+4+20:]...)
+	// The EXIF sub-IFD pointer past the end of the block
+	badPointer := bytes.Clone(dated)
+	binary.LittleEndian.PutUint32(badPointer[tiffStart+8+2+8:], 0xFFFFFF00)
+
+	fixtures := map[string][]byte{
+		"truncated":    dated[:tiffStart+30], // The file cut off inside the EXIF sub-IFD
+		"shortSegment": shortSegment,
+		"badPointer":   badPointer,
+	}
+	dir := t.TempDir()
+	for name, fixture := range fixtures {
+		path := filepath.Join(dir, "IMG_20240315_143022_"+name+".jpg")
+		if err := os.WriteFile(path, fixture, 0644); err != nil {
+			t.Fatal(err)
+		}
+		info, err := NewOrganizer(nil).extractImageInfo(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if info.DateSource != DateSourceFilename || info.Date.Format(time.DateTime) != "2024-03-15 14:30:22" {
+			t.Errorf("%s: dated %s from %s, want the filename date", name, info.Date.Format(time.DateTime), info.DateSource)
+		}
+	}
+
+	// A decoder panic comes back as errMalformedExif
+	err := guardExif(func() error {
+		var tags []string
+		_ = tags[1]
+		return nil
+	})
+	if !errors.Is(err, errMalformedExif) {
+		t.Errorf("a panic returned %v, want errMalformedExif", err)
+	}
+}
+
+func TestDatePriority(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "IMG_20240315_143022.jpg")
+	if err := os.WriteFile(path, exifJPEG(t, map[exif.FieldName]string{exif.DateTimeOriginal: "2023:07:01 10:00:00"}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2022, 1, 1, 8, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		priority []string
+		source   string
+		date     string // Wall clock of the date chosen
+	}{
+		{DefaultDatePriority, DateSourceMetadata, "2023-07-01 10:00:00"},
+		{[]string{DateSourceFilename, DateSourceMetadata, DateSourceModTime}, DateSourceFilename, "2024-03-15 14:30:22"},
+		{[]string{DateSourceModTime, DateSourceFilename, DateSourceMetadata}, DateSourceModTime, modified.Local().Format(time.DateTime)},
+	}
+	for _, tt := range tests {
+		org := NewOrganizer(nil)
+		org.datePriority = tt.priority
+		info, err := org.extractImageInfo(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.DateSource != tt.source || info.Date.Format(time.DateTime) != tt.date {
+			t.Errorf("with %v: dated %s from %s, want %s from %s", tt.priority, info.Date.Format(time.DateTime), info.DateSource, tt.date, tt.source)
+		}
+	}
+}
+
+func TestSubSecondCaptureOrder(t *testing.T) {
+	// Three shots in the same second, named out of capture order
+	dir := t.TempDir()
+	subsecs := map[string]string{"a.jpg": "7", "b.jpg": "450", "c.jpg": "12"} // 700, 450 and 120 ms
+	var cluster LocationCluster
+	for name, subsec := range subsecs {
+		path := filepath.Join(dir, name)
+		fixture := exifJPEG(t, map[exif.FieldName]string{exif.DateTimeOriginal: "2024:03:15 14:30:22", exif.SubSecTimeOriginal: subsec})
+		if err := os.WriteFile(path, fixture, 0644); err != nil {
+			t.Fatal(err)
+		}
+		cluster.Images = append(cluster.Images, path)
+	}
+
+	for run := 0; run < 3; run++ {
+		infos, skipped := NewOrganizer(nil).readClusterImages(cluster)
+		if skipped != 0 {
+			t.Fatalf("%d files skipped", skipped)
+		}
+		var order []string
+		for _, info := range infos {
+			order = append(order, filepath.Base(info.OriginalPath))
+		}
+		if want := []string{"c.jpg", "b.jpg", "a.jpg"}; !slices.Equal(order, want) {
+			t.Fatalf("sorted as %v, want %v", order, want)
+		}
+		if got := infos[0].Date.Nanosecond(); got != 120*int(time.Millisecond) {
+			t.Errorf("first shot at %d ns past the second, want 120 ms", got)
+		}
+		rand.Shuffle(len(cluster.Images), func(i, j int) {
+			cluster.Images[i], cluster.Images[j] = cluster.Images[j], cluster.Images[i]
+		})
+	}
+}
+
+func TestBorrowedLocationsIgnoreRecordingOrder(t *testing.T) {
+	noon := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	photo := func(path string, lng float64) *ImageInfo {
+		return &ImageInfo{OriginalPath: path, Date: noon, HasGPS: true, Latitude: 10, Longitude: lng}
+	}
+	// Two geotagged photos taken at the same moment in different places
+	infos := []*ImageInfo{photo("a.jpg", 10), photo("b.jpg", 20), {OriginalPath: "no-gps.jpg", Date: noon.Add(time.Minute)}}
+	borrowed := ""
+	for _, order := range [][]int{{0, 1, 2}, {1, 0, 2}, {2, 1, 0}} {
+		grid := newClusterGrid(0.001, ClusterStrategy{NoGPSPolicy: NoGPSNearestInTime})
+		for _, i := range order {
+			grid.AddImage(infos[i])
+		}
+		grid.BorrowNearestLocations(time.Hour)
+
+		from := ""
+		for key, cell := range grid.cells {
+			if slices.Contains(cell.Images, "no-gps.jpg") {
+				from = key
+			}
+		}
+		if borrowed == "" {
+			borrowed = from
+		} else if from != borrowed {
+			t.Errorf("added in order %v: placed in cell %s, but in %s in another order", order, from, borrowed)
+		}
+	}
+}
+
+func TestBracketInTime(t *testing.T) {
+	noon := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	sorted := []timedImage{
+		{Path: "first.jpg", Date: noon},
+		{Path: "same-a.jpg", Date: noon.Add(time.Hour)},
+		{Path: "same-b.jpg", Date: noon.Add(time.Hour)},
+		{Path: "last.jpg", Date: noon.Add(2 * time.Hour)},
+	}
+	tests := []struct {
+		name          string
+		date          time.Time
+		before, after string // Both empty when date is out of range
+	}{
+		{"at the first", noon, "first.jpg", "first.jpg"},
+		{"at the last", noon.Add(2 * time.Hour), "last.jpg", "last.jpg"},
+		{"before the first", noon.Add(-time.Second), "", ""},
+		{"after the last", noon.Add(2*time.Hour + time.Second), "", ""},
+		{"between", noon.Add(30 * time.Minute), "first.jpg", "same-a.jpg"},
+		{"at two at once", noon.Add(time.Hour), "same-a.jpg", "same-a.jpg"},
+		{"just after two at once", noon.Add(time.Hour + time.Second), "same-b.jpg", "last.jpg"},
+	}
+	for _, tt := range tests {
+		before, after, ok := bracketInTime(sorted, tt.date)
+		if ok != (tt.before != "") || before.Path != tt.before || after.Path != tt.after {
+			t.Errorf("%s: bracketed by %q and %q (found %v), want %q and %q", tt.name, before.Path, after.Path, ok, tt.before, tt.after)
+		}
+	}
+	if _, _, ok := bracketInTime(nil, noon); ok {
+		t.Error("bracketed a date with no geotagged photos")
+	}
+}
+
+func TestInterpolateLocationsAtTheEnds(t *testing.T) {
+	noon := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	located := func(path string, date time.Time, lng float64) *ImageInfo {
+		return &ImageInfo{OriginalPath: path, Date: date, HasGPS: true, Latitude: 10, Longitude: lng}
+	}
+	geotagged := []*ImageInfo{
+		located("start.jpg", noon, 10),
+		located("end.jpg", noon.Add(time.Hour), 11),
+		located("later.jpg", noon.Add(4*time.Hour), 20), // Too long after end.jpg to interpolate between
+	}
+	tests := []struct {
+		name string
+		date time.Time
+		lng  float64 // NaN when not located
+	}{
+		{"at the start", noon, 10},
+		{"at the end", noon.Add(time.Hour), 11},
+		{"halfway", noon.Add(30 * time.Minute), 10.5},
+		{"before the start", noon.Add(-time.Minute), math.NaN()},
+		{"after the last", noon.Add(5 * time.Hour), math.NaN()},
+		{"across a gap over the window", noon.Add(2 * time.Hour), math.NaN()},
+	}
+	for _, tt := range tests {
+		grid := newClusterGrid(0.001, ClusterStrategy{NoGPSPolicy: NoGPSInterpolate})
+		for _, info := range geotagged {
+			grid.AddImage(info)
+		}
+		grid.AddImage(&ImageInfo{OriginalPath: "no-gps.jpg", Date: tt.date})
+
+		estimates := grid.InterpolateLocations(2 * time.Hour)
+		switch {
+		case math.IsNaN(tt.lng) && len(estimates) != 0:
+			t.Errorf("%s: located at %v, %v, want no location", tt.name, estimates[0].Lat, estimates[0].Lng)
+		case !math.IsNaN(tt.lng) && (len(estimates) != 1 || math.Abs(estimates[0].Lng-tt.lng) > 1e-9 || estimates[0].Lat != 10):
+			t.Errorf("%s: located as %v, want 10, %v", tt.name, estimates, tt.lng)
+		}
+	}
+}
+
+func TestOverlongDestinationIsExplained(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "photo.jpg")
+	if err := os.WriteFile(src, []byte("photo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Too long for a file name on every filesystem the organizer writes to
+	name := strings.Repeat("x", 300) + ".jpg"
+	_, _, err := NewOrganizer(nil).copyFile(&ImageInfo{OriginalPath: src}, t.TempDir(), name)
+	if err == nil || !isPathTooLong(err) || !strings.Contains(err.Error(), "too long for the output drive") {
+		t.Errorf("copying to an overlong name returned %v, want an explained path length error", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Keyboard shortcuts, using Cmd on macOS and Ctrl elsewhere
+var (
+	runShortcut         = &desktop.CustomShortcut{KeyName: fyne.KeyR, Modifier: fyne.KeyModifierShortcutDefault}
+	preferencesShortcut = &desktop.CustomShortcut{KeyName: fyne.KeyComma, Modifier: fyne.KeyModifierShortcutDefault}
+)
+
+// buildMainMenu creates the window's menu bar
+func (app *App) buildMainMenu() *fyne.MainMenu {
+	app.runItem = fyne.NewMenuItem("Run", app.startOrganizing)
+	app.runItem.Shortcut = runShortcut
+	app.cancelItem = fyne.NewMenuItem("Cancel (Esc)", app.cancelOrganizing)
+	app.cancelItem.Disabled = true
+	file := fyne.NewMenu("File",
+		fyne.NewMenuItem("Select Source Folder...", app.selectSourceFolder),
+		fyne.NewMenuItem("Select Source Archive...", app.selectSourceArchive),
+		fyne.NewMenuItem("Select Output Folder...", app.selectOutputFolder),
+		fyne.NewMenuItemSeparator(),
+		app.runItem,
+		app.cancelItem,
+	)
+
+	preferencesItem := fyne.NewMenuItem("Preferences...", app.showPreferences)
+	preferencesItem.Shortcut = preferencesShortcut
+
+	profileItem := fyne.NewMenuItem("Profile Runs", nil)
+	profileItem.Checked = app.cpuProfilePath != "" || app.memProfilePath != ""
+	profileItem.Action = func() {
+		profileItem.Checked = !profileItem.Checked
+		app.setProfiling(profileItem.Checked)
+		if profileItem.Checked {
+			app.safeLog(fmt.Sprintf("Profiling enabled: each run writes %s and %s\n", app.cpuProfilePath, app.memProfilePath))
+		} else {
+			app.safeLog("Profiling disabled\n")
+		}
+		app.window.MainMenu().Refresh()
+	}
+
+	help := fyne.NewMenu("Help",
+		fyne.NewMenuItem("ExifTool Status", app.showExifToolStatus),
+		fyne.NewMenuItem("Supported Formats", app.showSupportedFormats),
+		fyne.NewMenuItem("About Media Organizer", app.showAbout),
+	)
+
+	return fyne.NewMainMenu(file, fyne.NewMenu("Edit", preferencesItem), fyne.NewMenu("Debug", profileItem), help)
+}
+
+// addShortcuts registers the window's keyboard shortcuts. Native macOS menus handle
+// the menu shortcuts themselves, and a run already in progress ignores the run shortcut.
+func (app *App) addShortcuts() {
+	canvas := app.window.Canvas()
+	canvas.AddShortcut(runShortcut, func(fyne.Shortcut) {
+		app.startOrganizing()
+	})
+	canvas.AddShortcut(preferencesShortcut, func(fyne.Shortcut) {
+		app.showPreferences()
+	})
+	canvas.SetOnTypedKey(func(event *fyne.KeyEvent) {
+		if event.Name == fyne.KeyEscape {
+			app.cancelOrganizing()
+		}
+	})
+}
+
+// setRunning records whether a run is in progress and enables the run controls to
+// match. It must be called on the main goroutine.
+func (app *App) setRunning(running bool) {
+	app.running.Store(running)
+	if running {
+		app.startBtn.Disable()
+		app.cancelBtn.Enable()
+	} else {
+		app.startBtn.Enable()
+		app.cancelBtn.Disable()
+	}
+	app.runItem.Disabled = running
+	app.cancelItem.Disabled = !running
+	app.window.MainMenu().Refresh()
+}
+
+// cancelOrganizing stops the run in progress, if any
+func (app *App) cancelOrganizing() {
+	if !app.running.Load() {
+		return
+	}
+	app.safeLog("Cancelling the run...\n")
+	app.cancelBtn.Disable()
+	app.Cancel()
+}
+
+// showAbout shows what the application does and where it runs
+func (app *App) showAbout() {
+	dialog.ShowInformation("About Media Organizer",
+		fmt.Sprintf("Media Organizer sorts photos, videos and audio into folders by location and date.\n\nRunning on %s/%s, built with %s.",
+			runtime.GOOS, runtime.GOARCH, runtime.Version()), app.window)
+}
+
+// showExifToolStatus reports which exiftool is in use and its version, or how to
+// install it when none was found
+func (app *App) showExifToolStatus() {
+	if exiftoolPath == "" {
+		dialog.ShowInformation("ExifTool Status",
+			"ExifTool was not found, so GPS and dates of videos and some HEIC files can't be read.\n\n"+exiftoolInstallHint(), app.window)
+		return
+	}
+
+	// Asking for the version runs exiftool, so keep it off the main goroutine
+	path, source := exiftoolPath, exiftoolSource
+	go func() {
+		message := ""
+		if version, err := validateExifTool(path); err != nil {
+			message = fmt.Sprintf("ExifTool at %s (%s) is not working: %v", path, source, err)
+		} else {
+			message = fmt.Sprintf("ExifTool v%s is in use.\n\nLocation: %s (%s)", version, path, source)
+		}
+		app.runOnMain(func() {
+			dialog.ShowInformation("ExifTool Status", message, app.window)
+		})
+	}()
+}
+
+// showSupportedFormats lists the recognized extensions, which of them need exiftool and
+// whether it's available
+func (app *App) showSupportedFormats() {
+	go func() {
+		// Checking exiftool runs it, so keep it off the main goroutine
+		capabilities := app.Capabilities()
+		app.runOnMain(func() {
+			text := widget.NewLabel(capabilities.String())
+			text.TextStyle.Monospace = true
+			scroll := container.NewVScroll(text)
+			scroll.SetMinSize(fyne.NewSize(620, 420))
+			dialog.ShowCustom("Supported Formats", "Close", scroll, app.window)
+		})
+	}()
+}
+
+// exiftoolInstallHint tells how to install exiftool on this platform
+func exiftoolInstallHint() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Download it from https://exiftool.org/"
+	case "darwin":
+		return "Install it with: brew install exiftool"
+	case "linux":
+		return "Install it with: sudo apt-get install libimage-exiftool-perl"
+	}
+	return "See https://exiftool.org/ to install it"
+}
@@ -0,0 +1,629 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Phase identifies the stage an organization run is in
+type Phase int
+
+const (
+	PhaseDiscovering Phase = iota // Scanning the source folder for media files
+	PhaseExtracting               // Reading metadata from each file
+	PhaseClustering               // Grouping files by location
+	PhaseCopying                  // Copying files into the output folder
+	PhaseDone                     // Finished, failed or cancelled
+)
+
+// String returns the phase name used in logs
+func (p Phase) String() string {
+	switch p {
+	case PhaseDiscovering:
+		return "discovering"
+	case PhaseExtracting:
+		return "extracting"
+	case PhaseClustering:
+		return "clustering"
+	case PhaseCopying:
+		return "copying"
+	case PhaseDone:
+		return "done"
+	default:
+		return fmt.Sprintf("phase(%d)", int(p))
+	}
+}
+
+// ProgressObserver receives updates from an Organizer while it runs. Callbacks are made
+// from the organizer's goroutines, so implementations must be safe for concurrent use.
+type ProgressObserver interface {
+	// OnLog receives one log message, including its trailing newline
+	OnLog(message string)
+	// OnProgress reports how many of the discovered files have been read
+	OnProgress(processed, total int64)
+	// OnPhaseChange is called as a run moves from one phase to the next
+	OnPhaseChange(phase Phase)
+}
+
+// Event types reported to an EventObserver
+const (
+	EventPhase          = "phase"
+	EventFileProcessed  = "file_processed"
+	EventBatchDone      = "batch_done"
+	EventClusterCreated = "cluster_created"
+	EventFileCopied     = "file_copied"
+	EventError          = "error"
+	EventDone           = "done"
+)
+
+// Event is one machine-readable record of something significant happening in a run
+type Event struct {
+	Type        string    `json:"type"`
+	Time        time.Time `json:"timestamp"`
+	Phase       string    `json:"phase,omitempty"`
+	Path        string    `json:"path,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	Cluster     string    `json:"cluster,omitempty"`
+	Files       int       `json:"files,omitempty"`  // Files in a batch or cluster, or copied in total
+	Errors      int64     `json:"errors,omitempty"` // Errors so far, on batch and done events
+	Error       string    `json:"error,omitempty"`
+}
+
+// EventObserver is an optional extension of ProgressObserver for observers that want
+// structured events in addition to the human-readable log
+type EventObserver interface {
+	OnEvent(event Event)
+}
+
+// Organizer holds the settings and state of an organization run. It has no UI
+// dependencies; the GUI is one ProgressObserver, and other front ends supply their own.
+type Organizer struct {
+	observer            ProgressObserver
+	sourceFolder        string
+	outputFolder        string
+	locationSensitivity float64
+	decodeWorkers       int // Workers extracting metadata in-process
+	batchSize           int
+	autoBatchSize       bool   // Adapt the batch size to observed heap growth instead of using batchSize
+	memoryCeiling       uint64 // Heap size automatic batch sizing aims to stay under
+	exiftoolWorkers     int    // Workers for files read with exiftool, and the most exiftool processes at once
+	includeAudio        bool   // Organize audio files (voice memos, clips) alongside photos
+	followSymlinks      bool   // Descend into symlinked folders and files while scanning
+	skipJunkFiles       bool   // Ignore hidden files and OS junk like .DS_Store and Thumbs.db
+	separateByDevice    bool   // Add a camera model folder level
+	elevationFolders    bool   // Add an elevation folder level below the location
+	keepSourceFolders   bool   // Recreate source subfolders below each location/date folder
+	cameraFilter        string // Only organize files whose camera model contains this text
+	geoJSONPath         string // Where to write a GeoJSON map of the clusters (empty to skip)
+	noGPSPolicy         string // How files without GPS data are placed
+	noGPSWindow         time.Duration
+	elevationBand       float64
+	maxGPSError         float64 // Ignore GPS positions with a larger recorded error in meters (0 keeps them all)
+	datePriority        []string
+	nameDecimals        int    // Decimal places in coordinate folder names (AutoNameDecimals follows sensitivity)
+	organizeMode        string // Folder organization mode (location+date, date only, location only)
+	dateGranularity     string // Size of the date folder buckets (day, week, month, year)
+	collapseSparseDates bool   // Merge date folders with few files into a coarser bucket
+	sparseDateThreshold int    // Date folders with fewer files than this are collapsed
+	exifTimeZone        string // How to interpret EXIF timestamps without a timezone
+	conflictPolicy      string // What to do when a destination file already exists
+	copyMode            string // Copy, clone or hard-link files into the output
+	syncCopies          bool   // Flush each copy to disk before giving it its final name
+	burstPolicy         string // What happens to burst frames other than the best one
+	burstHeuristic      string // How the best frame of a burst is chosen
+	flattenByDate       bool   // Put every file in one date tree, ignoring location
+	locationAnnotation  string // How flattened files keep their location
+	annotationFormat    string // Filename format when annotating, using {name} and {location}
+	renameOnCopy        bool   // Rename copies using renameTemplate
+	renameTemplate      string // Filename template for renamed copies
+	useGPSTimeZone      bool   // Derive the capture timezone from GPS coordinates
+	unsortedUnreadable  bool   // Copy files exiftool couldn't read to UnsortedFolder
+	routeUndated        bool   // Copy files with only an implausible modification time to UndatedFolder
+	suspectDatePolicy   string // What happens to files dated in the future or before EarliestPlausibleDate
+	writeCopyMetadata   bool   // Write cluster names and estimated GPS into copies with exiftool
+	forceFullRun        bool   // Reprocess files the manifest lists as already organized
+	mergeLibrary        bool   // Reuse existing location folders that cover a cluster's center
+	dedupeClusters      bool   // Copy files with the same contents only once across all clusters
+	splitCells          bool   // Split clusters whose files form clearly separate places
+	minClusterSize      int    // Clusters with fewer files are merged into nearby ones; below 2 to keep them all
+	lookupCacheSize     int    // Grid cells whose location lookups are kept in memory
+
+	folderPreview     *FolderPreview
+	runStats          *RunStats
+	spatialGrid       *SpatialGrid
+	globalWorkerPool  *WorkerPool
+	cancelProcessing  context.CancelFunc
+	cancelMutex       sync.Mutex // Guards cancelProcessing, which Cancel reads from other goroutines
+	exiftoolSemaphore chan struct{}
+	handlers          map[string]registeredHandler // Metadata readers by lowercase extension
+	manifest          *Manifest                    // Files organized into the output folder so far
+	archive           *sourceArchive               // Open while the source being organized is an archive
+	zoneCache         *LookupCache[*time.Location] // Capture timezones by grid cell, for the current run
+
+	// Interpolated locations by source path, for writing into the copies
+	estimatedLocations map[string]locationEstimate
+	// Folders this run created in the output folder, removed at the end if left empty
+	createdFolders map[string]bool
+	// Files this run placed in each destination folder so far, for {sequence} in file names
+	folderSequence map[string]int
+	// Where to write CPU and memory profiles of each run; empty leaves profiling off
+	cpuProfilePath string
+	memProfilePath string
+	// Labeled places that name the clusters centered in them instead of their coordinates
+	namedPlaces []NamedPlace
+
+	// Thread-safe counters, always accessed atomically
+	processedFiles atomic.Int64
+	totalFiles     atomic.Int64
+	errorFiles     atomic.Int64
+	// Files and bytes the copy phase has done so far, out of the totals of its plan
+	copyFilesDone  atomic.Int64
+	copyFilesTotal atomic.Int64
+	copyBytesDone  atomic.Int64
+	copyBytesTotal atomic.Int64
+}
+
+// NewOrganizer creates an organizer with the default settings, reporting to observer
+func NewOrganizer(observer ProgressObserver) *Organizer {
+	org := &Organizer{
+		observer:            observer,
+		locationSensitivity: 0.001,            // Default ~100m sensitivity
+		decodeWorkers:       runtime.NumCPU(), // Use number of CPU cores
+		batchSize:           DefaultBatchSize, // Default batch size for memory management
+		memoryCeiling:       DefaultMemoryCeiling,
+		exiftoolWorkers:     min(runtime.NumCPU()*2, MaxDefaultExifToolWorkers), // exiftool waits on I/O more than the CPU
+		lookupCacheSize:     DefaultLookupCacheSize,
+		folderPreview:       NewFolderPreview(),
+		runStats:            NewRunStats(),
+		organizeMode:        ModeLocationAndDate, // Cluster by location, then date
+		dateGranularity:     GranularityDay,      // One folder per day
+		sparseDateThreshold: 3,                   // Collapse days with only one or two files
+		exifTimeZone:        TimeZoneLocal,       // Cameras usually record local time
+		useGPSTimeZone:      true,                // Place captures on the right local day
+		conflictPolicy:      ConflictRename,      // Never lose either file
+		copyMode:            CopyModeCopy,        // Independent copies work everywhere
+		burstPolicy:         BurstKeepBest,       // One keeper per burst
+		burstHeuristic:      BestSharpest,        // Skip the shaken frames
+		noGPSPolicy:         NoGPSFolder,         // Keep GPS-less files together
+		noGPSWindow:         time.Hour,           // Borrow within an hour of a geotagged shot
+		suspectDatePolicy:   SuspectDateFlag,     // Point them out without moving anything
+		locationAnnotation:  AnnotateFilename,    // Keep geodata visible when flattening
+		skipJunkFiles:       true,                // Don't vacuum up .DS_Store and friends
+		annotationFormat:    DefaultAnnotationFormat,
+		renameTemplate:      DefaultRenameTemplate,
+		datePriority:        slices.Clone(DefaultDatePriority), // Metadata, then filename, then file date
+		handlers:            make(map[string]registeredHandler),
+	}
+	org.registerBuiltinHandlers()
+	return org
+}
+
+// Validate checks the settings before a run, so mistakes surface before anything is copied
+func (org *Organizer) Validate() error {
+	if org.sourceFolder == "" {
+		return fmt.Errorf("please select a source folder")
+	}
+
+	if org.outputFolder == "" {
+		return fmt.Errorf("please select an output folder")
+	}
+
+	if stat, err := os.Stat(org.sourceFolder); err == nil && !stat.IsDir() && !isArchive(org.sourceFolder) {
+		return fmt.Errorf("the source must be a folder or a %s archive", ArchiveExtension)
+	}
+
+	// Organizing a folder into itself would re-discover its own output on every run
+	if isSameFolder(org.outputFolder, org.sourceFolder) {
+		return fmt.Errorf("the output folder must be different from the source folder")
+	}
+
+	// Catch template typos before anything is copied
+	if org.renameOnCopy {
+		if err := validateFilenameTemplate(org.renameTemplate, renameTokens); err != nil {
+			return fmt.Errorf("invalid filename template: %v", err)
+		}
+	}
+	if org.flattenByDate && org.locationAnnotation == AnnotateFilename && org.annotationFormat != "" {
+		if err := validateFilenameTemplate(org.annotationFormat, annotationTokens); err != nil {
+			return fmt.Errorf("invalid location filename format: %v", err)
+		}
+	}
+
+	// Fail now rather than file by file once copying starts
+	return checkWritable(org.outputFolder)
+}
+
+// Run organizes the source folder into the output folder. It returns context.Canceled
+// when the run was cancelled and an error when the source couldn't be scanned or the
+// output folder stopped being writable; per-file problems are logged and counted instead.
+func (org *Organizer) Run() error {
+	org.setPhase(PhaseDiscovering)
+	defer func() {
+		// Clean up worker pool, releasing any worker still blocked on a send
+		org.cancelMutex.Lock()
+		if org.cancelProcessing != nil {
+			org.cancelProcessing()
+			org.cancelProcessing = nil
+		}
+		org.cancelMutex.Unlock()
+		if org.globalWorkerPool != nil {
+			org.globalWorkerPool.Close()
+			org.globalWorkerPool.Wait()
+			org.globalWorkerPool = nil
+		}
+
+		// Whether the run finished, failed or was cancelled, don't leave empty folders behind
+		org.pruneCreatedFolders()
+		org.closeArchive()
+
+		org.setPhase(PhaseDone)
+	}()
+	defer org.startProfiling()()
+
+	org.safeLog("Starting media organization...\n")
+
+	if isWithinFolder(org.outputFolder, org.sourceFolder) {
+		org.safeLog("Warning: The output folder is inside the source folder; it will be excluded from the scan\n")
+	} else if isWithinFolder(org.sourceFolder, org.outputFolder) {
+		org.safeLog("Warning: The source folder is inside the output folder; organizing the output folder later will pick these files up again\n")
+	}
+
+	// Cancel stops the run from here on
+	ctx, cancel := context.WithCancel(context.Background())
+	org.cancelMutex.Lock()
+	org.cancelProcessing = cancel
+	org.cancelMutex.Unlock()
+
+	// Reset counters
+	org.processedFiles.Store(0)
+	org.totalFiles.Store(0)
+	org.errorFiles.Store(0)
+	org.copyFilesTotal.Store(0)
+
+	// Initialize spatial grid with current sensitivity
+	org.spatialGrid = newClusterGrid(org.locationSensitivity, org.clusterStrategy())
+	org.folderPreview.Reset()
+	org.runStats = NewRunStats()
+	org.estimatedLocations = make(map[string]locationEstimate)
+	org.createdFolders = make(map[string]bool)
+	org.folderSequence = make(map[string]int)
+	org.zoneCache = NewLookupCache[*time.Location](org.lookupCacheSize)
+
+	// Find all media files
+	mediaFiles, err := org.findMediaFiles(org.sourceFolder)
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Error finding media files: %v\n", err))
+		org.emit(Event{Type: EventError, Path: org.sourceFolder, Error: err.Error()})
+		return fmt.Errorf("finding media files: %w", err)
+	}
+
+	// Files copied by earlier runs into this output folder are skipped unless changed
+	org.manifest, err = LoadManifest(org.outputFolder)
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: %v; starting a new manifest\n", err))
+	}
+	org.manifest.stat = org.statSource
+	if org.forceFullRun {
+		org.safeLog("Full re-run: files organized by previous runs are processed again\n")
+	} else {
+		mediaFiles = org.skipOrganizedFiles(mediaFiles)
+	}
+
+	// Set total files for progress tracking
+	org.totalFiles.Store(int64(len(mediaFiles)))
+	org.runStats.TotalFiles = len(mediaFiles)
+	org.setPhase(PhaseExtracting)
+
+	org.safeLog(fmt.Sprintf("Found %d media files\n", len(mediaFiles)))
+	if org.autoBatchSize {
+		org.safeLog(fmt.Sprintf("Using %d decode threads and an automatic batch size (memory ceiling %s) for processing\n",
+			org.decodeWorkers, formatBytes(int64(org.memoryCeiling))))
+	} else {
+		org.safeLog(fmt.Sprintf("Using %d decode threads and batch size of %d for processing\n", org.decodeWorkers, org.batchSize))
+	}
+	org.safeLog(fmt.Sprintf("Using %d exiftool workers for videos and audio\n", org.exiftoolWorkers))
+
+	// Bound concurrent exiftool processes independently of the worker count
+	org.exiftoolSemaphore = make(chan struct{}, org.exiftoolWorkers)
+
+	// Create the worker pool the files stream through
+	// No more than the largest batch is ever in flight, so the queues never need to
+	// hold more
+	batchSize, maxBatchSize := org.batchSize, org.batchSize
+	if org.autoBatchSize {
+		batchSize, maxBatchSize = InitialAutoBatchSize, MaxAutoBatchSize
+	}
+	org.globalWorkerPool = NewWorkerPool(ctx, org.decodeWorkers, org.exiftoolWorkers, maxBatchSize)
+	org.globalWorkerPool.Start(org)
+
+	totalFiles := len(mediaFiles)
+	var dateOnlyImages []string
+	filteredFiles := 0
+
+	// Each file goes into the grid as soon as it is read, while the workers carry on
+	// with the next; the batch size only bounds how many files are in flight, and sets
+	// how often progress is reported and memory is checked
+	window := newExtractionWindow(batchSize)
+	results := org.extractStream(ctx, org.globalWorkerPool, mediaFiles, window)
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	heapBaseline := memStats.HeapAlloc
+	received, batchStart, batchErrors := 0, 0, 0
+	for result := range results {
+		received++
+		info := org.collectResult(result)
+		if info == nil {
+			batchErrors++
+		} else if !org.matchesCameraFilter(info) {
+			filteredFiles++
+		} else {
+			org.runStats.RecordImage(info)
+			// Date-only mode skips clustering entirely
+			if org.organizeMode == ModeDateOnly {
+				dateOnlyImages = append(dateOnlyImages, info.OriginalPath)
+			} else {
+				org.spatialGrid.AddImage(info)
+			}
+		}
+
+		if received-batchStart < batchSize && received < totalFiles {
+			continue
+		}
+		if batchErrors > 0 {
+			org.safeLog(fmt.Sprintf("Batch completed with %d errors\n", batchErrors))
+		}
+		org.safeLog(fmt.Sprintf("Files %d-%d of %d processed and clustered\n", batchStart+1, received, totalFiles))
+		org.emit(Event{Type: EventBatchDone, Files: received - batchStart, Errors: org.errorFiles.Load()})
+
+		runtime.ReadMemStats(&memStats)
+		heapPeak := memStats.HeapAlloc
+		runtime.GC() // Force garbage collection for large datasets
+		runtime.ReadMemStats(&memStats)
+
+		if org.autoBatchSize && received < totalFiles {
+			var perFile uint64
+			if heapPeak > heapBaseline {
+				perFile = (heapPeak - heapBaseline) / uint64(received-batchStart)
+			}
+			batchSize = adaptBatchSize(batchSize, perFile, memStats.HeapAlloc, org.memoryCeiling)
+			window.Resize(batchSize)
+			org.safeLog(fmt.Sprintf("Auto batch size: %d files (heap %s after the last batch, ceiling %s)\n",
+				batchSize, formatBytes(int64(heapPeak)), formatBytes(int64(org.memoryCeiling))))
+		}
+		heapBaseline = memStats.HeapAlloc
+		batchStart, batchErrors = received, 0
+	}
+	if ctx.Err() != nil {
+		org.safeLog("Processing cancelled\n")
+		return ctx.Err()
+	}
+
+	if filteredFiles > 0 {
+		org.safeLog(fmt.Sprintf("Skipped %d files not taken with a camera matching \"%s\"\n", filteredFiles, org.cameraFilter))
+	}
+
+	// Get final clusters from spatial grid, or a single location-less group in date-only mode
+	org.setPhase(PhaseClustering)
+	var finalClusters []LocationCluster
+	if org.organizeMode == ModeDateOnly {
+		sort.Strings(dateOnlyImages)
+		finalClusters = []LocationCluster{{Images: dateOnlyImages}}
+		org.safeLog("Date-only mode: skipping location clustering\n")
+	} else {
+		org.locateFilesWithoutGPS()
+		org.splitSeparatedCells()
+		finalClusters = org.mergeStrayClusters(org.spatialGrid.GetClusters())
+		org.matchLibraryFolders(finalClusters)
+		org.safeLog(fmt.Sprintf("Clustering complete. Total location clusters: %d\n", len(finalClusters)))
+
+		if org.geoJSONPath != "" {
+			if err := writeClustersGeoJSON(org.geoJSONPath, finalClusters); err != nil {
+				org.safeLog(fmt.Sprintf("Warning: Could not write cluster map %s: %v\n", org.geoJSONPath, err))
+			} else {
+				org.safeLog(fmt.Sprintf("Cluster map written to %s\n", org.geoJSONPath))
+			}
+		}
+	}
+
+	org.runStats.RecordClusters(finalClusters)
+	org.previewClusters(finalClusters)
+	for _, cluster := range finalClusters {
+		org.emit(Event{Type: EventClusterCreated, Cluster: cluster.Name, Files: len(cluster.Images)})
+	}
+
+	// Copy files based on clusters
+	org.setPhase(PhaseCopying)
+	org.safeLog("Starting file organization...\n")
+	copiedFiles, err := org.organizeByLocationClusters(ctx, finalClusters)
+	if errors.Is(err, errPlanDeclined) {
+		org.safeLog("Copying cancelled; no files were written\n")
+		return err
+	} else if errors.Is(err, context.Canceled) {
+		org.safeLog(fmt.Sprintf("Copying cancelled after %d files; the next run picks up from there\n", copiedFiles))
+		return err
+	} else if err != nil {
+		org.safeLog(fmt.Sprintf("Copying stopped after %d files: %v\n", copiedFiles, err))
+		return err
+	}
+
+	org.safeLog(fmt.Sprintf("Organization complete! Processed %d media files into %d location clusters.\n", totalFiles, len(finalClusters)))
+
+	if problems := org.runStats.ProblemFiles(); len(problems) > 0 {
+		org.safeLog(fmt.Sprintf("ExifTool could not read %d files; they were organized by filename or file date:\n", len(problems)))
+		for _, problem := range problems {
+			org.safeLog(fmt.Sprintf("  %s: %s\n", problem.Path, problem.Reason))
+		}
+	}
+	if suspect := org.runStats.SuspectDateFiles(); len(suspect) > 0 {
+		org.safeLog(fmt.Sprintf("%d files have implausible capture dates:\n", len(suspect)))
+		for _, file := range suspect {
+			org.safeLog(fmt.Sprintf("  %s: %s\n", file.Path, file.Reason))
+		}
+	}
+	if failed := org.runStats.FailedFiles(); len(failed) > 0 {
+		org.safeLog(fmt.Sprintf("%d files could not be organized:\n", len(failed)))
+		for _, failure := range failed {
+			org.safeLog(fmt.Sprintf("  %s: %s\n", failure.Path, failure.Reason))
+		}
+	}
+
+	// Keep later scans of a folder holding the output from organizing it again
+	if copiedFiles > 0 && ctx.Err() == nil {
+		org.writeOutputIgnoreMarker()
+	}
+
+	org.runStats.SetCopied(copiedFiles)
+	org.runStats.SetErrors(org.errorFiles.Load())
+	if hits, misses := org.zoneCache.Stats(); hits+misses > 0 {
+		org.safeLog(fmt.Sprintf("Timezone lookups: %d answered from the cache, %d looked up\n", hits, misses))
+		org.runStats.SetLookups(hits, misses)
+	}
+	org.emit(Event{Type: EventDone, Files: copiedFiles, Errors: org.errorFiles.Load()})
+
+	// Clean up spatial grid
+	org.spatialGrid.Clear()
+
+	return nil
+}
+
+// previewClusters shows clusters in the destination preview, each as a folder holding
+// its files, until the copy plan fills in their date folders
+func (org *Organizer) previewClusters(clusters []LocationCluster) {
+	org.folderPreview.Reset()
+	for _, cluster := range clusters {
+		relDir := cluster.Name
+		if org.flattenByDate || (cluster.Name == NoLocationClusterName && org.noGPSPolicy == NoGPSDateOnly) {
+			relDir = "" // These files go straight into date folders at the top of the output
+		}
+		for _, image := range cluster.Images {
+			org.folderPreview.AddFile(relDir, image)
+		}
+	}
+}
+
+// locateFilesWithoutGPS gives files without GPS a location from the photos around them
+// in time, when the no-GPS policy asks for one
+func (org *Organizer) locateFilesWithoutGPS() {
+	located, estimates := org.spatialGrid.LocateWithoutGPS(org.clusterStrategy())
+	switch org.noGPSPolicy {
+	case NoGPSNearestInTime:
+		org.safeLog(fmt.Sprintf("Borrowed locations for %d files without GPS from photos taken within %v\n", located, org.noGPSWindow))
+	case NoGPSInterpolate:
+		for _, estimate := range estimates {
+			org.estimatedLocations[estimate.Path] = estimate
+			org.safeLog(fmt.Sprintf("Estimated location for %s: %s (interpolated)\n",
+				filepath.Base(estimate.Path), formatLocation(estimate.Lat, estimate.Lng, fileLocationDecimals)))
+		}
+		org.runStats.SetEstimated(len(estimates))
+		org.safeLog(fmt.Sprintf("Interpolated locations for %d files without GPS\n", len(estimates)))
+	}
+}
+
+// adaptBatchSize picks the next automatic batch size. Each file of the last batch grew
+// the heap by perFile bytes, and baseline bytes are still live after collecting it.
+// The next batch may use half the remaining room under ceiling; the size at most
+// doubles per batch so one light batch can't cause a spike, but shrinks right away.
+func adaptBatchSize(current int, perFile, baseline, ceiling uint64) int {
+	next := MaxAutoBatchSize
+	if baseline >= ceiling {
+		next = MinAutoBatchSize
+	} else if perFile > 0 {
+		if target := (ceiling - baseline) / 2 / perFile; target < uint64(next) {
+			next = int(target)
+		}
+	}
+
+	if next > current*2 {
+		next = current * 2
+	}
+	return min(max(next, MinAutoBatchSize), MaxAutoBatchSize)
+}
+
+// needsExifTool reports whether reading path's metadata always takes exiftool. HEIC/HEIF
+// files usually have EXIF that is decoded in-process, so only their fallback does.
+func (org *Organizer) needsExifTool(path string) bool {
+	return org.mediaKind(path).exifToolUse() == ExifToolRequired
+}
+
+// collectResult counts a file the workers have finished and returns its info, or nil
+// after reporting why it couldn't be read
+func (org *Organizer) collectResult(result ProcessingResult) *ImageInfo {
+	org.incrementProcessedFiles()
+	if result.Error != nil {
+		org.recordFailure(result.Info.OriginalPath, result.Error)
+		org.safeLog(fmt.Sprintf("Warning: Could not extract info from %s: %v\n",
+			filepath.Base(result.Info.OriginalPath), result.Error))
+		org.emit(Event{Type: EventError, Path: result.Info.OriginalPath, Error: result.Error.Error()})
+		return nil
+	}
+	org.emit(Event{Type: EventFileProcessed, Path: result.Info.OriginalPath})
+	return result.Info
+}
+
+// safeLog passes a log message to the observer
+func (org *Organizer) safeLog(message string) {
+	if org.observer != nil {
+		org.observer.OnLog(message)
+	}
+}
+
+// setPhase tells the observer the run has moved to phase
+func (org *Organizer) setPhase(phase Phase) {
+	if org.observer != nil {
+		org.observer.OnPhaseChange(phase)
+	}
+	org.emit(Event{Type: EventPhase, Phase: phase.String()})
+}
+
+// emit timestamps event and passes it on, if the observer wants events
+func (org *Organizer) emit(event Event) {
+	if observer, ok := org.observer.(EventObserver); ok {
+		event.Time = time.Now()
+		observer.OnEvent(event)
+	}
+}
+
+// Cancel stops the run in progress, if any, from any goroutine. Files read so far are
+// dropped and files copied so far are kept; Run then returns context.Canceled.
+func (org *Organizer) Cancel() {
+	org.cancelMutex.Lock()
+	defer org.cancelMutex.Unlock()
+	if org.cancelProcessing != nil {
+		org.cancelProcessing()
+	}
+}
+
+// incrementProcessedFiles thread-safely increments the processed file counter
+func (org *Organizer) incrementProcessedFiles() {
+	processed := org.processedFiles.Add(1)
+	if org.observer != nil {
+		org.observer.OnProgress(processed, org.totalFiles.Load())
+	}
+}
+
+// recordFailure thread-safely counts path as an error and keeps why it failed, so it
+// can be listed for review after the run
+func (org *Organizer) recordFailure(path string, err error) {
+	org.errorFiles.Add(1)
+	org.runStats.RecordFailure(path, err.Error())
+}
+
+// matchesCameraFilter reports whether info passes the camera model filter (case-insensitive)
+func (org *Organizer) matchesCameraFilter(info *ImageInfo) bool {
+	if org.cameraFilter == "" {
+		return true
+	}
+	device := strings.ToLower(info.CameraMake + " " + info.CameraModel)
+	return strings.Contains(device, strings.ToLower(org.cameraFilter))
+}
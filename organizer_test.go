@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// logObserver collects what an Organizer logs
+type logObserver struct{ log strings.Builder }
+
+func (o *logObserver) OnLog(message string)              { o.log.WriteString(message) }
+func (o *logObserver) OnProgress(processed, total int64) {}
+func (o *logObserver) OnPhaseChange(phase Phase)         {}
+
+func TestValidateNestedFolders(t *testing.T) {
+	dir := t.TempDir()
+	photos := filepath.Join(dir, "Photos")
+	if err := os.MkdirAll(filepath.Join(photos, "import"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		source, output string
+		valid          bool
+		warning        string // Logged by a run
+	}{
+		// The output is pruned from the scan, and a source inside the output only
+		// warns that a later run of the output would pick its files up again
+		{"output inside source", photos, filepath.Join(photos, "organized"), true, "The output folder is inside the source folder"},
+		{"source inside output", filepath.Join(photos, "import"), photos, true, "The source folder is inside the output folder"},
+		{"siblings", photos, filepath.Join(dir, "Organized"), true, ""},
+		{"same folder", photos, photos, false, ""},
+		{"same folder, spelled differently", photos, filepath.Join(photos, "import", "..") + string(filepath.Separator), false, ""},
+	}
+	for _, tt := range tests {
+		org := NewOrganizer(nil)
+		org.sourceFolder, org.outputFolder = tt.source, tt.output
+		if err := org.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate returned %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+
+	// Runs warn about either nesting
+	for _, tt := range tests {
+		if tt.warning == "" {
+			continue
+		}
+		observer := &logObserver{}
+		org := NewOrganizer(observer)
+		org.sourceFolder, org.outputFolder = tt.source, tt.output
+		if err := org.Run(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !strings.Contains(observer.log.String(), tt.warning) {
+			t.Errorf("%s: the run didn't warn %q", tt.name, tt.warning)
+		}
+	}
+
+	if !isWithinFolder(filepath.Join(photos, "organized"), photos) || isWithinFolder(photos, filepath.Join(photos, "organized")) {
+		t.Error("isWithinFolder got the nesting backwards")
+	}
+	if isWithinFolder(filepath.Join(dir, "Photos2"), photos) {
+		t.Error("a folder sharing a prefix counted as nested")
+	}
+}
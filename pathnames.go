@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// maxSegmentBytes is the longest file or folder name most filesystems accept
+	maxSegmentBytes = 255
+	// windowsMaxPath is the classic Windows path limit (MAX_PATH), which many programs
+	// still enforce even though the organizer itself writes past it
+	windowsMaxPath = 260
+	// hashSuffixBytes is the length of the "-" and hash ending an overlong name cut short
+	hashSuffixBytes = 9
+)
+
+// windowsReservedNames are device names Windows refuses as a file or folder name, even
+// with an extension (NUL.jpg is as bad as NUL)
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true, "CONIN$": true, "CONOUT$": true,
+	"COM0": true, "COM1": true, "COM2": true, "COM3": true, "COM4": true,
+	"COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT0": true, "LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true,
+	"LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizePathSegment makes name safe as a single file or folder name on Windows, macOS,
+// Linux and FAT32/exFAT drives. It is idempotent, and names that are already safe are
+// returned unchanged.
+func sanitizePathSegment(name string) string {
+	return sanitizeSegment(name, maxSegmentBytes)
+}
+
+// sanitizeFilename is sanitizePathSegment for a filename, keeping its extension intact
+func sanitizeFilename(filename string) string {
+	ext := filepath.Ext(filename)
+	if ext == filename || len(ext) < 2 || strings.TrimRight(ext, " .") != ext || strings.ContainsFunc(ext, isIllegalNameRune) {
+		// A dotfile or a mangled extension is sanitized as a whole
+		return sanitizePathSegment(filename)
+	}
+	if maxSegmentBytes-len(ext) <= hashSuffixBytes {
+		// So is an extension too long to leave room for a cut name before it
+		return sanitizePathSegment(filename)
+	}
+	return sanitizeSegment(strings.TrimSuffix(filename, ext), maxSegmentBytes-len(ext)) + ext
+}
+
+// sanitizeSegment makes name a safe name of at most maxBytes bytes:
+//   - Unicode is normalized to the composed form (NFC), so a name written on macOS
+//     doesn't turn into a near-duplicate on other systems, and invalid UTF-8 is replaced
+//   - characters illegal on Windows or FAT, and control characters, become '-'
+//   - trailing dots and spaces, which Windows silently drops, are trimmed
+//   - Windows device names like CON get a leading underscore
+//   - overlong names are cut short and end with a hash of the full name, so names that
+//     only differ past the cut stay apart; below hashSuffixBytes there's no room for
+//     the hash, and they are only cut
+func sanitizeSegment(name string, maxBytes int) string {
+	original := name
+	name = norm.NFC.String(strings.ToValidUTF8(name, "-"))
+	name = strings.Map(func(r rune) rune {
+		if isIllegalNameRune(r) {
+			return '-'
+		}
+		return r
+	}, name)
+
+	if len(name) > maxBytes {
+		hash := fnv.New32a()
+		hash.Write([]byte(original))
+		suffix := fmt.Sprintf("-%08x", hash.Sum32())
+		if maxBytes <= len(suffix) {
+			suffix = ""
+		}
+		cut := max(maxBytes-len(suffix), 0)
+		for cut > 0 && !utf8.RuneStart(name[cut]) {
+			cut--
+		}
+		name = strings.TrimRight(name[:cut], " .") + suffix
+	}
+
+	name = strings.TrimRight(name, " .")
+	if name == "" {
+		return "_"
+	}
+
+	base, _, _ := strings.Cut(name, ".")
+	if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+		name = "_" + name
+		if len(name) > maxBytes {
+			name = sanitizeSegment(name, maxBytes)
+		}
+	}
+	return name
+}
+
+// isIllegalNameRune reports whether r can't appear in a file or folder name on Windows
+// or a FAT32/exFAT drive
+func isIllegalNameRune(r rune) bool {
+	switch r {
+	case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+		return true
+	}
+	return r < 0x20 || r == 0x7f
+}
+
+// pathLengthError explains err when it came from a destination path the output drive
+// considers too long, which the OS otherwise reports rather cryptically
+func pathLengthError(err error, path string) error {
+	if !isPathTooLong(err) {
+		return err
+	}
+	return fmt.Errorf("destination path is %d characters, too long for the output drive; choose a shorter output folder or fewer folder levels: %w", len(path), err)
+}
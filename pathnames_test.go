@@ -0,0 +1,71 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSanitizePathSegment(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Paris", "Paris"},
+		{"CON", "_CON"},
+		{"con", "_con"},
+		{"NUL.jpg", "_NUL.jpg"},
+		{"LPT1 ", "_LPT1"},
+		{"CONSOLE", "CONSOLE"}, // Only the device names themselves are reserved
+		{`a:b*c?d"e<f>g|h\i/j`, "a-b-c-d-e-f-g-h-i-j"},
+		{"tab\there", "tab-here"},
+		{"Trailing. . ", "Trailing"},
+		{"...", "_"},
+		{"", "_"},
+		{"Cafe\u0301", "Caf\u00e9"}, // Decomposed, as macOS writes it
+		{"bad\xffutf8", "bad-utf8"},
+	}
+	for _, tt := range tests {
+		got := sanitizePathSegment(tt.name)
+		if got != tt.want {
+			t.Errorf("sanitizePathSegment(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if again := sanitizePathSegment(got); again != got {
+			t.Errorf("sanitizing %q again gave %q", got, again)
+		}
+	}
+}
+
+func TestSanitizeOverlongNames(t *testing.T) {
+	long := strings.Repeat("é", 200) // 400 bytes
+	first, second := sanitizePathSegment(long+"a"), sanitizePathSegment(long+"b")
+	for _, name := range []string{first, second} {
+		if len(name) > maxSegmentBytes || !utf8.ValidString(name) {
+			t.Errorf("overlong name cut to %d bytes, valid UTF-8 %v", len(name), utf8.ValidString(name))
+		}
+		if again := sanitizePathSegment(name); again != name {
+			t.Errorf("sanitizing a cut name again changed it")
+		}
+	}
+	if first == second {
+		t.Error("names differing only past the cut were cut to the same name")
+	}
+
+	// Filenames keep their extension when cut
+	if got := sanitizeFilename(strings.Repeat("x", 300) + ".jpeg"); len(got) > maxSegmentBytes || !strings.HasSuffix(got, ".jpeg") {
+		t.Errorf("overlong filename cut to %q", got)
+	}
+	// An extension leaving no room for the rest of the name is cut with it
+	for _, name := range []string{"abcdefghij." + strings.Repeat("x", 250), "abcdefghij." + strings.Repeat("x", 246)} {
+		if got := sanitizeFilename(name); len(got) > maxSegmentBytes || !strings.HasPrefix(got, "abcdefghij.") {
+			t.Errorf("filename with a %d-byte extension cut to %q", len(filepath.Ext(name)), got)
+		}
+	}
+	// Too short a limit for the hash just cuts the name
+	if got := sanitizeSegment(long, 4); got != "éé" {
+		t.Errorf("sanitizeSegment(%d bytes, 4) = %q, want éé", len(long), got)
+	}
+	if got := sanitizeFilename("aux.jpg"); got != "_aux.jpg" {
+		t.Errorf("sanitizeFilename(aux.jpg) = %q, want _aux.jpg", got)
+	}
+}
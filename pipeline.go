@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// extractionWindow bounds how many files are between being submitted to the workers and
+// being taken by the clustering stage, which is what holds their metadata in memory.
+// Its size can change while files stream through it.
+type extractionWindow struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	size     int
+	inFlight int
+	closed   bool
+}
+
+// newExtractionWindow returns a window letting size files through at once
+func newExtractionWindow(size int) *extractionWindow {
+	window := &extractionWindow{size: max(size, 1)}
+	window.cond = sync.NewCond(&window.mutex)
+	return window
+}
+
+// Acquire blocks until another file may be submitted and reports whether it may; it
+// returns false once the window is closed
+func (ew *extractionWindow) Acquire() bool {
+	ew.mutex.Lock()
+	defer ew.mutex.Unlock()
+	for ew.inFlight >= ew.size && !ew.closed {
+		ew.cond.Wait()
+	}
+	if ew.closed {
+		return false
+	}
+	ew.inFlight++
+	return true
+}
+
+// Release frees the place of a file the clustering stage has taken
+func (ew *extractionWindow) Release() {
+	ew.mutex.Lock()
+	defer ew.mutex.Unlock()
+	ew.inFlight--
+	ew.cond.Broadcast()
+}
+
+// Resize changes how many files may be in flight. Shrinking takes effect as files
+// already in flight are released.
+func (ew *extractionWindow) Resize(size int) {
+	ew.mutex.Lock()
+	defer ew.mutex.Unlock()
+	ew.size = max(size, 1)
+	ew.cond.Broadcast()
+}
+
+// Close stops any further files from being submitted
+func (ew *extractionWindow) Close() {
+	ew.mutex.Lock()
+	defer ew.mutex.Unlock()
+	ew.closed = true
+	ew.cond.Broadcast()
+}
+
+// extractStream submits mediaFiles to pool and returns a channel that yields each file's
+// result as soon as a worker finishes it, so the caller can cluster files while others
+// are still being read. At most window's size files are in flight; each is released once
+// the caller receives it, so a slow consumer holds back submission rather than letting
+// results pile up. The channel is closed when every file is done or ctx is cancelled,
+// after which pool is closed.
+func (org *Organizer) extractStream(ctx context.Context, pool *WorkerPool, mediaFiles []string, window *extractionWindow) <-chan ProcessingResult {
+	// A cancelled run must not leave the submitter waiting for room
+	stop := context.AfterFunc(ctx, window.Close)
+
+	go func() {
+		defer func() {
+			pool.Close()
+			pool.Wait()
+		}()
+		for _, mediaFile := range mediaFiles {
+			if !window.Acquire() {
+				return
+			}
+			if !pool.Submit(mediaFile, org.needsExifTool(mediaFile)) {
+				window.Release()
+				return
+			}
+		}
+	}()
+
+	// Unbuffered, so a file stays counted against the window until it is taken
+	results := make(chan ProcessingResult)
+	go func() {
+		defer close(results)
+		defer stop()
+		for result := range pool.Results {
+			results <- result
+			window.Release()
+		}
+	}()
+	return results
+}
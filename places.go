@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+const (
+	// DefaultPlaceRadius is the radius in meters given to new labeled places
+	DefaultPlaceRadius = 200
+	// earthRadiusMeters is the mean radius of the Earth
+	earthRadiusMeters = 6371000
+)
+
+// NamedPlace is a labeled region like "Home": clusters centered within Radius meters of
+// its position are named after it instead of their coordinates
+type NamedPlace struct {
+	Name   string  `json:"name"`
+	Lat    float64 `json:"lat"`
+	Lng    float64 `json:"lng"`
+	Radius float64 `json:"radius"` // Meters
+}
+
+// Validate reports what is wrong with place, if anything
+func (place NamedPlace) Validate() error {
+	switch {
+	case strings.TrimSpace(place.Name) == "":
+		return errors.New("a place needs a name")
+	case math.IsNaN(place.Lat) || place.Lat < -90 || place.Lat > 90:
+		return fmt.Errorf("latitude %g is outside -90 to 90", place.Lat)
+	case math.IsNaN(place.Lng) || place.Lng < -180 || place.Lng > 180:
+		return fmt.Errorf("longitude %g is outside -180 to 180", place.Lng)
+	case !(place.Radius > 0):
+		return fmt.Errorf("radius %g must be more than 0 meters", place.Radius)
+	}
+	return nil
+}
+
+// String describes place for lists and logs
+func (place NamedPlace) String() string {
+	return fmt.Sprintf("%s (%.5f, %.5f, within %.0f m)", place.Name, place.Lat, place.Lng, place.Radius)
+}
+
+// placeAt returns the labeled place covering a position. When places overlap, the one
+// whose center is nearest wins.
+func placeAt(places []NamedPlace, lat, lng float64) (NamedPlace, bool) {
+	var best NamedPlace
+	bestDistance := math.Inf(1)
+	for _, place := range places {
+		distance := distanceMeters(place.Lat, place.Lng, lat, lng)
+		if distance <= place.Radius && distance < bestDistance {
+			best, bestDistance = place, distance
+		}
+	}
+	return best, !math.IsInf(bestDistance, 1)
+}
+
+// distanceMeters returns the great-circle distance between two positions in meters
+func distanceMeters(lat1, lng1, lat2, lng2 float64) float64 {
+	return angularDistance(lat1, lng1, lat2, lng2) * math.Pi / 180 * earthRadiusMeters
+}
+
+// parseNamedPlaces decodes a JSON list of places, rejecting any that are invalid
+func parseNamedPlaces(data []byte) ([]NamedPlace, error) {
+	var places []NamedPlace
+	if err := json.Unmarshal(data, &places); err != nil {
+		return nil, err
+	}
+	for _, place := range places {
+		if err := place.Validate(); err != nil {
+			return nil, fmt.Errorf("place %q: %w", place.Name, err)
+		}
+	}
+	return places, nil
+}
+
+// readNamedPlaces reads a JSON file of places, as written by encoding a []NamedPlace
+func readNamedPlaces(path string) ([]NamedPlace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	places, err := parseNamedPlaces(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return places, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errPlanDeclined is returned by a run whose copy plan wasn't confirmed; like a
+// cancelled run, nothing more is written
+var errPlanDeclined = fmt.Errorf("copy plan declined: %w", context.Canceled)
+
+// CopyPlan summarizes what the copy phase is about to write, so it can be confirmed
+// before anything is
+type CopyPlan struct {
+	Files      int
+	Clusters   int
+	Bytes      int64 // Total size of the files, including any found to be organized already
+	NewFolders int   // Folders that don't exist in the output folder yet
+	CopyMode   string
+	Output     string
+}
+
+// PlanConfirmer is an optional extension of ProgressObserver for observers that want to
+// approve the copy plan before any file is written. Runs whose observer doesn't
+// implement it go ahead without asking.
+type PlanConfirmer interface {
+	// ConfirmPlan blocks until plan is approved or declined
+	ConfirmPlan(plan CopyPlan) bool
+}
+
+// Summary renders the plan as multi-line text
+func (plan CopyPlan) Summary() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Files: %d (%s)\n", plan.Files, formatBytes(plan.Bytes))
+	fmt.Fprintf(&sb, "Clusters: %d\n", plan.Clusters)
+	fmt.Fprintf(&sb, "New folders: about %d\n", plan.NewFolders)
+	fmt.Fprintf(&sb, "Placed by: %s\n", plan.CopyMode)
+	fmt.Fprintf(&sb, "Into: %s", plan.Output)
+	return sb.String()
+}
+
+// previewPlan replaces the destination preview with the folders the copy phase places
+// clusterInfos in, date folders included
+func (org *Organizer) previewPlan(clusterInfos [][]*ImageInfo, sparse map[string]bool) {
+	output := filepath.Clean(org.outputFolder)
+	org.folderPreview.Reset()
+	for _, infos := range clusterInfos {
+		for _, info := range infos {
+			if relDir, err := filepath.Rel(output, org.plannedFolder(output, info, sparse)); err == nil {
+				org.folderPreview.AddFile(relDir, info.OriginalPath)
+			}
+		}
+	}
+}
+
+// planCopy works out what copying clusterInfos, the files of each of clusters, will
+// write. It reads file sizes and checks which folders exist, but writes nothing.
+func (org *Organizer) planCopy(clusters []LocationCluster, clusterInfos [][]*ImageInfo, sparse map[string]bool) CopyPlan {
+	plan := CopyPlan{CopyMode: org.copyMode, Output: org.outputFolder}
+	output := filepath.Clean(org.outputFolder)
+	folders := make(map[string]bool) // Whether each folder is missing
+	for i := range clusters {
+		if len(clusterInfos[i]) > 0 {
+			plan.Clusters++
+		}
+		for _, info := range clusterInfos[i] {
+			plan.Files++
+			if stat, err := org.statSource(info.OriginalPath); err == nil {
+				info.Size = stat.Size()
+				plan.Bytes += info.Size
+			}
+
+			// Check the folder and its parents up to the output folder, once each
+			folder := org.plannedFolder(output, info, sparse)
+			for folder != output && folder != filepath.Dir(folder) {
+				if _, seen := folders[folder]; seen {
+					break
+				}
+				_, err := os.Lstat(folder)
+				folders[folder] = os.IsNotExist(err)
+				folder = filepath.Dir(folder)
+			}
+		}
+	}
+	for _, missing := range folders {
+		if missing {
+			plan.NewFolders++
+		}
+	}
+	return plan
+}
+
+// confirmPlan logs plan and asks the observer to confirm it, if it can
+func (org *Organizer) confirmPlan(plan CopyPlan) bool {
+	org.safeLog("Copy plan:\n" + plan.Summary() + "\n")
+	confirmer, ok := org.observer.(PlanConfirmer)
+	if !ok {
+		return true
+	}
+	return confirmer.ConfirmPlan(plan)
+}
@@ -0,0 +1,649 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// preferencesDraft holds the edits made in the preferences dialog until they are saved
+type preferencesDraft struct {
+	Settings
+	NotifyOnComplete bool
+	ProgressMode     string
+	ExifToolPath     string // Custom exiftool executable; empty to auto-detect
+}
+
+// showPreferences opens the preferences dialog. Saving applies the settings to the next
+// run and keeps them for later sessions; cancelling discards the edits. Labeled places
+// are saved as soon as they are edited.
+func (app *App) showPreferences() {
+	if app.running.Load() {
+		dialog.ShowInformation("Preferences", "Settings can be changed once the current run finishes.", app.window)
+		return
+	}
+
+	draft := &preferencesDraft{
+		Settings:         app.Settings(),
+		NotifyOnComplete: app.notifyOnComplete,
+		ProgressMode:     app.progressMode,
+		ExifToolPath:     app.fyneApp.Preferences().String(prefExifToolPath),
+	}
+	preferences := dialog.NewCustomConfirm("Preferences", "Save", "Cancel", app.preferencesTabs(draft), func(save bool) {
+		if save {
+			app.savePreferences(draft)
+		}
+	}, app.window)
+	preferences.Resize(fyne.NewSize(760, 580))
+	preferences.Show()
+}
+
+// savePreferences applies draft and persists it
+func (app *App) savePreferences(draft *preferencesDraft) {
+	// The run shortcut still works while the dialog is open
+	if app.running.Load() {
+		dialog.ShowError(fmt.Errorf("a run started while the preferences were open; they weren't saved"), app.window)
+		return
+	}
+
+	app.ApplySettings(draft.Settings)
+	app.notifyOnComplete = draft.NotifyOnComplete
+	app.progressMode = draft.ProgressMode
+	if draft.ExifToolPath != app.fyneApp.Preferences().String(prefExifToolPath) {
+		app.setCustomExifToolPath(draft.ExifToolPath)
+	}
+	app.saveSettings()
+}
+
+// loadSettings applies the settings saved by an earlier session, if any
+func (app *App) loadSettings() {
+	prefs := app.fyneApp.Preferences()
+	app.notifyOnComplete = prefs.BoolWithFallback(prefNotifyOnComplete, app.notifyOnComplete)
+	if mode := prefs.String(prefProgressMode); mode == ProgressFiles || mode == ProgressBytes {
+		app.progressMode = mode
+	}
+
+	stored := prefs.String(prefSettings)
+	if stored == "" {
+		return
+	}
+	settings, err := parseSettings([]byte(stored), app.Settings())
+	if err != nil {
+		app.safeLog(fmt.Sprintf("Warning: Could not read saved settings, using the defaults: %v\n", err))
+		return
+	}
+	app.ApplySettings(settings)
+}
+
+// saveSettings persists the current settings for later sessions
+func (app *App) saveSettings() {
+	data, err := json.Marshal(app.Settings())
+	if err != nil {
+		app.safeLog(fmt.Sprintf("Warning: Could not save settings: %v\n", err))
+		return
+	}
+	prefs := app.fyneApp.Preferences()
+	prefs.SetString(prefSettings, string(data))
+	prefs.SetBool(prefNotifyOnComplete, app.notifyOnComplete)
+	prefs.SetString(prefProgressMode, app.progressMode)
+}
+
+// preferencesTabs builds the tabs of the preferences dialog, editing draft
+func (app *App) preferencesTabs(draft *preferencesDraft) fyne.CanvasObject {
+	// Location sensitivity slider
+	sensitivityLabel := widget.NewLabel("Location Grouping Sensitivity:")
+	sensitivityInfo := widget.NewLabel("Lower = Group closer locations together")
+	sensitivitySlider := widget.NewSlider(math.Log10(minSensitivity), math.Log10(maxSensitivity))
+	sensitivitySlider.Value = math.Log10(draft.LocationSensitivity)
+	sensitivitySlider.Step = 0.01
+
+	sensitivityValueLabel := widget.NewLabel(formatSensitivity(draft.LocationSensitivity))
+
+	sensitivitySlider.OnChanged = func(value float64) {
+		draft.LocationSensitivity = sensitivityFromSlider(value)
+		sensitivityValueLabel.SetText(formatSensitivity(draft.LocationSensitivity))
+	}
+
+	// Presets move the slider, which sets the sensitivity
+	sensitivityPresetRow := container.NewHBox(widget.NewLabel("Presets:"))
+	for _, preset := range sensitivityPresets {
+		preset := preset
+		label := fmt.Sprintf("%s (~%s)", preset.Name, formatDistance(preset.Meters))
+		sensitivityPresetRow.Add(widget.NewButton(label, func() {
+			sensitivitySlider.SetValue(math.Log10(preset.Degrees()))
+		}))
+	}
+
+	// Folder name precision
+	nameDecimalLabels := []string{"Match sensitivity", "2 decimals (~1 km)", "3 decimals (~110 m)", "4 decimals (~11 m)", "5 decimals (~1 m)"}
+	nameDecimalSelect := widget.NewSelect(nameDecimalLabels, func(value string) {
+		draft.NameDecimals = nameDecimalChoices[value]
+	})
+	for _, label := range nameDecimalLabels {
+		if nameDecimalChoices[label] == draft.NameDecimals {
+			nameDecimalSelect.SetSelected(label)
+		}
+	}
+	splitCheck := widget.NewCheck("Split clusters that contain clearly separate places", func(checked bool) {
+		draft.SplitCells = checked
+	})
+	splitCheck.SetChecked(draft.SplitCells)
+	minClusterSelect := widget.NewSelect([]string{"Off", "2", "3", "5", "10"}, func(value string) {
+		draft.MinClusterSize, _ = strconv.Atoi(value) // Off parses as 0
+	})
+	minClusterSelect.SetSelected("Off")
+	if draft.MinClusterSize >= 2 {
+		minClusterSelect.SetSelected(strconv.Itoa(draft.MinClusterSize))
+	}
+
+	// Decode worker slider
+	workerLabel := widget.NewLabel("Decode Threads:")
+	workerInfo := widget.NewLabel("Read photo EXIF in-process; more threads = faster on fast CPUs (uses more CPU)")
+	workerSlider := widget.NewSlider(1, float64(runtime.NumCPU()*2))
+	workerSlider.Value = float64(draft.DecodeWorkers)
+	workerSlider.Step = 1
+
+	workerValueLabel := widget.NewLabel(fmt.Sprintf("%d threads (CPU cores: %d)", draft.DecodeWorkers, runtime.NumCPU()))
+
+	workerSlider.OnChanged = func(value float64) {
+		draft.DecodeWorkers = int(value)
+		workerValueLabel.SetText(fmt.Sprintf("%d threads (CPU cores: %d)", draft.DecodeWorkers, runtime.NumCPU()))
+	}
+
+	// Exiftool worker slider
+	exiftoolLimitLabel := widget.NewLabel("ExifTool Workers:")
+	exiftoolLimitInfo := widget.NewLabel("Concurrent exiftool processes for videos, audio and HEIC/HEIF files; mostly waits on the disk")
+	exiftoolLimitSlider := widget.NewSlider(1, float64(runtime.NumCPU()*4))
+	exiftoolLimitSlider.Value = float64(draft.ExiftoolWorkers)
+	exiftoolLimitSlider.Step = 1
+
+	exiftoolLimitValueLabel := widget.NewLabel(fmt.Sprintf("%d processes", draft.ExiftoolWorkers))
+
+	exiftoolLimitSlider.OnChanged = func(value float64) {
+		draft.ExiftoolWorkers = int(value)
+		exiftoolLimitValueLabel.SetText(fmt.Sprintf("%d processes", draft.ExiftoolWorkers))
+	}
+
+	// Batch size slider
+	batchLabel := widget.NewLabel("Batch Size:")
+	batchInfo := widget.NewLabel("Smaller batches = less memory usage (but slower processing)")
+	batchSlider := widget.NewSlider(10, 500) // Reduced max for large datasets
+	batchSlider.Value = float64(draft.BatchSize)
+	batchSlider.Step = 10
+
+	batchValueLabel := widget.NewLabel(fmt.Sprintf("%d files per batch", draft.BatchSize))
+
+	batchSlider.OnChanged = func(value float64) {
+		draft.BatchSize = int(value)
+		batchValueLabel.SetText(fmt.Sprintf("%d files per batch", draft.BatchSize))
+	}
+
+	memoryCeilingLabels := []string{"256 MB", "512 MB", "1 GB", "2 GB"}
+	memoryCeilingSelect := widget.NewSelect(memoryCeilingLabels, func(value string) {
+		draft.MemoryCeiling = memoryCeilings[value]
+	})
+	for _, label := range memoryCeilingLabels {
+		if memoryCeilings[label] == draft.MemoryCeiling {
+			memoryCeilingSelect.SetSelected(label)
+		}
+	}
+	autoBatchCheck := widget.NewCheck("Adjust automatically, keeping memory under", func(checked bool) {
+		// The slider can't be disabled; its value is simply unused while this is on
+		draft.AutoBatchSize = checked
+		if checked {
+			memoryCeilingSelect.Enable()
+		} else {
+			memoryCeilingSelect.Disable()
+		}
+	})
+	autoBatchCheck.SetChecked(draft.AutoBatchSize)
+	if !draft.AutoBatchSize {
+		memoryCeilingSelect.Disable()
+	}
+
+	// Organization mode
+	modeLabel := widget.NewLabel("Folder Organization:")
+	modeRadio := widget.NewRadioGroup([]string{ModeLocationAndDate, ModeDateOnly, ModeLocationOnly}, func(value string) {
+		draft.OrganizeMode = value
+	})
+	modeRadio.Horizontal = true
+	modeRadio.Required = true
+	modeRadio.SetSelected(draft.OrganizeMode)
+
+	granularityLabel := widget.NewLabel("Date folders per:")
+	granularitySelect := widget.NewSelect([]string{GranularityDay, GranularityWeek, GranularityMonth, GranularityYear}, func(value string) {
+		draft.DateGranularity = value
+	})
+	granularitySelect.SetSelected(draft.DateGranularity)
+
+	sparseThresholdSelect := widget.NewSelect([]string{"2", "3", "5", "10"}, func(value string) {
+		if threshold, err := strconv.Atoi(value); err == nil {
+			draft.SparseDateThreshold = threshold
+		}
+	})
+	sparseThresholdSelect.SetSelected(strconv.Itoa(draft.SparseDateThreshold))
+	collapseCheck := widget.NewCheck("Collapse date folders with fewer than", func(checked bool) {
+		draft.CollapseSparseDates = checked
+		if checked {
+			sparseThresholdSelect.Enable()
+		} else {
+			sparseThresholdSelect.Disable()
+		}
+	})
+	collapseCheck.SetChecked(draft.CollapseSparseDates)
+	if !draft.CollapseSparseDates {
+		sparseThresholdSelect.Disable()
+	}
+
+	// Flatten options
+	annotationSelect := widget.NewSelect([]string{AnnotateFilename, AnnotateSidecar, AnnotateNone}, func(value string) {
+		draft.LocationAnnotation = value
+	})
+	annotationSelect.SetSelected(draft.LocationAnnotation)
+	annotationFormatEntry := widget.NewEntry()
+	annotationFormatEntry.SetText(draft.AnnotationFormat)
+	annotationFormatEntry.OnChanged = func(value string) {
+		draft.AnnotationFormat = strings.TrimSpace(value)
+	}
+	flattenCheck := widget.NewCheck("Flatten into a single date tree (no location folders)", func(checked bool) {
+		draft.FlattenByDate = checked
+		if checked {
+			annotationSelect.Enable()
+			annotationFormatEntry.Enable()
+		} else {
+			annotationSelect.Disable()
+			annotationFormatEntry.Disable()
+		}
+	})
+	flattenCheck.SetChecked(draft.FlattenByDate)
+	if !draft.FlattenByDate {
+		annotationSelect.Disable()
+		annotationFormatEntry.Disable()
+	}
+
+	// Rename-on-copy template
+	renameEntry := widget.NewEntry()
+	renameEntry.SetText(draft.RenameTemplate)
+	renameEntry.OnChanged = func(value string) {
+		draft.RenameTemplate = strings.TrimSpace(value)
+	}
+	renameCheck := widget.NewCheck("Rename copies using a template ({date}, {time}, {original-name}, {sequence}, {location}, {elevation})", func(checked bool) {
+		draft.RenameOnCopy = checked
+		if checked {
+			renameEntry.Enable()
+		} else {
+			renameEntry.Disable()
+		}
+	})
+	renameCheck.SetChecked(draft.RenameOnCopy)
+
+	writeMetadataCheck := widget.NewCheck("Write cluster names and interpolated GPS into the copies (requires ExifTool)", func(checked bool) {
+		draft.WriteCopyMetadata = checked
+	})
+	writeMetadataCheck.SetChecked(draft.WriteCopyMetadata)
+	if !draft.RenameOnCopy {
+		renameEntry.Disable()
+	}
+
+	// GPS-less file handling
+	noGPSWindowLabels := []string{"15 minutes", "1 hour", "3 hours", "12 hours"}
+	noGPSWindowSelect := widget.NewSelect(noGPSWindowLabels, func(value string) {
+		draft.NoGPSWindow = Duration(noGPSWindows[value])
+	})
+	for _, label := range noGPSWindowLabels {
+		if Duration(noGPSWindows[label]) == draft.NoGPSWindow {
+			noGPSWindowSelect.SetSelected(label)
+		}
+	}
+	noGPSSelect := widget.NewSelect([]string{NoGPSFolder, NoGPSDateOnly, NoGPSNearestInTime, NoGPSInterpolate}, func(value string) {
+		draft.NoGPSPolicy = value
+		if value == NoGPSNearestInTime || value == NoGPSInterpolate {
+			noGPSWindowSelect.Enable()
+		} else {
+			noGPSWindowSelect.Disable()
+		}
+	})
+	noGPSSelect.SetSelected(draft.NoGPSPolicy)
+	gpsAccuracyLabels := []string{"Off", "25 m", "50 m", "100 m", "500 m", "1000 m"}
+	gpsAccuracySelect := widget.NewSelect(gpsAccuracyLabels, func(value string) {
+		draft.MaxGPSError = gpsAccuracyChoices[value]
+	})
+	for _, label := range gpsAccuracyLabels {
+		if gpsAccuracyChoices[label] == draft.MaxGPSError {
+			gpsAccuracySelect.SetSelected(label)
+		}
+	}
+	undatedCheck := widget.NewCheck("Send files dated only by a suspiciously recent file date to "+UndatedFolder, func(checked bool) {
+		draft.RouteUndated = checked
+	})
+	undatedCheck.SetChecked(draft.RouteUndated)
+
+	// Elevation-aware clustering
+	elevationBandLabels := []string{"Off", "100 m", "250 m", "500 m", "1000 m"}
+	elevationBandSelect := widget.NewSelect(elevationBandLabels, func(value string) {
+		draft.ElevationBand = elevationBands[value]
+	})
+	for _, label := range elevationBandLabels {
+		if elevationBands[label] == draft.ElevationBand {
+			elevationBandSelect.SetSelected(label)
+		}
+	}
+
+	// Destination conflict policy
+	conflictLabel := widget.NewLabel("When a file already exists:")
+	conflictSelect := widget.NewSelect([]string{ConflictRename, ConflictSkip, ConflictOverwrite, ConflictKeepNewest, ConflictKeepLatest}, func(value string) {
+		draft.ConflictPolicy = value
+	})
+	conflictSelect.SetSelected(draft.ConflictPolicy)
+
+	// Copy, clone or hard-link files into the output
+	copyModeSelect := widget.NewSelect([]string{CopyModeCopy, CopyModeHardLink, CopyModeClone}, func(value string) {
+		draft.CopyMode = value
+	})
+	copyModeSelect.SetSelected(draft.CopyMode)
+	syncCheck := widget.NewCheck("Flush each copy to disk before finishing it (slower, survives power loss)", func(checked bool) {
+		draft.SyncCopies = checked
+	})
+	syncCheck.SetChecked(draft.SyncCopies)
+
+	// Burst handling
+	burstHeuristicSelect := widget.NewSelect([]string{BestSharpest, BestResolution, BestLargest}, func(value string) {
+		draft.BurstHeuristic = value
+	})
+	burstHeuristicSelect.SetSelected(draft.BurstHeuristic)
+	burstPolicySelect := widget.NewSelect([]string{BurstKeepBest, BurstRejectRest, BurstKeepAll}, func(value string) {
+		draft.BurstPolicy = value
+		if value == BurstKeepAll {
+			burstHeuristicSelect.Disable()
+		} else {
+			burstHeuristicSelect.Enable()
+		}
+	})
+	burstPolicySelect.SetSelected(draft.BurstPolicy)
+
+	// Capture time zone settings
+	timeZoneLabel := widget.NewLabel("EXIF times without a timezone are:")
+	timeZoneSelect := widget.NewSelect([]string{TimeZoneLocal, TimeZoneUTC}, func(value string) {
+		draft.ExifTimeZone = value
+	})
+	timeZoneSelect.SetSelected(draft.ExifTimeZone)
+	gpsTimeZoneCheck := widget.NewCheck("Use GPS location to determine the local capture time zone", func(checked bool) {
+		draft.UseGPSTimeZone = checked
+	})
+	gpsTimeZoneCheck.SetChecked(draft.UseGPSTimeZone)
+	suspectDateLabel := widget.NewLabel(fmt.Sprintf("Dates in the future or before %d:", EarliestPlausibleDate.Year()))
+	suspectDateSelect := widget.NewSelect([]string{SuspectDateFlag, SuspectDateClamp, SuspectDateFolder}, func(value string) {
+		draft.SuspectDates = value
+	})
+	suspectDateSelect.SetSelected(draft.SuspectDates)
+
+	// Date source priority, reordered with the arrow buttons
+	datePriorityLabel := widget.NewLabel("Date sources, most trusted first:")
+	datePriorityList := container.NewVBox()
+	var showDatePriority func()
+	moveDateSource := func(from, to int) {
+		draft.DatePriority[from], draft.DatePriority[to] = draft.DatePriority[to], draft.DatePriority[from]
+		showDatePriority()
+	}
+	showDatePriority = func() {
+		datePriorityList.RemoveAll()
+		for i, source := range draft.DatePriority {
+			i := i
+			upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() { moveDateSource(i, i-1) })
+			downBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() { moveDateSource(i, i+1) })
+			if i == 0 {
+				upBtn.Disable()
+			}
+			if i == len(draft.DatePriority)-1 {
+				downBtn.Disable()
+			}
+			datePriorityList.Add(container.NewHBox(upBtn, downBtn, widget.NewLabel(fmt.Sprintf("%d. %s", i+1, source))))
+		}
+	}
+	showDatePriority()
+
+	// Labeled places, named instead of their coordinates
+	placesLabel := widget.NewLabel("Labeled Places (clusters centered in one take its name):")
+	placesList := container.NewVBox()
+	var showPlaces func()
+	showPlaces = func() {
+		placesList.RemoveAll()
+		for i, place := range app.namedPlaces {
+			i := i
+			editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+				app.editNamedPlace(i, showPlaces)
+			})
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				app.namedPlaces = slices.Delete(slices.Clone(app.namedPlaces), i, i+1)
+				app.saveNamedPlaces()
+				showPlaces()
+			})
+			placesList.Add(container.NewHBox(editBtn, removeBtn, widget.NewLabel(place.String())))
+		}
+	}
+	showPlaces()
+	addPlaceBtn := widget.NewButtonWithIcon("Add Place", theme.ContentAddIcon(), func() {
+		app.editNamedPlace(-1, showPlaces)
+	})
+
+	// Custom exiftool location
+	exiftoolLabel := widget.NewLabel("ExifTool Path (optional):")
+	exiftoolEntry := widget.NewEntry()
+	exiftoolEntry.SetPlaceHolder("Auto-detect")
+	exiftoolEntry.SetText(draft.ExifToolPath)
+	exiftoolEntry.OnChanged = func(value string) {
+		draft.ExifToolPath = strings.TrimSpace(value)
+	}
+	exiftoolBrowseBtn := widget.NewButton("Browse...", func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			path := reader.URI().Path()
+			reader.Close()
+			exiftoolEntry.SetText(path)
+		}, app.window)
+	})
+	unsortedCheck := widget.NewCheck("Copy files ExifTool can't read to "+UnsortedFolder+" instead", func(checked bool) {
+		draft.UnsortedUnreadable = checked
+	})
+	unsortedCheck.SetChecked(draft.UnsortedUnreadable)
+
+	// GeoJSON cluster export
+	geoJSONLabel := widget.NewLabel("Cluster Map Export (optional):")
+	geoJSONEntry := widget.NewEntry()
+	geoJSONEntry.SetPlaceHolder("Path for a GeoJSON file of clusters")
+	geoJSONEntry.SetText(draft.GeoJSONPath)
+	geoJSONEntry.OnChanged = func(value string) {
+		draft.GeoJSONPath = strings.TrimSpace(value)
+	}
+	geoJSONBrowseBtn := widget.NewButton("Save As...", func() {
+		saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil || writer == nil {
+				return
+			}
+			path := writer.URI().Path()
+			writer.Close()
+			geoJSONEntry.SetText(path)
+		}, app.window)
+		saveDialog.SetFileName("clusters.geojson")
+		saveDialog.Show()
+	})
+
+	// Camera model options
+	deviceCheck := widget.NewCheck("Separate files into camera model folders", func(checked bool) {
+		draft.SeparateByDevice = checked
+	})
+	deviceCheck.SetChecked(draft.SeparateByDevice)
+	elevationCheck := widget.NewCheck("Separate files into elevation folders (e.g. 1200m) below each location", func(checked bool) {
+		draft.ElevationFolders = checked
+	})
+	elevationCheck.SetChecked(draft.ElevationFolders)
+
+	keepFoldersCheck := widget.NewCheck("Keep source subfolders (albums) below each location/date folder", func(checked bool) {
+		draft.KeepSourceFolders = checked
+	})
+	keepFoldersCheck.SetChecked(draft.KeepSourceFolders)
+	cameraFilterLabel := widget.NewLabel("Only organize camera model:")
+	cameraFilterEntry := widget.NewEntry()
+	cameraFilterEntry.SetPlaceHolder("Any camera (e.g. iPhone 15 Pro)")
+	cameraFilterEntry.SetText(draft.CameraFilter)
+	cameraFilterEntry.OnChanged = func(value string) {
+		draft.CameraFilter = strings.TrimSpace(value)
+	}
+
+	// Audio toggle
+	audioCheck := widget.NewCheck("Include audio files (voice memos, recordings) - organized by date", func(checked bool) {
+		draft.IncludeAudio = checked
+	})
+	audioCheck.SetChecked(draft.IncludeAudio)
+
+	// Hidden/junk file toggle
+	junkCheck := widget.NewCheck("Skip hidden and system files (.DS_Store, ._*, Thumbs.db)", func(checked bool) {
+		draft.SkipJunkFiles = checked
+	})
+	junkCheck.SetChecked(draft.SkipJunkFiles)
+
+	// Symlink toggle
+	symlinkCheck := widget.NewCheck("Follow symbolic links in the source folder", func(checked bool) {
+		draft.FollowSymlinks = checked
+	})
+	symlinkCheck.SetChecked(draft.FollowSymlinks)
+
+	mergeCheck := widget.NewCheck("Merge into existing location folders, keeping ones renamed since (e.g. \"Paris\")", func(checked bool) {
+		draft.MergeLibrary = checked
+	})
+	mergeCheck.SetChecked(draft.MergeLibrary)
+
+	dedupeCheck := widget.NewCheck("Copy identical files only once across all locations, preferring ones with GPS", func(checked bool) {
+		draft.DedupeClusters = checked
+	})
+	dedupeCheck.SetChecked(draft.DedupeClusters)
+
+	// Notification toggle
+	notifyCheck := widget.NewCheck("Show a desktop notification when organization finishes", func(checked bool) {
+		draft.NotifyOnComplete = checked
+	})
+	notifyCheck.SetChecked(draft.NotifyOnComplete)
+
+	// What the progress bar counts while copying
+	progressLabel := widget.NewLabel("Copy progress counts:")
+	progressRadio := widget.NewRadioGroup([]string{ProgressFiles, ProgressBytes}, func(value string) {
+		draft.ProgressMode = value
+	})
+	progressRadio.Horizontal = true
+	progressRadio.Required = true
+	progressRadio.SetSelected(draft.ProgressMode)
+
+	groupingTab := container.NewVBox(
+		sensitivityLabel,
+		sensitivityInfo,
+		sensitivitySlider,
+		sensitivityValueLabel,
+		sensitivityPresetRow,
+		container.NewHBox(widget.NewLabel("Folder name precision:"), nameDecimalSelect),
+		splitCheck,
+		container.NewHBox(widget.NewLabel("Merge clusters with fewer than"), minClusterSelect,
+			widget.NewLabel("files into the nearest larger one, or "+MiscLocationsClusterName)),
+		container.NewHBox(widget.NewLabel("Separate clusters by elevation every:"), elevationBandSelect),
+		widget.NewSeparator(),
+		container.NewHBox(widget.NewLabel("Files without GPS:"), noGPSSelect, widget.NewLabel("within"), noGPSWindowSelect),
+		container.NewHBox(widget.NewLabel("Ignore GPS positions less accurate than:"), gpsAccuracySelect),
+		widget.NewSeparator(),
+		placesLabel,
+		placesList,
+		addPlaceBtn,
+	)
+
+	foldersTab := container.NewVBox(
+		modeLabel,
+		modeRadio,
+		container.NewHBox(granularityLabel, granularitySelect),
+		container.NewHBox(collapseCheck, sparseThresholdSelect, widget.NewLabel("files into a coarser folder")),
+		widget.NewSeparator(),
+		deviceCheck,
+		elevationCheck,
+		container.NewBorder(nil, nil, cameraFilterLabel, nil, cameraFilterEntry),
+		keepFoldersCheck,
+		widget.NewSeparator(),
+		flattenCheck,
+		container.NewHBox(widget.NewLabel("Record location:"), annotationSelect),
+		container.NewBorder(nil, nil, widget.NewLabel("Filename format:"), nil, annotationFormatEntry),
+		renameCheck,
+		container.NewBorder(nil, nil, widget.NewLabel("Filename template:"), nil, renameEntry),
+	)
+
+	copyingTab := container.NewVBox(
+		container.NewHBox(conflictLabel, conflictSelect),
+		container.NewHBox(widget.NewLabel("Place files by:"), copyModeSelect),
+		syncCheck,
+		container.NewHBox(widget.NewLabel("Bursts:"), burstPolicySelect, widget.NewLabel("choosing the"), burstHeuristicSelect),
+		mergeCheck,
+		dedupeCheck,
+		writeMetadataCheck,
+	)
+
+	datesTab := container.NewVBox(
+		datePriorityLabel,
+		datePriorityList,
+		container.NewHBox(timeZoneLabel, timeZoneSelect),
+		gpsTimeZoneCheck,
+		undatedCheck,
+		container.NewHBox(suspectDateLabel, suspectDateSelect),
+	)
+
+	scanningTab := container.NewVBox(
+		audioCheck,
+		junkCheck,
+		symlinkCheck,
+		widget.NewSeparator(),
+		exiftoolLabel,
+		container.NewBorder(nil, nil, nil, exiftoolBrowseBtn, exiftoolEntry),
+		unsortedCheck,
+	)
+
+	performanceTab := container.NewVBox(
+		workerLabel,
+		workerInfo,
+		workerSlider,
+		workerValueLabel,
+		widget.NewSeparator(),
+		exiftoolLimitLabel,
+		exiftoolLimitInfo,
+		exiftoolLimitSlider,
+		exiftoolLimitValueLabel,
+		widget.NewSeparator(),
+		batchLabel,
+		batchInfo,
+		batchSlider,
+		batchValueLabel,
+		container.NewHBox(autoBatchCheck, memoryCeilingSelect),
+	)
+
+	otherTab := container.NewVBox(
+		geoJSONLabel,
+		container.NewBorder(nil, nil, nil, geoJSONBrowseBtn, geoJSONEntry),
+		notifyCheck,
+		container.NewHBox(progressLabel, progressRadio),
+	)
+
+	return container.NewAppTabs(
+		container.NewTabItem("Grouping", container.NewVScroll(groupingTab)),
+		container.NewTabItem("Folders", container.NewVScroll(foldersTab)),
+		container.NewTabItem("Copying", container.NewVScroll(copyingTab)),
+		container.NewTabItem("Dates", container.NewVScroll(datesTab)),
+		container.NewTabItem("Scanning", container.NewVScroll(scanningTab)),
+		container.NewTabItem("Performance", container.NewVScroll(performanceTab)),
+		container.NewTabItem("Other", container.NewVScroll(otherTab)),
+	)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+)
+
+// Profile files written to the temporary folder when profiling is turned on from the GUI
+const (
+	CPUProfileName    = "media-organizer-cpu.prof"
+	MemoryProfileName = "media-organizer-mem.prof"
+)
+
+// startProfiling starts a CPU profile when the organizer has a CPU profile path and
+// returns the function that stops it and writes the allocation profile, if one was
+// asked for. With neither path set nothing is started and the returned function does
+// nothing, so runs that aren't profiled pay nothing for it.
+func (org *Organizer) startProfiling() func() {
+	// Toggling profiling during a run takes effect from the next one
+	cpuPath, memPath := org.cpuProfilePath, org.memProfilePath
+	if cpuPath == "" && memPath == "" {
+		return func() {}
+	}
+
+	var cpuFile *os.File
+	if cpuPath != "" {
+		file, err := os.Create(cpuPath)
+		if err != nil {
+			org.safeLog(fmt.Sprintf("Warning: Could not create CPU profile: %v\n", err))
+		} else if err := pprof.StartCPUProfile(file); err != nil {
+			org.safeLog(fmt.Sprintf("Warning: Could not start CPU profile: %v\n", err))
+			file.Close()
+		} else {
+			cpuFile = file
+		}
+	}
+
+	return func() {
+		if cpuFile != nil {
+			pprof.StopCPUProfile()
+			if err := cpuFile.Close(); err != nil {
+				org.safeLog(fmt.Sprintf("Warning: Could not write CPU profile: %v\n", err))
+			} else {
+				org.safeLog(fmt.Sprintf("CPU profile written to %s\n", cpuPath))
+			}
+		}
+		if memPath != "" {
+			org.writeMemoryProfile(memPath)
+		}
+	}
+}
+
+// writeMemoryProfile writes the allocations made since the program started to path
+func (org *Organizer) writeMemoryProfile(path string) {
+	file, err := os.Create(path)
+	if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not create memory profile: %v\n", err))
+		return
+	}
+	defer file.Close()
+
+	// Fold the latest garbage collection into the in-use figures
+	runtime.GC()
+	if err := pprof.Lookup("allocs").WriteTo(file, 0); err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not write memory profile: %v\n", err))
+		return
+	}
+	org.safeLog(fmt.Sprintf("Memory profile written to %s\n", path))
+}
+
+// setProfiling turns profiling of the following runs on or off, writing the profiles
+// to the temporary folder
+func (org *Organizer) setProfiling(enabled bool) {
+	if !enabled {
+		org.cpuProfilePath, org.memProfilePath = "", ""
+		return
+	}
+	org.cpuProfilePath = filepath.Join(os.TempDir(), CPUProfileName)
+	org.memProfilePath = filepath.Join(os.TempDir(), MemoryProfileName)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// What the progress bar measures while copying. A few large videos take far longer than
+// the same number of photos, so counting bytes shows how much of the work is left.
+const (
+	ProgressFiles = "Files"
+	ProgressBytes = "Bytes"
+)
+
+// startCopyProgress resets the copy progress to plan, which the copy phase is about to
+// carry out. planCopy must have filled in the file sizes.
+func (org *Organizer) startCopyProgress(plan CopyPlan) {
+	org.copyFilesDone.Store(0)
+	org.copyBytesDone.Store(0)
+	org.copyBytesTotal.Store(plan.Bytes)
+	org.copyFilesTotal.Store(int64(plan.Files))
+}
+
+// countCopied counts info as done towards the copy progress, whether it was placed,
+// skipped or failed. bytesBefore is the bytes done before it was started, so the part
+// of it counted while copying isn't counted twice.
+func (org *Organizer) countCopied(info *ImageInfo, bytesBefore int64) {
+	org.copyBytesDone.Store(bytesBefore + info.Size)
+	org.copyFilesDone.Add(1)
+}
+
+// countingReader adds the bytes read through it to a counter, so copies of large files
+// show progress while they're under way
+type countingReader struct {
+	reader  io.Reader
+	counter *atomic.Int64
+}
+
+// Read reads from the underlying reader and counts what it read
+func (r countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.counter.Add(int64(n))
+	return n, err
+}
+
+// progress returns how far the run is, from 0 to 1, and describes it for the progress
+// bar. Copying is measured in the chosen progressMode; reading metadata always in files.
+func (app *App) progress() (float64, string) {
+	if files := app.copyFilesTotal.Load(); files > 0 {
+		if bytes := app.copyBytesTotal.Load(); app.progressMode == ProgressBytes && bytes > 0 {
+			done := min(app.copyBytesDone.Load(), bytes)
+			return float64(done) / float64(bytes), fmt.Sprintf("Copying: %s of %s", formatBytes(done), formatBytes(bytes))
+		}
+		done := app.copyFilesDone.Load()
+		return float64(done) / float64(files), fmt.Sprintf("Copying: %d of %d files", done, files)
+	}
+	if total := app.totalFiles.Load(); total > 0 {
+		processed := app.processedFiles.Load()
+		return float64(processed) / float64(total), fmt.Sprintf("Reading: %d of %d files", processed, total)
+	}
+	return 0, ""
+}
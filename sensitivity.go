@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const (
+	// metersPerDegree is the length of a degree of latitude, for showing sensitivities
+	// as distances
+	metersPerDegree = 111000
+	// Sensitivity slider range in degrees; the slider moves along a logarithmic scale
+	// so street-level and region-level grouping are both in easy reach
+	minSensitivity = 0.0001
+	maxSensitivity = 0.5
+)
+
+// sensitivityPreset is a named location sensitivity for people who think in places
+// rather than degrees
+type sensitivityPreset struct {
+	Name   string
+	Meters float64
+}
+
+// sensitivityPresets are offered as buttons beside the sensitivity slider
+var sensitivityPresets = []sensitivityPreset{
+	{"Street", 50},
+	{"Neighborhood", 500},
+	{"City", 5000},
+	{"Region", 50000},
+}
+
+// Degrees returns the sensitivity of the preset, to the slider's precision
+func (preset sensitivityPreset) Degrees() float64 {
+	return roundSignificant(preset.Meters/metersPerDegree, 2)
+}
+
+// sensitivityPresetName returns the name of the preset matching sensitivity, if any
+func sensitivityPresetName(sensitivity float64) (string, bool) {
+	for _, preset := range sensitivityPresets {
+		if math.Abs(sensitivity-preset.Degrees()) <= sensitivity*1e-9 {
+			return preset.Name, true
+		}
+	}
+	return "", false
+}
+
+// sensitivityFromSlider converts a slider position, the base-10 logarithm of the
+// sensitivity, into degrees with two significant digits
+func sensitivityFromSlider(position float64) float64 {
+	return roundSignificant(math.Pow(10, position), 2)
+}
+
+// formatSensitivity describes sensitivity in degrees and roughly in meters or
+// kilometers, with the preset it matches
+func formatSensitivity(sensitivity float64) string {
+	meters := roundSignificant(sensitivity*metersPerDegree, 2)
+	text := fmt.Sprintf("%s° (~%s)", strconv.FormatFloat(sensitivity, 'f', -1, 64), formatDistance(meters))
+	if name, ok := sensitivityPresetName(sensitivity); ok {
+		text += " - " + name
+	}
+	return text
+}
+
+// formatDistance renders meters in meters or, from a kilometer up, kilometers
+func formatDistance(meters float64) string {
+	switch {
+	case meters >= 10000:
+		return fmt.Sprintf("%.0f km", meters/1000)
+	case meters >= 1000:
+		return strconv.FormatFloat(meters/1000, 'f', -1, 64) + " km"
+	}
+	return fmt.Sprintf("%.0f m", meters)
+}
+
+// roundSignificant rounds value to the given number of significant digits
+func roundSignificant(value float64, digits int) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'g', digits, 64), 64)
+	return rounded
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// Settings are the organizer settings the user chooses, as saved between sessions. The
+// folders of a run and per-run toggles like a forced full re-run aren't included.
+type Settings struct {
+	LocationSensitivity float64  `json:"locationSensitivity"`
+	NameDecimals        int      `json:"nameDecimals"`
+	SplitCells          bool     `json:"splitCells"`
+	MinClusterSize      int      `json:"minClusterSize"`
+	ElevationBand       float64  `json:"elevationBand"`
+	MaxGPSError         float64  `json:"maxGPSError"`
+	NoGPSPolicy         string   `json:"noGPSPolicy"`
+	NoGPSWindow         Duration `json:"noGPSWindow"`
+	RouteUndated        bool     `json:"routeUndated"`
+	SuspectDates        string   `json:"suspectDates"`
+
+	OrganizeMode        string `json:"organizeMode"`
+	DateGranularity     string `json:"dateGranularity"`
+	CollapseSparseDates bool   `json:"collapseSparseDates"`
+	SparseDateThreshold int    `json:"sparseDateThreshold"`
+	FlattenByDate       bool   `json:"flattenByDate"`
+	LocationAnnotation  string `json:"locationAnnotation"`
+	AnnotationFormat    string `json:"annotationFormat"`
+	RenameOnCopy        bool   `json:"renameOnCopy"`
+	RenameTemplate      string `json:"renameTemplate"`
+	WriteCopyMetadata   bool   `json:"writeCopyMetadata"`
+	SeparateByDevice    bool   `json:"separateByDevice"`
+	ElevationFolders    bool   `json:"elevationFolders"`
+	KeepSourceFolders   bool   `json:"keepSourceFolders"`
+	CameraFilter        string `json:"cameraFilter"`
+	ConflictPolicy      string `json:"conflictPolicy"`
+	CopyMode            string `json:"copyMode"`
+	SyncCopies          bool   `json:"syncCopies"`
+	BurstPolicy         string `json:"burstPolicy"`
+	BurstHeuristic      string `json:"burstHeuristic"`
+	MergeLibrary        bool   `json:"mergeLibrary"`
+	DedupeClusters      bool   `json:"dedupeClusters"`
+
+	DatePriority   []string `json:"datePriority"`
+	ExifTimeZone   string   `json:"exifTimeZone"`
+	UseGPSTimeZone bool     `json:"useGPSTimeZone"`
+
+	IncludeAudio       bool   `json:"includeAudio"`
+	SkipJunkFiles      bool   `json:"skipJunkFiles"`
+	FollowSymlinks     bool   `json:"followSymlinks"`
+	UnsortedUnreadable bool   `json:"unsortedUnreadable"`
+	GeoJSONPath        string `json:"geoJSONPath"`
+
+	DecodeWorkers   int    `json:"decodeWorkers"`
+	ExiftoolWorkers int    `json:"exiftoolWorkers"`
+	BatchSize       int    `json:"batchSize"`
+	AutoBatchSize   bool   `json:"autoBatchSize"`
+	MemoryCeiling   uint64 `json:"memoryCeiling"`
+}
+
+// Duration is a time.Duration saved as text like "1h0m0s"
+type Duration time.Duration
+
+// MarshalText renders the duration like time.Duration.String
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText parses a duration written by MarshalText
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Settings returns the organizer's current settings
+func (org *Organizer) Settings() Settings {
+	return Settings{
+		LocationSensitivity: org.locationSensitivity,
+		NameDecimals:        org.nameDecimals,
+		SplitCells:          org.splitCells,
+		MinClusterSize:      org.minClusterSize,
+		ElevationBand:       org.elevationBand,
+		MaxGPSError:         org.maxGPSError,
+		NoGPSPolicy:         org.noGPSPolicy,
+		NoGPSWindow:         Duration(org.noGPSWindow),
+		RouteUndated:        org.routeUndated,
+		SuspectDates:        org.suspectDatePolicy,
+		OrganizeMode:        org.organizeMode,
+		DateGranularity:     org.dateGranularity,
+		CollapseSparseDates: org.collapseSparseDates,
+		SparseDateThreshold: org.sparseDateThreshold,
+		FlattenByDate:       org.flattenByDate,
+		LocationAnnotation:  org.locationAnnotation,
+		AnnotationFormat:    org.annotationFormat,
+		RenameOnCopy:        org.renameOnCopy,
+		RenameTemplate:      org.renameTemplate,
+		WriteCopyMetadata:   org.writeCopyMetadata,
+		SeparateByDevice:    org.separateByDevice,
+		ElevationFolders:    org.elevationFolders,
+		KeepSourceFolders:   org.keepSourceFolders,
+		CameraFilter:        org.cameraFilter,
+		ConflictPolicy:      org.conflictPolicy,
+		CopyMode:            org.copyMode,
+		SyncCopies:          org.syncCopies,
+		BurstPolicy:         org.burstPolicy,
+		BurstHeuristic:      org.burstHeuristic,
+		MergeLibrary:        org.mergeLibrary,
+		DedupeClusters:      org.dedupeClusters,
+		DatePriority:        slices.Clone(org.datePriority),
+		ExifTimeZone:        org.exifTimeZone,
+		UseGPSTimeZone:      org.useGPSTimeZone,
+		IncludeAudio:        org.includeAudio,
+		SkipJunkFiles:       org.skipJunkFiles,
+		FollowSymlinks:      org.followSymlinks,
+		UnsortedUnreadable:  org.unsortedUnreadable,
+		GeoJSONPath:         org.geoJSONPath,
+		DecodeWorkers:       org.decodeWorkers,
+		ExiftoolWorkers:     org.exiftoolWorkers,
+		BatchSize:           org.batchSize,
+		AutoBatchSize:       org.autoBatchSize,
+		MemoryCeiling:       org.memoryCeiling,
+	}
+}
+
+// ApplySettings replaces the organizer's settings. It must not be called during a run.
+func (org *Organizer) ApplySettings(settings Settings) {
+	org.locationSensitivity = settings.LocationSensitivity
+	org.nameDecimals = settings.NameDecimals
+	org.splitCells = settings.SplitCells
+	org.minClusterSize = settings.MinClusterSize
+	org.elevationBand = settings.ElevationBand
+	org.maxGPSError = settings.MaxGPSError
+	org.noGPSPolicy = settings.NoGPSPolicy
+	org.noGPSWindow = time.Duration(settings.NoGPSWindow)
+	org.routeUndated = settings.RouteUndated
+	org.suspectDatePolicy = settings.SuspectDates
+	org.organizeMode = settings.OrganizeMode
+	org.dateGranularity = settings.DateGranularity
+	org.collapseSparseDates = settings.CollapseSparseDates
+	org.sparseDateThreshold = settings.SparseDateThreshold
+	org.flattenByDate = settings.FlattenByDate
+	org.locationAnnotation = settings.LocationAnnotation
+	org.annotationFormat = settings.AnnotationFormat
+	org.renameOnCopy = settings.RenameOnCopy
+	org.renameTemplate = settings.RenameTemplate
+	org.writeCopyMetadata = settings.WriteCopyMetadata
+	org.separateByDevice = settings.SeparateByDevice
+	org.elevationFolders = settings.ElevationFolders
+	org.keepSourceFolders = settings.KeepSourceFolders
+	org.cameraFilter = settings.CameraFilter
+	org.conflictPolicy = settings.ConflictPolicy
+	org.copyMode = settings.CopyMode
+	org.syncCopies = settings.SyncCopies
+	org.burstPolicy = settings.BurstPolicy
+	org.burstHeuristic = settings.BurstHeuristic
+	org.mergeLibrary = settings.MergeLibrary
+	org.dedupeClusters = settings.DedupeClusters
+	org.datePriority = slices.Clone(settings.DatePriority)
+	org.exifTimeZone = settings.ExifTimeZone
+	org.useGPSTimeZone = settings.UseGPSTimeZone
+	org.includeAudio = settings.IncludeAudio
+	org.skipJunkFiles = settings.SkipJunkFiles
+	org.followSymlinks = settings.FollowSymlinks
+	org.unsortedUnreadable = settings.UnsortedUnreadable
+	org.geoJSONPath = settings.GeoJSONPath
+	org.decodeWorkers = settings.DecodeWorkers
+	org.exiftoolWorkers = settings.ExiftoolWorkers
+	org.batchSize = settings.BatchSize
+	org.autoBatchSize = settings.AutoBatchSize
+	org.memoryCeiling = settings.MemoryCeiling
+}
+
+// parseSettings decodes saved settings over defaults, so settings added since they were
+// saved keep their defaults. Choices this version doesn't offer revert to the default.
+func parseSettings(data []byte, defaults Settings) (Settings, error) {
+	settings := defaults
+	settings.DatePriority = nil // Replaced rather than merged by decoding
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return defaults, err
+	}
+
+	choose := func(value *string, fallback string, choices ...string) {
+		if !slices.Contains(choices, *value) {
+			*value = fallback
+		}
+	}
+	choose(&settings.NoGPSPolicy, defaults.NoGPSPolicy, NoGPSFolder, NoGPSDateOnly, NoGPSNearestInTime, NoGPSInterpolate)
+	choose(&settings.OrganizeMode, defaults.OrganizeMode, ModeLocationAndDate, ModeDateOnly, ModeLocationOnly)
+	choose(&settings.DateGranularity, defaults.DateGranularity, GranularityDay, GranularityWeek, GranularityMonth, GranularityYear)
+	choose(&settings.LocationAnnotation, defaults.LocationAnnotation, AnnotateFilename, AnnotateSidecar, AnnotateNone)
+	choose(&settings.ConflictPolicy, defaults.ConflictPolicy, ConflictRename, ConflictSkip, ConflictOverwrite, ConflictKeepNewest, ConflictKeepLatest)
+	choose(&settings.CopyMode, defaults.CopyMode, CopyModeCopy, CopyModeHardLink, CopyModeClone)
+	choose(&settings.BurstPolicy, defaults.BurstPolicy, BurstKeepBest, BurstRejectRest, BurstKeepAll)
+	choose(&settings.BurstHeuristic, defaults.BurstHeuristic, BestSharpest, BestResolution, BestLargest)
+	choose(&settings.ExifTimeZone, defaults.ExifTimeZone, TimeZoneLocal, TimeZoneUTC)
+	choose(&settings.SuspectDates, defaults.SuspectDates, SuspectDateFlag, SuspectDateClamp, SuspectDateFolder)
+
+	// The date sources must be the known ones, each once
+	priority := slices.Clone(settings.DatePriority)
+	slices.Sort(priority)
+	known := slices.Clone(DefaultDatePriority)
+	slices.Sort(known)
+	if !slices.Equal(priority, known) {
+		settings.DatePriority = defaults.DatePriority
+	}
+
+	if !(settings.LocationSensitivity >= minSensitivity && settings.LocationSensitivity <= maxSensitivity) {
+		settings.LocationSensitivity = defaults.LocationSensitivity
+	}
+	if settings.MinClusterSize < 0 {
+		settings.MinClusterSize = defaults.MinClusterSize
+	}
+	if settings.SparseDateThreshold < 2 {
+		settings.SparseDateThreshold = defaults.SparseDateThreshold
+	}
+	if settings.DecodeWorkers < 1 {
+		settings.DecodeWorkers = defaults.DecodeWorkers
+	}
+	if settings.ExiftoolWorkers < 1 {
+		settings.ExiftoolWorkers = defaults.ExiftoolWorkers
+	}
+	if settings.BatchSize < 1 {
+		settings.BatchSize = defaults.BatchSize
+	}
+	if settings.MemoryCeiling == 0 {
+		settings.MemoryCeiling = defaults.MemoryCeiling
+	}
+	return settings, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// simulatedFilesPerTrip is the average number of photos per synthetic location
+	simulatedFilesPerTrip = 200
+	// simulatedNoGPSShare is the fraction of synthetic photos without a GPS position
+	simulatedNoGPSShare = 0.1
+)
+
+// SimulationReport describes a clustering run over synthetic data
+type SimulationReport struct {
+	Files        int           `json:"files"`
+	WithGPS      int           `json:"with_gps"`
+	Clusters     int           `json:"clusters"`
+	Workers      int           `json:"workers"`
+	Sensitivity  float64       `json:"sensitivity"`
+	AddTime      time.Duration `json:"add_ns"`
+	ClusterTime  time.Duration `json:"cluster_ns"`
+	HeapInUse    uint64        `json:"heap_bytes"`
+	TotalAlloc   uint64        `json:"allocated_bytes"`
+	LargestCount int           `json:"largest_cluster"`
+}
+
+// String renders the report for the terminal
+func (sr SimulationReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Simulated files: %d (%d with GPS)\n", sr.Files, sr.WithGPS)
+	fmt.Fprintf(&sb, "Settings: %d workers, sensitivity %g\n", sr.Workers, sr.Sensitivity)
+	fmt.Fprintf(&sb, "Clusters: %d (largest: %d files)\n", sr.Clusters, sr.LargestCount)
+	rate := 0.0
+	if sr.AddTime > 0 {
+		rate = float64(sr.Files) / sr.AddTime.Seconds()
+	}
+	fmt.Fprintf(&sb, "Adding to grid: %v (%.0f files/s)\n", sr.AddTime, rate)
+	fmt.Fprintf(&sb, "Clustering: %v\n", sr.ClusterTime)
+	fmt.Fprintf(&sb, "Memory: %s heap in use, %s allocated", formatBytes(int64(sr.HeapInUse)), formatBytes(int64(sr.TotalAlloc)))
+	return sb.String()
+}
+
+// syntheticImages generates count photo records grouped into trips: each trip has a
+// random center and start time, and its photos are scattered within a few hundred meters
+// and a few days of them. The same seed always yields the same records.
+func syntheticImages(count int, seed int64) []*ImageInfo {
+	rng := rand.New(rand.NewSource(seed))
+	trips := count/simulatedFilesPerTrip + 1
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type trip struct {
+		lat, lng float64
+		date     time.Time
+	}
+	centers := make([]trip, trips)
+	for i := range centers {
+		centers[i] = trip{
+			lat:  rng.Float64()*130 - 60, // Where people live, more or less
+			lng:  rng.Float64()*360 - 180,
+			date: start.Add(time.Duration(rng.Int63n(int64(10 * 365 * 24 * time.Hour)))),
+		}
+	}
+
+	infos := make([]*ImageInfo, count)
+	for i := range infos {
+		center := centers[rng.Intn(trips)]
+		info := &ImageInfo{
+			OriginalPath: fmt.Sprintf("simulated/IMG_%07d.jpg", i),
+			Date:         center.date.Add(time.Duration(rng.Int63n(int64(72 * time.Hour)))),
+			Location:     NoLocationClusterName,
+		}
+		if rng.Float64() >= simulatedNoGPSShare {
+			// Roughly 300m of scatter around the trip's center
+			info.Latitude = center.lat + rng.NormFloat64()*0.003
+			info.Longitude = center.lng + rng.NormFloat64()*0.003/math.Max(0.1, math.Cos(center.lat*math.Pi/180))
+			info.HasGPS = true
+		}
+		infos[i] = info
+	}
+	return infos
+}
+
+// Simulate clusters count synthetic photos with the organizer's current clustering
+// settings, without reading or copying any files, and reports how long it took and how
+// much memory it used. It is a repeatable benchmark for sensitivity and worker counts.
+func (org *Organizer) Simulate(count int, seed int64) SimulationReport {
+	infos := syntheticImages(count, seed)
+
+	org.spatialGrid = newClusterGrid(org.locationSensitivity, org.clusterStrategy())
+	org.runStats = NewRunStats()
+	org.estimatedLocations = make(map[string]locationEstimate)
+
+	report := SimulationReport{Files: count, Workers: org.decodeWorkers, Sensitivity: org.locationSensitivity}
+	for _, info := range infos {
+		if info.HasGPS {
+			info.Location = formatLocation(info.Latitude, info.Longitude, fileLocationDecimals)
+			report.WithGPS++
+		}
+	}
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	// Workers add to the shared grid concurrently, as they do in a real run
+	started := time.Now()
+	var wg sync.WaitGroup
+	workers := max(1, org.decodeWorkers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(infos); i += workers {
+				org.spatialGrid.AddImage(infos[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+	report.AddTime = time.Since(started)
+
+	started = time.Now()
+	org.locateFilesWithoutGPS()
+	org.splitSeparatedCells()
+	clusters := org.mergeStrayClusters(org.spatialGrid.GetClusters())
+	report.ClusterTime = time.Since(started)
+
+	runtime.ReadMemStats(&after)
+	report.HeapInUse = after.HeapInuse
+	report.TotalAlloc = after.TotalAlloc - before.TotalAlloc
+
+	report.Clusters = len(clusters)
+	for _, cluster := range clusters {
+		report.LargestCount = max(report.LargestCount, len(cluster.Images))
+	}
+
+	org.spatialGrid.Clear()
+	return report
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// splitResolution is how many times finer than the grid the points of a cell are
+// examined for separate groups: groups are separate when a quarter of a cell or more
+// of empty space lies between them
+const splitResolution = 4
+
+// cellPoint is the position of one image in a grid cell, kept so the cell can be split
+type cellPoint struct {
+	Path         string
+	Lat, Lng     float64
+	Elevation    float64
+	HasElevation bool
+	Borrowed     bool // Placed at the nearest-in-time photo's position; doesn't shape groups or the center
+}
+
+// cellSplit describes a cell that was split into several clusters
+type cellSplit struct {
+	Lat, Lng float64 // Center of the cell before splitting
+	Parts    int
+}
+
+// fineCell identifies a cell of the finer grid used to look for separate groups
+type fineCell struct{ Lat, Lng int64 }
+
+// SplitSeparatedCells splits every cell whose images form groups separated by empty
+// space into one cell per group, so two towns that share a coarse cell get a cluster
+// each. It does nothing unless the grid keeps points, and returns the splits made.
+func (sg *SpatialGrid) SplitSeparatedCells() []cellSplit {
+	sg.mutex.Lock()
+	defer sg.mutex.Unlock()
+
+	if !sg.splitCells {
+		return nil
+	}
+
+	// Sorted, so the split cells get the same keys on every run
+	keys := make([]string, 0, len(sg.cells))
+	for key := range sg.cells {
+		if key != noLocationKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var splits []cellSplit
+	for _, key := range keys {
+		cell := sg.cells[key]
+		groups := sg.separateGroups(cell.Points)
+		if len(groups) < 2 {
+			continue
+		}
+
+		lat, lng := cell.Center()
+		splits = append(splits, cellSplit{Lat: lat, Lng: lng, Parts: len(groups)})
+		delete(sg.cells, key)
+		for i, points := range groups {
+			part := &GridCell{Band: cell.Band, HasBand: cell.HasBand}
+			for _, point := range points {
+				part.addPoint(point, true)
+			}
+			sg.cells[fmt.Sprintf("%s,s%d", key, i+1)] = part
+		}
+	}
+	return splits
+}
+
+// separateGroups partitions points into groups of neighboring cells on the fine grid,
+// ordered by position. Borrowed points join the group of the position they borrowed.
+func (sg *SpatialGrid) separateGroups(points []cellPoint) [][]cellPoint {
+	size := sg.sensitivity / splitResolution
+	fineCellOf := func(point cellPoint) fineCell {
+		return fineCell{int64(math.Floor(point.Lat / size)), int64(math.Floor(point.Lng / size))}
+	}
+
+	group := make(map[fineCell]int)
+	for _, point := range points {
+		if !point.Borrowed {
+			group[fineCellOf(point)] = -1
+		}
+	}
+	if len(group) < 2 {
+		return nil
+	}
+
+	// Flood fill the occupied fine cells, including diagonal neighbors
+	occupied := make([]fineCell, 0, len(group))
+	for fc := range group {
+		occupied = append(occupied, fc)
+	}
+	sort.Slice(occupied, func(i, j int) bool {
+		if occupied[i].Lat != occupied[j].Lat {
+			return occupied[i].Lat < occupied[j].Lat
+		}
+		return occupied[i].Lng < occupied[j].Lng
+	})
+	count := 0
+	for _, start := range occupied {
+		if group[start] >= 0 {
+			continue
+		}
+		group[start] = count
+		pending := []fineCell{start}
+		for len(pending) > 0 {
+			fc := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			for dLat := int64(-1); dLat <= 1; dLat++ {
+				for dLng := int64(-1); dLng <= 1; dLng++ {
+					neighbor := fineCell{fc.Lat + dLat, fc.Lng + dLng}
+					if g, ok := group[neighbor]; ok && g < 0 {
+						group[neighbor] = count
+						pending = append(pending, neighbor)
+					}
+				}
+			}
+		}
+		count++
+	}
+	if count < 2 {
+		return nil
+	}
+
+	groups := make([][]cellPoint, count)
+	for _, point := range points {
+		g, ok := group[fineCellOf(point)]
+		if !ok {
+			// A borrowed position always belongs to a located point, but be safe
+			g = 0
+		}
+		groups[g] = append(groups[g], point)
+	}
+	return groups
+}
+
+// splitSeparatedCells splits clusters holding separate places, when enabled, and logs
+// each split
+func (org *Organizer) splitSeparatedCells() {
+	decimals := locationDecimals(org.locationSensitivity)
+	for _, split := range org.spatialGrid.SplitSeparatedCells() {
+		org.safeLog(fmt.Sprintf("Split the cluster around %s into %d separate places\n",
+			formatLocation(split.Lat, split.Lng, decimals), split.Parts))
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// MiscLocationsClusterName is the cluster collecting the files of small clusters that
+// have no larger cluster nearby. Its files are spread out, so it has no center and,
+// like No-Location, never takes part in distance calculations or map exports.
+const MiscLocationsClusterName = "Misc-Locations"
+
+// strayMergeDistance is how far in meters a small cluster may be from a larger one to
+// be merged into it; farther ones go to MiscLocationsClusterName
+const strayMergeDistance = 50000
+
+// clusterMerge describes a small cluster folded into another
+type clusterMerge struct {
+	From     string
+	Into     string // A larger cluster, or MiscLocationsClusterName
+	Files    int
+	Distance float64 // Meters to the cluster merged into; 0 for MiscLocationsClusterName
+}
+
+// mergeStrayClusters folds every located cluster with fewer than minSize files into the
+// nearest cluster with at least minSize files, when one is within strayMergeDistance,
+// and into a MiscLocationsClusterName cluster otherwise. A single photo from a layover
+// then doesn't get a folder of its own. Clusters named after labeled places are kept
+// whatever their size, and a minSize below 2 changes nothing.
+//
+// clusters must be sorted as GetClusters returns them. Small clusters are only merged
+// into clusters that were large to begin with, and ties in distance go to the first,
+// so the result doesn't depend on the order merges are made in. Merged clusters keep
+// their center and name. It returns the clusters, sorted, and the merges made.
+func mergeStrayClusters(clusters []LocationCluster, minSize int) ([]LocationCluster, []clusterMerge) {
+	if minSize < 2 {
+		return clusters, nil
+	}
+
+	stray := func(cluster LocationCluster) bool {
+		return cluster.HasLocation && !cluster.Place && len(cluster.Images) < minSize
+	}
+	var targets []int
+	for i, cluster := range clusters {
+		if cluster.HasLocation && len(cluster.Images) >= minSize {
+			targets = append(targets, i)
+		}
+	}
+
+	var merges []clusterMerge
+	merged := make(map[int][]string) // Images added to each target
+	var misc []string
+	for _, cluster := range clusters {
+		if !stray(cluster) {
+			continue
+		}
+		best, bestDistance := -1, math.Inf(1)
+		for _, target := range targets {
+			distance := distanceMeters(cluster.CenterLat, cluster.CenterLng, clusters[target].CenterLat, clusters[target].CenterLng)
+			if distance < bestDistance {
+				best, bestDistance = target, distance
+			}
+		}
+
+		if best == -1 || bestDistance > strayMergeDistance {
+			misc = append(misc, cluster.Images...)
+			merges = append(merges, clusterMerge{From: cluster.Name, Into: MiscLocationsClusterName, Files: len(cluster.Images)})
+			continue
+		}
+		merged[best] = append(merged[best], cluster.Images...)
+		merges = append(merges, clusterMerge{From: cluster.Name, Into: clusters[best].Name, Files: len(cluster.Images), Distance: bestDistance})
+	}
+	if len(merges) == 0 {
+		return clusters, nil
+	}
+
+	result := make([]LocationCluster, 0, len(clusters)-len(merges)+1)
+	for i, cluster := range clusters {
+		if stray(cluster) {
+			continue
+		}
+		if images, ok := merged[i]; ok {
+			cluster.Images = sortedImages(append(cluster.Images, images...))
+		}
+		result = append(result, cluster)
+	}
+	if len(misc) > 0 {
+		result = append(result, LocationCluster{Name: MiscLocationsClusterName, Images: sortedImages(misc)})
+		sortClusters(result)
+	}
+	return result, merges
+}
+
+// mergeStrayClusters folds clusters smaller than the minimum cluster size into nearby
+// larger ones, or Misc-Locations, and logs each merge
+func (org *Organizer) mergeStrayClusters(clusters []LocationCluster) []LocationCluster {
+	clusters, merges := mergeStrayClusters(clusters, org.minClusterSize)
+	toMisc := 0
+	for _, merge := range merges {
+		if merge.Into == MiscLocationsClusterName {
+			toMisc++
+			org.safeLog(fmt.Sprintf("Moved %d files of %s to %s: no cluster of %d or more files within %s\n",
+				merge.Files, merge.From, merge.Into, org.minClusterSize, formatDistance(strayMergeDistance)))
+			continue
+		}
+		org.safeLog(fmt.Sprintf("Merged %d files of %s into %s, %s away\n",
+			merge.Files, merge.From, merge.Into, formatDistance(math.Round(merge.Distance/100)*100)))
+	}
+	if len(merges) > 0 {
+		org.safeLog(fmt.Sprintf("Merged %d clusters with fewer than %d files: %d into nearby clusters, %d into %s\n",
+			len(merges), org.minClusterSize, len(merges)-toMisc, toMisc, MiscLocationsClusterName))
+	}
+	return clusters
+}
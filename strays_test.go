@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMergeStrayClusters(t *testing.T) {
+	// Degrees of latitude spanning meters
+	degrees := func(meters float64) float64 { return meters / (math.Pi / 180 * earthRadiusMeters) }
+	cluster := func(name string, lat, lng float64, files int) LocationCluster {
+		c := LocationCluster{Name: name, CenterLat: lat, CenterLng: lng, HasLocation: true}
+		for i := 0; i < files; i++ {
+			c.Images = append(c.Images, fmt.Sprintf("/photos/%s-%d.jpg", name, i))
+		}
+		return c
+	}
+	noLocation := LocationCluster{Name: NoLocationClusterName, Images: []string{"/photos/scan.jpg"}}
+	place := cluster("Home", 10, 10, 1)
+	place.Place = true
+
+	tests := []struct {
+		name     string
+		clusters []LocationCluster
+		want     map[string]int // Files in each resulting cluster
+		into     map[string]string
+	}{
+		{"nearest larger cluster", []LocationCluster{
+			cluster("Paris", 48, 2, 3), cluster("Orleans", 47.9, 1.9, 3), cluster("Stray", 48.01, 2.01, 1),
+		}, map[string]int{"Paris": 4, "Orleans": 3}, map[string]string{"Stray": "Paris"}},
+		{"within the merge distance", []LocationCluster{
+			cluster("Paris", 48, 2, 3), cluster("North", 48+degrees(strayMergeDistance-100), 2, 2),
+		}, map[string]int{"Paris": 5}, map[string]string{"North": "Paris"}},
+		{"past the merge distance", []LocationCluster{
+			cluster("Paris", 48, 2, 3), cluster("North", 48+degrees(strayMergeDistance+100), 2, 2),
+		}, map[string]int{"Paris": 3, MiscLocationsClusterName: 2}, map[string]string{"North": MiscLocationsClusterName}},
+		{"no larger cluster", []LocationCluster{
+			cluster("A", 48, 2, 1), cluster("B", 48.001, 2, 2), noLocation,
+		}, map[string]int{MiscLocationsClusterName: 3, NoLocationClusterName: 1}, map[string]string{"A": MiscLocationsClusterName, "B": MiscLocationsClusterName}},
+		{"places and no location are kept", []LocationCluster{
+			cluster("Paris", 48, 2, 3), place, noLocation,
+		}, map[string]int{"Paris": 3, "Home": 1, NoLocationClusterName: 1}, map[string]string{}},
+	}
+	for _, tt := range tests {
+		sortClusters(tt.clusters)
+		clusters, merges := mergeStrayClusters(tt.clusters, 3)
+
+		files := make(map[string]int)
+		for _, cluster := range clusters {
+			files[cluster.Name] = len(cluster.Images)
+		}
+		into := make(map[string]string)
+		for _, merge := range merges {
+			into[merge.From] = merge.Into
+		}
+		if !reflect.DeepEqual(files, tt.want) {
+			t.Errorf("%s: clusters %v, want %v", tt.name, files, tt.want)
+		}
+		if !reflect.DeepEqual(into, tt.into) {
+			t.Errorf("%s: merged %v, want %v", tt.name, into, tt.into)
+		}
+	}
+
+	// A minimum size below 2 changes nothing
+	clusters := []LocationCluster{cluster("A", 48, 2, 1), cluster("B", 10, 10, 1)}
+	if merged, merges := mergeStrayClusters(clusters, 1); !reflect.DeepEqual(merged, clusters) || merges != nil {
+		t.Errorf("minimum size 1 merged %v", merges)
+	}
+}
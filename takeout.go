@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// takeoutSupplementalSuffix is what newer Takeout exports add before ".json"
+	takeoutSupplementalSuffix = ".supplemental-metadata"
+	// takeoutMaxNameLength is the longest sidecar name Takeout writes, ".json" included;
+	// longer names are cut short before the extension
+	takeoutMaxNameLength = 51
+)
+
+// takeoutDuplicatePattern matches the "(1)" Takeout adds before the extension of a
+// second file with the same name, whose sidecar then ends "jpg(1).json", or
+// "jpg.supplemental-metadata(1).json" in newer exports
+var takeoutDuplicatePattern = regexp.MustCompile(`^(.*)(\(\d+\))(\.[^.]*)$`)
+
+// takeoutTime is a Takeout timestamp: seconds since the epoch, as a string in most
+// exports and as a number in some
+type takeoutTime struct {
+	Timestamp json.Number `json:"timestamp"`
+}
+
+// takeoutGeo is a Takeout position; exports without one write zeroes
+type takeoutGeo struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+}
+
+// takeoutSidecar is the part of a Google Photos Takeout sidecar the organizer reads.
+// Older exports kept the camera's position only in geoDataExif; newer ones fill geoData,
+// which also holds locations added in Google Photos.
+type takeoutSidecar struct {
+	PhotoTakenTime *takeoutTime `json:"photoTakenTime"`
+	GeoData        *takeoutGeo  `json:"geoData"`
+	GeoDataExif    *takeoutGeo  `json:"geoDataExif"`
+}
+
+// takeoutSidecarNames returns the names a Takeout sidecar for the file name may have,
+// most likely first: IMG_1234.jpg.json in older exports, and
+// IMG_1234.jpg.supplemental-metadata.json in newer ones, each possibly cut short and
+// carrying a duplicate's "(1)" just before ".json"
+func takeoutSidecarNames(name string) []string {
+	type sidecarBase struct{ name, counter string }
+	bases := []sidecarBase{{name, ""}}
+	if match := takeoutDuplicatePattern.FindStringSubmatch(name); match != nil {
+		bases = append(bases, sidecarBase{match[1] + match[3], match[2]})
+	}
+	// Edits share the original's sidecar
+	ext := filepath.Ext(name)
+	if stem := strings.TrimSuffix(name, ext); strings.HasSuffix(stem, "-edited") {
+		bases = append(bases, sidecarBase{strings.TrimSuffix(stem, "-edited") + ext, ""})
+	}
+
+	limit := takeoutMaxNameLength - len(".json")
+	var names []string
+	for _, base := range bases {
+		for _, stem := range []string{base.name, base.name + takeoutSupplementalSuffix} {
+			if len(stem) > limit {
+				stem = stem[:limit]
+			}
+			names = append(names, stem+base.counter+".json")
+		}
+	}
+	return names
+}
+
+// readTakeoutSidecar reads the Takeout sidecar next to path, if there is one, with
+// readFile, so sidecars are found inside an archived Takeout export too
+func readTakeoutSidecar(path string, readFile func(string) ([]byte, error)) (*takeoutSidecar, string, error) {
+	dir := filepath.Dir(path)
+	for _, name := range takeoutSidecarNames(filepath.Base(path)) {
+		data, err := readFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var sidecar takeoutSidecar
+		if err := json.Unmarshal(data, &sidecar); err != nil {
+			return nil, name, err
+		}
+		return &sidecar, name, nil
+	}
+	return nil, "", os.ErrNotExist
+}
+
+// position returns the sidecar's position, ignoring the zeroes Takeout writes when it
+// has none
+func (ts *takeoutSidecar) position() (takeoutGeo, bool) {
+	for _, geo := range []*takeoutGeo{ts.GeoData, ts.GeoDataExif} {
+		if geo != nil && (geo.Latitude != 0 || geo.Longitude != 0) {
+			return *geo, true
+		}
+	}
+	return takeoutGeo{}, false
+}
+
+// takenTime returns when the photo was taken, per the sidecar
+func (ts *takeoutSidecar) takenTime() (time.Time, bool) {
+	if ts.PhotoTakenTime == nil {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.ParseInt(ts.PhotoTakenTime.Timestamp.String(), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// applyTakeoutSidecar fills in the GPS position and capture date of a file exported by
+// Google Photos Takeout, which moves them out of the file into a JSON sidecar. Only what
+// the file's own metadata lacks is taken from the sidecar.
+func (org *Organizer) applyTakeoutSidecar(info *ImageInfo, path string) {
+	_, hasDate := info.dateCandidates[DateSourceMetadata]
+	needsGPS := !info.HasGPS && org.includeGPS()
+	if hasDate && !needsGPS {
+		return
+	}
+
+	sidecar, name, err := readTakeoutSidecar(path, org.readSource)
+	if os.IsNotExist(err) {
+		return
+	} else if err != nil {
+		org.safeLog(fmt.Sprintf("Warning: Could not read Takeout sidecar %s: %v\n", name, err))
+		return
+	}
+
+	var used []string
+	if geo, ok := sidecar.position(); ok && needsGPS {
+		info.HasGPS = true
+		info.Latitude, info.Longitude = geo.Latitude, geo.Longitude
+		info.Location = formatLocation(geo.Latitude, geo.Longitude, fileLocationDecimals)
+		if geo.Altitude != 0 {
+			info.Elevation, info.HasElevation = geo.Altitude, true
+		}
+		used = append(used, "location")
+	}
+	if taken, ok := sidecar.takenTime(); ok && !hasDate {
+		// Takeout records the instant in UTC, so the local time comes from the usual
+		// timezone settings
+		info.offerDate(DateSourceMetadata, taken, absoluteTime)
+		used = append(used, "capture date")
+	}
+	if len(used) > 0 {
+		org.safeLog(fmt.Sprintf("Using %s from Takeout sidecar %s\n", strings.Join(used, " and "), name))
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+func TestTakeoutSidecarNames(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"IMG_1234.jpg", []string{"IMG_1234.jpg.json", "IMG_1234.jpg.supplemental-metadata.json"}},
+		{"IMG_1234(1).jpg", []string{
+			"IMG_1234(1).jpg.json", "IMG_1234(1).jpg.supplemental-metadata.json",
+			"IMG_1234.jpg(1).json", "IMG_1234.jpg.supplemental-metadata(1).json",
+		}},
+		{"IMG_1234-edited.jpg", []string{
+			"IMG_1234-edited.jpg.json", "IMG_1234-edited.jpg.supplemental-metadata.json",
+			"IMG_1234.jpg.json", "IMG_1234.jpg.supplemental-metadata.json",
+		}},
+		// Cut short at 46 characters before ".json"
+		{"Screenshot_20240315-143022_Google Maps.jpg", []string{
+			"Screenshot_20240315-143022_Google Maps.jpg.json", "Screenshot_20240315-143022_Google Maps.jpg.sup.json",
+		}},
+		// A duplicate's counter follows the cut
+		{"Screenshot_20240315-143022_Google Maps(1).jpg", []string{
+			"Screenshot_20240315-143022_Google Maps(1).jpg.json", "Screenshot_20240315-143022_Google Maps(1).jpg..json",
+			"Screenshot_20240315-143022_Google Maps.jpg(1).json", "Screenshot_20240315-143022_Google Maps.jpg.sup(1).json",
+		}},
+	}
+	for _, tt := range tests {
+		if got := takeoutSidecarNames(tt.name); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: sidecars %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTakeoutSidecar(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	taken := time.Date(2023, 7, 1, 8, 0, 0, 0, time.UTC)
+	sidecar := `{"photoTakenTime": {"timestamp": "1688198400"}, "geoData": {"latitude": 0, "longitude": 0},` +
+		` "geoDataExif": {"latitude": 48.8584, "longitude": 2.2945, "altitude": 35}}`
+
+	tests := []struct {
+		name        string
+		file        []byte
+		sidecar     string
+		sidecarName string
+		date        time.Time
+		hasGPS      bool
+	}{
+		// The zeroes in geoData mean no position, so geoDataExif's is used
+		{"plain.png", nil, sidecar, "plain.png.supplemental-metadata.json", taken, true},
+		// A numeric timestamp, and no position at all
+		{"numeric.png", nil, `{"photoTakenTime": {"timestamp": 1688198400}}`, "numeric.png.json", taken, false},
+		// A newer export's sidecar for a duplicate, with the counter last
+		{"copy(1).png", nil, sidecar, "copy.png.supplemental-metadata(1).json", taken, true},
+		// The file's own capture date wins over the sidecar's
+		{"dated.jpg", exifJPEG(t, map[exif.FieldName]string{exif.DateTimeOriginal: "2020:01:02 03:04:05"}), sidecar, "dated.jpg.json",
+			time.Date(2020, 1, 2, 3, 4, 5, 0, time.Local), true},
+		// A sidecar that isn't JSON is ignored
+		{"broken.png", nil, `{"photoTakenTime":`, "broken.png.json", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		var path string
+		if tt.file != nil {
+			path = write(tt.name, tt.file)
+		} else {
+			path = filepath.Join(dir, tt.name)
+			writeTestPNG(t, path)
+		}
+		write(tt.sidecarName, []byte(tt.sidecar))
+
+		info, err := NewOrganizer(nil).extractImageInfo(path)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !tt.date.IsZero() && (!info.Date.Equal(tt.date) || info.DateSource != DateSourceMetadata) {
+			t.Errorf("%s: dated %v from %s, want %v from metadata", tt.name, info.Date, info.DateSource, tt.date)
+		}
+		if tt.date.IsZero() && info.DateSource == DateSourceMetadata {
+			t.Errorf("%s: dated %v from the broken sidecar", tt.name, info.Date)
+		}
+		if info.HasGPS != tt.hasGPS {
+			t.Errorf("%s: GPS %v, want %v", tt.name, info.HasGPS, tt.hasGPS)
+		} else if tt.hasGPS && (info.Latitude != 48.8584 || info.Longitude != 2.2945 || info.Elevation != 35) {
+			t.Errorf("%s: at %v, %v, %vm, want the sidecar's position", tt.name, info.Latitude, info.Longitude, info.Elevation)
+		}
+	}
+}
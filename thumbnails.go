@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register decoders for thumbnailing
+	_ "image/jpeg" // Register decoders for thumbnailing
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rwcarlsen/goexif/exif"
+	_ "golang.org/x/image/bmp" // Register decoders for thumbnailing
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/tiff" // Register decoders for thumbnailing
+	_ "golang.org/x/image/webp" // Register decoders for thumbnailing
+)
+
+const (
+	// ThumbnailSize is the longest edge of a generated thumbnail, in pixels
+	ThumbnailSize = 128
+	// MaxThumbnails limits how many thumbnails are shown for one selected folder
+	MaxThumbnails = 200
+	// thumbnailCacheVersion is bumped whenever generated thumbnails change, so stale
+	// cache entries are regenerated
+	thumbnailCacheVersion = 2
+)
+
+// ThumbnailCache generates small previews of media files and keeps them on disk, keyed
+// by content hash, so each file is only decoded once even if it is moved or renamed
+type ThumbnailCache struct {
+	dir string // Cache folder; empty disables the disk cache
+}
+
+// NewThumbnailCache creates a thumbnail cache in the user's cache folder, falling back
+// to generating thumbnails without caching when there is none
+func NewThumbnailCache() *ThumbnailCache {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return &ThumbnailCache{}
+	}
+	return &ThumbnailCache{dir: filepath.Join(cacheDir, "media-organizer", "thumbnails")}
+}
+
+// Thumbnail returns a thumbnail for the file at path, generating and caching it if needed
+func (tc *ThumbnailCache) Thumbnail(path string) (image.Image, error) {
+	hash, err := fileSHA256(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cachePath := ""
+	if tc.dir != "" {
+		cachePath = filepath.Join(tc.dir, hash[:2], fmt.Sprintf("%s-v%d.png", hash, thumbnailCacheVersion))
+		if cached, err := readPNG(cachePath); err == nil {
+			return cached, nil
+		}
+	}
+
+	source, orientation, err := decodeForThumbnail(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Orienting after scaling gives the same result for far less work
+	thumbnail := applyOrientation(scaleToFit(source, ThumbnailSize), orientation)
+
+	if cachePath != "" {
+		// A failed cache write only costs a decode next time
+		_ = writePNG(cachePath, thumbnail)
+	}
+	return thumbnail, nil
+}
+
+// decodeForThumbnail decodes the image at path, falling back to the JPEG preview
+// embedded in its EXIF data, which most RAW formats carry. It also returns the EXIF
+// orientation (1 when there is none).
+func decodeForThumbnail(path string) (image.Image, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	return decodeImage(file, path)
+}
+
+// decodeImage does the work of decodeForThumbnail, reading the file at path from file
+func decodeImage(file io.ReadSeeker, path string) (image.Image, int, error) {
+	img, _, decodeErr := image.Decode(file)
+
+	var exifData *exif.Exif
+	if _, err := file.Seek(0, 0); err == nil {
+		exifData, _ = decodeExif(file)
+	}
+
+	orientation := 1
+	if exifData != nil {
+		guardExif(func() error {
+			if tag, err := exifData.Get(exif.Orientation); err == nil {
+				if value, err := tag.Int(0); err == nil {
+					orientation = value
+				}
+			}
+			return nil
+		})
+	}
+
+	if decodeErr == nil {
+		return img, orientation, nil
+	}
+
+	if exifData == nil {
+		return nil, 0, fmt.Errorf("cannot decode %s: %v", filepath.Base(path), decodeErr)
+	}
+	var preview []byte
+	err := guardExif(func() error {
+		var err error
+		preview, err = exifData.JpegThumbnail()
+		return err
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("cannot decode %s: %v", filepath.Base(path), decodeErr)
+	}
+	img, _, err = image.Decode(bytes.NewReader(preview))
+	return img, orientation, err
+}
+
+// applyOrientation returns img transformed for display according to an EXIF Orientation
+// value: 1 is upright, 2-4 mirror or turn it over, and 5-8 swap its width and height.
+// Unknown values leave img unchanged.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	// source maps a destination pixel to the source pixel it shows
+	var source func(x, y int) (int, int)
+	dstWidth, dstHeight := width, height
+	switch orientation {
+	case 2: // Mirrored horizontally
+		source = func(x, y int) (int, int) { return width - 1 - x, y }
+	case 3: // Rotated 180°
+		source = func(x, y int) (int, int) { return width - 1 - x, height - 1 - y }
+	case 4: // Mirrored vertically
+		source = func(x, y int) (int, int) { return x, height - 1 - y }
+	case 5: // Mirrored along the top-left to bottom-right diagonal
+		source = func(x, y int) (int, int) { return y, x }
+	case 6: // Needs turning 90° clockwise
+		source = func(x, y int) (int, int) { return y, height - 1 - x }
+	case 7: // Mirrored along the top-right to bottom-left diagonal
+		source = func(x, y int) (int, int) { return width - 1 - y, height - 1 - x }
+	case 8: // Needs turning 90° counterclockwise
+		source = func(x, y int) (int, int) { return width - 1 - y, x }
+	}
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	oriented := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			sx, sy := source(x, y)
+			oriented.Set(x, y, img.At(bounds.Min.X+sx, bounds.Min.Y+sy))
+		}
+	}
+	return oriented
+}
+
+// scaleToFit shrinks img so its longest edge is at most size pixels
+func scaleToFit(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.ApproxBiLinear.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+	return scaled
+}
+
+// readPNG decodes a cached thumbnail
+func readPNG(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return png.Decode(file)
+}
+
+// writePNG saves a thumbnail, writing to a temporary file first so a crash never
+// leaves a truncated cache entry behind
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".thumbnail-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := png.Encode(tmp, img); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
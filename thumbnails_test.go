@@ -0,0 +1,52 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+func TestApplyOrientation(t *testing.T) {
+	// A 2×3 image whose pixels are labeled by letter, away from the origin so offset
+	// bounds are covered too:
+	//
+	//	A B
+	//	C D
+	//	E F
+	source := image.NewGray(image.Rect(10, 20, 12, 23))
+	for i, label := range "ABCDEF" {
+		source.SetGray(10+i%2, 20+i/2, color.Gray{Y: uint8(label)})
+	}
+
+	tests := []struct {
+		orientation int
+		want        []string // Rows of the oriented image
+	}{
+		{1, []string{"AB", "CD", "EF"}},
+		{2, []string{"BA", "DC", "FE"}},
+		{3, []string{"FE", "DC", "BA"}},
+		{4, []string{"EF", "CD", "AB"}},
+		{5, []string{"ACE", "BDF"}},
+		{6, []string{"ECA", "FDB"}},
+		{7, []string{"FDB", "ECA"}},
+		{8, []string{"BDF", "ACE"}},
+		{0, []string{"AB", "CD", "EF"}}, // Unknown values leave the image as it is
+		{9, []string{"AB", "CD", "EF"}},
+	}
+	for _, tt := range tests {
+		oriented := applyOrientation(source, tt.orientation)
+		bounds := oriented.Bounds()
+		var got []string
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			row := ""
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				row += string(rune(color.GrayModel.Convert(oriented.At(x, y)).(color.Gray).Y))
+			}
+			got = append(got, row)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("orientation %d: got %v, want %v", tt.orientation, got, tt.want)
+		}
+	}
+}
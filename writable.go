@@ -0,0 +1,43 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// errOutputUnwritable is returned when the output folder can't be written to, before a
+// run or because its drive went away or became read-only during one
+var errOutputUnwritable = errors.New("the output folder can't be written to")
+
+// checkWritable reports whether files can be created in folder by creating and removing
+// one. A folder that doesn't exist yet is checked through the nearest folder above it
+// that does, since the run creates it there.
+func checkWritable(folder string) error {
+	dir := filepath.Clean(folder)
+	for {
+		stat, err := os.Stat(dir)
+		if err == nil {
+			if !stat.IsDir() {
+				return fmt.Errorf("%w: %s is not a folder", errOutputUnwritable, dir)
+			}
+			break
+		}
+		if !os.IsNotExist(err) || dir == filepath.Dir(dir) {
+			return fmt.Errorf("%w: %v", errOutputUnwritable, err)
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	ioPath, _ := longPath(dir)
+	probe, err := os.CreateTemp(ioPath, ".organizer-write-check-*")
+	if err != nil {
+		return fmt.Errorf("%w: %v", errOutputUnwritable, err)
+	}
+	probe.Close()
+	if err := os.Remove(probe.Name()); err != nil {
+		return fmt.Errorf("%w: %v", errOutputUnwritable, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(file, []byte("photo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkWritable(dir); err != nil {
+		t.Errorf("writable folder: %v", err)
+	}
+	missing := filepath.Join(dir, "organized", "2024")
+	if err := checkWritable(missing); err != nil {
+		t.Errorf("missing folder below a writable one: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "organized")); !os.IsNotExist(err) {
+		t.Error("checking a missing folder created it")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("the write check left %d files behind", len(entries)-1)
+	}
+
+	for _, path := range []string{file, filepath.Join(file, "organized")} {
+		if err := checkWritable(path); !errors.Is(err, errOutputUnwritable) {
+			t.Errorf("%s: %v, want errOutputUnwritable", path, err)
+		}
+	}
+
+	// Validate runs the same check
+	org := NewOrganizer(nil)
+	org.sourceFolder, org.outputFolder = dir, file
+	if err := org.Validate(); !errors.Is(err, errOutputUnwritable) {
+		t.Errorf("Validate with a file as output: %v, want errOutputUnwritable", err)
+	}
+}
+
+func TestCheckWritableReadOnly(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "locked")
+	if err := os.Mkdir(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(dir, 0755)
+	if probe, err := os.Create(filepath.Join(dir, "probe")); err == nil {
+		probe.Close()
+		t.Skip("permissions aren't enforced for this user")
+	}
+
+	for _, path := range []string{dir, filepath.Join(dir, "organized")} {
+		if err := checkWritable(path); !errors.Is(err, errOutputUnwritable) {
+			t.Errorf("%s: %v, want errOutputUnwritable", path, err)
+		}
+	}
+}